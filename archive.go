@@ -0,0 +1,165 @@
+package youtube
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ArchiveEntry records a video downloaded into an archive along with its format.
+type ArchiveEntry struct {
+	VideoID      string        `json:"video_id"`
+	Path         string        `json:"path"`
+	Title        string        `json:"title,omitempty"`
+	Duration     time.Duration `json:"duration,omitempty"`
+	Format       Format        `json:"format"`
+	DownloadedAt time.Time     `json:"downloaded_at"`
+}
+
+// Archive records the videos downloaded by Youtube.Sync, so an archive is
+// kept up to date without downloading the same videos again. It is stored as
+// a json entry per line, appended to on each download, the last entry of a
+// video wins. Share it between the Youtube of a process through Options.Archive.
+//
+// Several processes may share the archive, such as a cron run of youtubedr
+// and youtubed: the entries recorded by the others are read before each
+// lookup, and the records are serialized by a lock file.
+type Archive struct {
+	path    string
+	mu      sync.Mutex
+	entries map[string]ArchiveEntry
+	// offset is the end of the last complete line read.
+	offset int64
+}
+
+// OpenArchive reads the archive at path, a missing file is an empty archive.
+// A truncated last line, left by a crash, is ignored.
+func OpenArchive(path string) (*Archive, error) {
+	a := &Archive{path: path, entries: make(map[string]ArchiveEntry)}
+	if err := a.read(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// read reads the entries appended since the last read. A last line without
+// newline is being written by another process, or was truncated by a crash,
+// it is left out.
+func (a *Archive) read() error {
+	f, err := os.Open(a.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.Seek(a.offset, io.SeekStart); err != nil {
+		return err
+	}
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var entry ArchiveEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return err
+		}
+		a.entries[entry.VideoID] = entry
+		a.offset += int64(len(line))
+	}
+}
+
+// Entry returns the last entry of the video, including the ones recorded by
+// the other processes sharing the archive.
+func (a *Archive) Entry(videoID string) (ArchiveEntry, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	// an unreadable update keeps the entries known
+	a.read()
+	entry, ok := a.entries[videoID]
+	return entry, ok
+}
+
+// Entries returns the last entry of every video, sorted by video id.
+func (a *Archive) Entries() []ArchiveEntry {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.read()
+	entries := make([]ArchiveEntry, 0, len(a.entries))
+	for _, entry := range a.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].VideoID < entries[j].VideoID })
+	return entries
+}
+
+// Record appends entry to the archive.
+func (a *Archive) Record(entry ArchiveEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	lock, err := lockFile(a.path, true)
+	if err != nil {
+		return err
+	}
+	defer lock.unlock()
+	if err := a.read(); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(a.path, os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	// no other process is writing, a line past offset was truncated by a crash
+	if err := f.Truncate(a.offset); err != nil {
+		f.Close()
+		return err
+	}
+	line = append(line, '\n')
+	if _, err := f.WriteAt(line, a.offset); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	a.offset += int64(len(line))
+	a.entries[entry.VideoID] = entry
+	return nil
+}
+
+// UpgradePolicy decides whether Youtube.Sync downloads an archived video again.
+type UpgradePolicy string
+
+// The upgrade policies.
+const (
+	// UpgradeNever skips the archived videos, it is the default.
+	UpgradeNever UpgradePolicy = "never"
+	// UpgradeBetter downloads an archived video again when a better format
+	// than the archived one is available, such as a 4K remaster.
+	UpgradeBetter UpgradePolicy = "better"
+	// UpgradeAlways downloads the archived videos again.
+	UpgradeAlways UpgradePolicy = "always"
+)
+
+func (p UpgradePolicy) validate() ErrInvalidOptions {
+	switch p {
+	case "", UpgradeNever, UpgradeBetter, UpgradeAlways:
+		return nil
+	}
+	return ErrInvalidOptions{ErrInvalidOption{Option: "Upgrade", Reason: "unknown upgrade policy " + string(p)}}
+}
@@ -0,0 +1,51 @@
+package youtube
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestArchive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "youtube")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "archive.jsonl")
+
+	archive, err := OpenArchive(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range []ArchiveEntry{
+		{VideoID: "BaW_jenozKc", Path: "a.mp4", Format: Format{ItagNo: 18, Height: 360}},
+		{VideoID: "rFejpH_tAHM", Path: "b.mp4", Format: Format{ItagNo: 18, Height: 360}},
+		{VideoID: "BaW_jenozKc", Path: "a.mp4", Format: Format{ItagNo: 22, Height: 720}},
+	} {
+		if err := archive.Record(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// a crash in the middle of a record
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	f.WriteString(`{"video_id":"9bZkp7q19f0","pa`)
+	f.Close()
+
+	archive, err = OpenArchive(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry, ok := archive.Entry("BaW_jenozKc"); !ok || entry.Format.ItagNo != 22 {
+		t.Errorf("Entry() = %+v, %v, want the last record", entry, ok)
+	}
+	if _, ok := archive.Entry("9bZkp7q19f0"); ok {
+		t.Error("Entry() found the truncated record")
+	}
+
+	ioutil.WriteFile(path, []byte("{\"video_id\":\ntruncated\n{}\n"), 0644)
+	if _, err := OpenArchive(path); err == nil {
+		t.Error("OpenArchive() accepted an archive damaged before its last line")
+	}
+}
@@ -0,0 +1,104 @@
+package youtube
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// AuditStatus is the outcome of the audit of an archived video.
+type AuditStatus string
+
+// The statuses of AuditResult, from the healthiest.
+const (
+	// AuditOK is a video unchanged since it was archived.
+	AuditOK AuditStatus = "ok"
+	// AuditRetitled is a video whose title changed since it was archived.
+	AuditRetitled AuditStatus = "retitled"
+	// AuditReuploaded is a video whose content was replaced since it was
+	// archived, its duration changed.
+	AuditReuploaded AuditStatus = "reuploaded"
+	// AuditRemoved is a video youtube doesn't play anymore: deleted, made
+	// private or blocked.
+	AuditRemoved AuditStatus = "removed"
+	// AuditMissing is a video whose archived file is gone.
+	AuditMissing AuditStatus = "missing"
+	// AuditError is a video which couldn't be checked, such as on a network
+	// failure, auditing again may succeed.
+	AuditError AuditStatus = "error"
+)
+
+// reuploadTolerance is the difference of duration above which an archived
+// video is considered replaced, the durations are rounded differently.
+const reuploadTolerance = 2 * time.Second
+
+// AuditResult is the audit of an archived video.
+type AuditResult struct {
+	Entry  ArchiveEntry `json:"entry"`
+	Status AuditStatus  `json:"status"`
+	// Title and Duration are the current ones, when the video still plays.
+	Title    string        `json:"title,omitempty"`
+	Duration time.Duration `json:"duration,omitempty"`
+	// Error is the failure of the check, for AuditRemoved and AuditError.
+	Error string `json:"error,omitempty"`
+}
+
+// AuditArchive checks the videos of archive against youtube without
+// downloading anything: the metadata of each archived video is resolved
+// again, bypassing the MetadataCache, and compared to the archived one. The
+// archived files are only checked for existence, see Verify for their
+// content. It stops when ctx is done, returning the videos checked so far.
+func (y *Youtube) AuditArchive(ctx context.Context, archive *Archive) ([]AuditResult, error) {
+	var results []AuditResult
+	for _, entry := range archive.Entries() {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		results = append(results, y.audit(ctx, entry))
+	}
+	return results, nil
+}
+
+func (y *Youtube) audit(ctx context.Context, entry ArchiveEntry) AuditResult {
+	result := AuditResult{Entry: entry, Status: AuditOK}
+	if _, err := os.Stat(entry.Path); os.IsNotExist(err) {
+		result.Status = AuditMissing
+	}
+
+	probe, err := NewYoutubeWithOptions(y.options())
+	if err != nil {
+		result.Status, result.Error = AuditError, err.Error()
+		return result
+	}
+	probe.VideoID = entry.VideoID
+	if err := probe.decode(ctx, true); err != nil {
+		y.log(fmt.Sprintf("audit of %s failed: %s", entry.VideoID, err))
+		result.Status, result.Error = AuditError, err.Error()
+		if unplayable(err) {
+			result.Status = AuditRemoved
+		}
+		return result
+	}
+	result.Title = probe.StreamList[0].Title
+	result.Duration = probe.duration()
+	if result.Status != AuditOK {
+		return result
+	}
+	switch {
+	case entry.Duration > 0 && result.Duration > 0 &&
+		(result.Duration-entry.Duration > reuploadTolerance || entry.Duration-result.Duration > reuploadTolerance):
+		result.Status = AuditReuploaded
+	case entry.Title != "" && result.Title != entry.Title:
+		result.Status = AuditRetitled
+	}
+	return result
+}
+
+// unplayable reports whether err is youtube refusing to play the video, as
+// opposed to a failure to reach it.
+func unplayable(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "'fail' response status") || strings.Contains(msg, "Cannot playback")
+}
@@ -0,0 +1,107 @@
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestYoutube_AuditArchive(t *testing.T) {
+	videos := map[string]struct{ title, length string }{
+		"BaW_jenozKc": {"youtube-dl test video", "10"},
+		"rFejpH_tAHM": {"Simplicity is Complicated (remastered)", "1401"},
+		"9bZkp7q19f0": {"PSY - GANGNAM STYLE", "300"},
+		"n3kPvBCYT3E": {"Missing locally", "60"},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		videoID := r.URL.Query().Get("video_id")
+		if videoID == "54e6lBE3BoQ" {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		video, ok := videos[videoID]
+		if !ok {
+			w.Write([]byte(url.Values{"status": {"fail"}, "reason": {"Video unavailable"}}.Encode()))
+			return
+		}
+		playerResponse, _ := json.Marshal(map[string]interface{}{
+			"playabilityStatus": map[string]string{"status": "OK"},
+			"videoDetails":      map[string]string{"title": video.title, "author": "gopher", "lengthSeconds": video.length},
+			"streamingData": map[string]interface{}{
+				"formats": []map[string]interface{}{
+					{"itag": 18, "url": "https://r1.googlevideo.com/videoplayback?itag=18", "mimeType": "video/mp4", "quality": "medium"},
+				},
+			},
+		})
+		w.Write([]byte(url.Values{"status": {"ok"}, "player_response": {string(playerResponse)}}.Encode()))
+	}))
+	defer server.Close()
+	defer func(url string) { baseURL = url }(baseURL)
+	baseURL = server.URL
+
+	dir, err := ioutil.TempDir("", "youtube")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	archive, err := OpenArchive(filepath.Join(dir, "archive.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]AuditStatus{
+		"BaW_jenozKc": AuditOK,
+		"rFejpH_tAHM": AuditRetitled,
+		"9bZkp7q19f0": AuditReuploaded,
+		"dQw4w9WgXcQ": AuditRemoved,
+		"n3kPvBCYT3E": AuditMissing,
+		"54e6lBE3BoQ": AuditError,
+	}
+	entries := []ArchiveEntry{
+		{VideoID: "BaW_jenozKc", Title: "youtube-dl test video", Duration: 10 * time.Second},
+		{VideoID: "rFejpH_tAHM", Title: "Simplicity is Complicated", Duration: 1401 * time.Second},
+		{VideoID: "9bZkp7q19f0", Title: "PSY - GANGNAM STYLE", Duration: 253 * time.Second},
+		{VideoID: "dQw4w9WgXcQ", Title: "Never Gonna Give You Up", Duration: 213 * time.Second},
+		{VideoID: "n3kPvBCYT3E", Title: "Missing locally", Duration: 60 * time.Second},
+		{VideoID: "54e6lBE3BoQ", Title: "Unreachable", Duration: 60 * time.Second},
+	}
+	for _, entry := range entries {
+		entry.Path = filepath.Join(dir, entry.VideoID+".mp4")
+		if entry.VideoID != "n3kPvBCYT3E" {
+			ioutil.WriteFile(entry.Path, []byte("media"), 0644)
+		}
+		if err := archive.Record(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	results, err := NewYoutube(false).AuditArchive(context.Background(), archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != len(want) {
+		t.Fatalf("AuditArchive() checked %d videos, want %d", len(results), len(want))
+	}
+	for _, result := range results {
+		if result.Status != want[result.Entry.VideoID] {
+			t.Errorf("audit of %s = %s (%s), want %s", result.Entry.VideoID, result.Status, result.Error, want[result.Entry.VideoID])
+		}
+	}
+	for _, result := range results {
+		if result.Entry.VideoID == "rFejpH_tAHM" && result.Title != "Simplicity is Complicated (remastered)" {
+			t.Errorf("current title = %q", result.Title)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if results, err := NewYoutube(false).AuditArchive(ctx, archive); err != context.Canceled || len(results) != 0 {
+		t.Errorf("AuditArchive() canceled = %d results, %v", len(results), err)
+	}
+}
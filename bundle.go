@@ -0,0 +1,390 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// BundleOptions selects what DownloadBundle fetches along with the media.
+type BundleOptions struct {
+	DownloadOptions
+	// Captions are the language codes of the caption tracks to save as
+	// WebVTT, the captions written by the uploader are preferred over the
+	// automatic ones.
+	Captions []string
+	// Thumbnail saves the largest thumbnail of the video.
+	Thumbnail bool
+	// Metadata saves the title, author, description and formats of the
+	// video in a json sidecar.
+	Metadata bool
+	// NFO saves the title, author and description of the video in a .nfo
+	// sidecar, read by media centers such as Kodi and Jellyfin.
+	NFO bool
+	// Merge, when set and the selected format is video only, downloads the
+	// best audio only format too and merges both into the media file.
+	Merge Processor
+}
+
+// Bundle holds the paths of the files written by DownloadBundle.
+type Bundle struct {
+	Media     string
+	Captions  []string
+	Thumbnail string
+	Metadata  string
+	NFO       string
+}
+
+// bundleMetadata is the json sidecar of a bundle.
+type bundleMetadata struct {
+	VideoID     string      `json:"video_id"`
+	Title       string      `json:"title"`
+	Author      string      `json:"author"`
+	ChannelID   string      `json:"channel_id,omitempty"`
+	Duration    float64     `json:"duration"`
+	Description string      `json:"description,omitempty"`
+	Format      Format      `json:"format"`
+	Formats     []Format    `json:"formats"`
+	Thumbnails  []Thumbnail `json:"thumbnails,omitempty"`
+}
+
+// stagedMediaName is the name of the media file in the staging directory of
+// a bundle, ahead of its extension.
+const stagedMediaName = ".media"
+
+// DownloadBundle downloads the media of the decoded video along with the
+// captions, thumbnail and metadata sidecars selected by opts, concurrently,
+// into the output directory. The files are named after the media file, such
+// as "title.mp4", "title.en.vtt", "title.jpg", "title.info.json" and
+// "title.nfo".
+//
+// The bundle is all or nothing: the files are fetched, and merged, into a
+// hidden staging directory and only moved into the output directory once all
+// of them succeeded, a failure removes every file of the bundle. The media
+// file is moved last, so a media scanner finding it finds its sidecars too.
+func (y *Youtube) DownloadBundle(ctx context.Context, opts BundleOptions) (*Bundle, error) {
+	dlOpts, s, err := y.prepareDownload(opts.DownloadOptions)
+	if err != nil {
+		return nil, err
+	}
+	tracks, err := y.captionTracks(opts.Captions)
+	if err != nil {
+		return nil, err
+	}
+	var thumbnail Thumbnail
+	if opts.Thumbnail {
+		thumbnails := y.Thumbnails()
+		if len(thumbnails) == 0 {
+			return nil, ErrThumbnailNotFound
+		}
+		thumbnail = largestThumbnail(thumbnails)
+	}
+	var audio stream
+	merge := opts.Merge != nil && s.Adaptive && strings.HasPrefix(s.Type, "video/")
+	if merge {
+		var ok bool
+		if audio, ok = y.bestAudioStream(); !ok {
+			return nil, ErrNoAudioFormat
+		}
+	}
+
+	outputDir := dlOpts.OutputDir
+	if outputDir == "" {
+		if outputDir, err = defaultOutputDir(); err != nil {
+			return nil, err
+		}
+	}
+	mediaFile := dlOpts.Filesystem.FileName(outputDir, dlOpts.OutputFile)
+	if mediaFile == "" {
+		mediaFile = dlOpts.Filesystem.FileName(outputDir, s.Title+pickIdealFileExtension(s.Type))
+	}
+	ext := filepath.Ext(mediaFile)
+	if strings.Contains(ext, " ") {
+		// a dot in a title rather than an extension
+		ext = ""
+	}
+	base := strings.TrimSuffix(mediaFile, ext)
+	// the media is staged under a short name, the name of the media file
+	// would be shortened again for the longer path of the staging directory
+	stagedMedia := stagedMediaName + ext
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return nil, err
+	}
+	staging, err := ioutil.TempDir(outputDir, ".bundle-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(staging)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		files    []string
+	)
+	// fetch runs write in the background, the first failure cancels the others
+	fetch := func(name string, write func(dest string) error) {
+		files = append(files, name)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := write(filepath.Join(staging, name)); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	bundle := &Bundle{}
+	var mediaPath string
+	fetch(stagedMedia, func(dest string) error {
+		mediaOpts := dlOpts
+		mediaOpts.OutputDir, mediaOpts.OutputFile = staging, stagedMedia
+		if !merge {
+			var err error
+			mediaPath, err = y.download(ctx, mediaOpts)
+			return err
+		}
+		mediaPath = dest
+		return y.downloadMerged(ctx, mediaOpts, s, audio, opts.Merge, dest)
+	})
+	for _, track := range tracks {
+		name := base + "." + track.LanguageCode + ".vtt"
+		bundle.Captions = append(bundle.Captions, filepath.Join(outputDir, name))
+		trackURL := track.BaseURL
+		fetch(name, func(dest string) error {
+			return y.fetchCaption(ctx, trackURL, dest)
+		})
+	}
+	if opts.Thumbnail {
+		name := base + thumbnailExtension(thumbnail.URL)
+		bundle.Thumbnail = filepath.Join(outputDir, name)
+		fetch(name, func(dest string) error {
+			data, err := y.FetchThumbnail(ctx, thumbnail.URL)
+			if err != nil {
+				return err
+			}
+			return ioutil.WriteFile(dest, data, 0644)
+		})
+	}
+	if opts.Metadata {
+		name := base + ".info.json"
+		bundle.Metadata = filepath.Join(outputDir, name)
+		metadata := y.bundleMetadata(s)
+		fetch(name, func(dest string) error {
+			data, err := json.MarshalIndent(metadata, "", "  ")
+			if err != nil {
+				return err
+			}
+			return ioutil.WriteFile(dest, data, 0644)
+		})
+	}
+	if opts.NFO {
+		name := base + ".nfo"
+		bundle.NFO = filepath.Join(outputDir, name)
+		nfo := y.bundleNFO(s, thumbnail)
+		fetch(name, func(dest string) error {
+			data, err := xml.MarshalIndent(nfo, "", "  ")
+			if err != nil {
+				return err
+			}
+			return ioutil.WriteFile(dest, append([]byte(xml.Header), data...), 0644)
+		})
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	// the staged media file may have been renamed by FixExtension
+	files[0] = base + strings.TrimPrefix(filepath.Base(mediaPath), stagedMediaName)
+	if err := os.Rename(mediaPath, filepath.Join(staging, files[0])); err != nil {
+		return nil, err
+	}
+	bundle.Media = filepath.Join(outputDir, files[0])
+
+	if err := finalizeBundle(staging, outputDir, files); err != nil {
+		return nil, err
+	}
+	return bundle, nil
+}
+
+// finalizeBundle moves files from staging into outputDir, the sidecars first
+// and the media, files[0], last. The files already moved are taken back on
+// failure.
+func finalizeBundle(staging, outputDir string, files []string) error {
+	order := append(append([]string(nil), files[1:]...), files[0])
+	for i, name := range order {
+		if err := os.Rename(filepath.Join(staging, name), filepath.Join(outputDir, name)); err != nil {
+			for _, moved := range order[:i] {
+				os.Remove(filepath.Join(outputDir, moved))
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// downloadMerged downloads the video stream of opts and the audio stream into
+// the output directory of opts, a staging directory, and merges them into
+// dest with processor.
+func (y *Youtube) downloadMerged(ctx context.Context, opts DownloadOptions, video, audio stream, processor Processor, dest string) error {
+	y.progress = newAggregateProgress([]stream{video, audio})
+	defer func() { y.progress = nil }()
+	y.downloadLevel = 0
+
+	videoOpts := opts
+	videoOpts.OutputFile = ".video" + pickIdealFileExtension(video.Type)
+	videoPath, err := y.download(ctx, videoOpts)
+	defer os.Remove(videoPath)
+	if err != nil {
+		return err
+	}
+	y.progress.next()
+
+	audioOpts := opts
+	audioOpts.ItagNo, audioOpts.Quality = audio.ItagNo, ""
+	audioOpts.OutputFile = ".audio" + pickIdealFileExtension(audio.Type)
+	audioPath, err := y.download(ctx, audioOpts)
+	defer os.Remove(audioPath)
+	if err != nil {
+		return err
+	}
+	return processor.Merge(ctx, videoPath, audioPath, dest)
+}
+
+// captionTrack is a caption track of the decoded video.
+type captionTrack struct {
+	BaseURL      string
+	LanguageCode string
+}
+
+// captionTracks returns the caption tracks of the languages, the tracks of
+// the uploader are preferred over the automatic ones, "asr".
+func (y *Youtube) captionTracks(languages []string) ([]captionTrack, error) {
+	if len(languages) == 0 {
+		return nil, nil
+	}
+	if y.playerResponse == nil {
+		return nil, ErrCaptionNotFound
+	}
+	var tracks []captionTrack
+	for _, language := range languages {
+		var found *captionTrack
+		for _, track := range y.playerResponse.Captions.PlayerCaptionsTracklistRenderer.CaptionTracks {
+			if track.LanguageCode != language || (found != nil && track.Kind == "asr") {
+				continue
+			}
+			found = &captionTrack{BaseURL: track.BaseURL, LanguageCode: track.LanguageCode}
+		}
+		if found == nil {
+			return nil, ErrCaptionNotFound
+		}
+		tracks = append(tracks, *found)
+	}
+	return tracks, nil
+}
+
+// fetchCaption writes the caption track at trackURL to dest as WebVTT.
+func (y *Youtube) fetchCaption(ctx context.Context, trackURL, dest string) error {
+	u, err := url.Parse(trackURL)
+	if err != nil {
+		return err
+	}
+	query := u.Query()
+	query.Set("fmt", "vtt")
+	u.RawQuery = query.Encode()
+	data, err := y.httpGetBody(ctx, u.String())
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(dest, data, 0644)
+}
+
+// largestThumbnail returns the widest of thumbnails.
+func largestThumbnail(thumbnails []Thumbnail) Thumbnail {
+	largest := thumbnails[0]
+	for _, t := range thumbnails[1:] {
+		if t.Width > largest.Width {
+			largest = t
+		}
+	}
+	return largest
+}
+
+// thumbnailExtension returns the extension of the image at rawURL, .jpg by default.
+func thumbnailExtension(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil {
+		switch ext := path.Ext(u.Path); ext {
+		case ".jpg", ".png", ".webp":
+			return ext
+		}
+	}
+	return ".jpg"
+}
+
+// bundleNFO is the .nfo sidecar of a bundle, in the movie format of Kodi.
+type bundleNFO struct {
+	XMLName  xml.Name `xml:"movie"`
+	Title    string   `xml:"title"`
+	Plot     string   `xml:"plot,omitempty"`
+	Studio   string   `xml:"studio,omitempty"`
+	Runtime  int      `xml:"runtime,omitempty"`
+	Thumb    string   `xml:"thumb,omitempty"`
+	UniqueID nfoID    `xml:"uniqueid"`
+}
+
+type nfoID struct {
+	Type    string `xml:"type,attr"`
+	Default bool   `xml:"default,attr"`
+	ID      string `xml:",chardata"`
+}
+
+// bundleNFO returns the .nfo sidecar of the download of s, the runtime is in minutes.
+func (y *Youtube) bundleNFO(s stream, thumbnail Thumbnail) bundleNFO {
+	nfo := bundleNFO{
+		Title:    s.Title,
+		Studio:   s.Author,
+		Runtime:  int(y.duration().Minutes() + 0.5),
+		Thumb:    thumbnail.URL,
+		UniqueID: nfoID{Type: "youtube", Default: true, ID: y.VideoID},
+	}
+	if y.playerResponse != nil {
+		nfo.Plot = y.playerResponse.VideoDetails.ShortDescription
+	}
+	return nfo
+}
+
+// bundleMetadata returns the metadata sidecar of the download of s.
+func (y *Youtube) bundleMetadata(s stream) bundleMetadata {
+	metadata := bundleMetadata{
+		VideoID:    y.VideoID,
+		Title:      s.Title,
+		Author:     s.Author,
+		Duration:   y.duration().Seconds(),
+		Format:     s.format(),
+		Formats:    y.Formats(),
+		Thumbnails: y.Thumbnails(),
+	}
+	if y.playerResponse != nil {
+		metadata.ChannelID = y.playerResponse.VideoDetails.ChannelID
+		metadata.Description = y.playerResponse.VideoDetails.ShortDescription
+	}
+	return metadata
+}
@@ -0,0 +1,243 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package youtube
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestYoutube_DownloadBundle(t *testing.T) {
+	media := append(append([]byte{}, mp4Header...), bytes.Repeat([]byte{1}, 1000)...)
+	mediaServer := newMediaServer(media)
+	defer mediaServer.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/timedtext" && r.URL.Query().Get("fmt") == "vtt" && r.URL.Query().Get("kind") == "":
+			w.Write([]byte("WEBVTT\n\n00:00.000 --> 00:01.000\n" + r.URL.Query().Get("lang") + "\n"))
+		case r.URL.Path == "/vi/rFejpH_tAHM/maxresdefault.webp":
+			w.Write([]byte("RIFF"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "youtube")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	y := NewYoutube(false)
+	y.VideoID = "rFejpH_tAHM"
+	y.StreamList = []stream{{ItagNo: 18, Type: "video/mp4", URL: mediaServer.URL, Title: "talk", Author: "dotconferences"}}
+	playerResponse := `{
+		"captions": {"playerCaptionsTracklistRenderer": {"captionTracks": [
+			{"baseUrl": "` + server.URL + `/timedtext?lang=en&kind=asr", "languageCode": "en", "kind": "asr"},
+			{"baseUrl": "` + server.URL + `/timedtext?lang=en", "languageCode": "en"},
+			{"baseUrl": "` + server.URL + `/timedtext?lang=fr", "languageCode": "fr"},
+			{"baseUrl": "` + server.URL + `/missing?lang=de", "languageCode": "de"}
+		]}},
+		"videoDetails": {"lengthSeconds": "1401", "thumbnail": {"thumbnails": [
+			{"url": "` + server.URL + `/vi/rFejpH_tAHM/default.jpg", "width": 120},
+			{"url": "` + server.URL + `/vi/rFejpH_tAHM/maxresdefault.webp", "width": 1280}
+		]}}
+	}`
+	if err := json.Unmarshal([]byte(playerResponse), &y.playerResponse); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	bundle, err := y.DownloadBundle(ctx, BundleOptions{
+		DownloadOptions: DownloadOptions{OutputDir: dir},
+		Captions:        []string{"en", "fr"},
+		Thumbnail:       true,
+		Metadata:        true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Bundle{
+		Media:     filepath.Join(dir, "talk.mp4"),
+		Captions:  []string{filepath.Join(dir, "talk.en.vtt"), filepath.Join(dir, "talk.fr.vtt")},
+		Thumbnail: filepath.Join(dir, "talk.webp"),
+		Metadata:  filepath.Join(dir, "talk.info.json"),
+	}
+	if !reflect.DeepEqual(bundle, want) {
+		t.Errorf("DownloadBundle() = %+v, want %+v", bundle, want)
+	}
+	if got, _ := ioutil.ReadFile(bundle.Captions[0]); !bytes.HasSuffix(got, []byte("\nen\n")) {
+		t.Errorf("captions = %q, want the uploader track", got)
+	}
+	var metadata bundleMetadata
+	data, _ := ioutil.ReadFile(bundle.Metadata)
+	if err := json.Unmarshal(data, &metadata); err != nil || metadata.Title != "talk" || metadata.Duration != 1401 || metadata.Format.ItagNo != 18 {
+		t.Errorf("metadata = %s", data)
+	}
+	if entries, _ := ioutil.ReadDir(dir); len(entries) != 5 {
+		t.Errorf("%d files in the output directory, want the 5 files of the bundle", len(entries))
+	}
+
+	// a failing caption leaves nothing behind
+	empty, err := ioutil.TempDir("", "youtube")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(empty)
+	_, err = y.DownloadBundle(ctx, BundleOptions{
+		DownloadOptions: DownloadOptions{OutputDir: empty},
+		Captions:        []string{"en", "de"},
+		Thumbnail:       true,
+	})
+	if err != ErrUnexpectedStatusCode(http.StatusNotFound) {
+		t.Errorf("DownloadBundle() error = %v, want the caption failure", err)
+	}
+	if entries, _ := ioutil.ReadDir(empty); len(entries) != 0 {
+		t.Errorf("%d files left in the output directory after a failure", len(entries))
+	}
+
+	if _, err := y.DownloadBundle(ctx, BundleOptions{Captions: []string{"ja"}}); err != ErrCaptionNotFound {
+		t.Errorf("DownloadBundle() error = %v, want ErrCaptionNotFound", err)
+	}
+}
+
+func TestYoutube_DownloadBundle_LongTitle(t *testing.T) {
+	media := append(append([]byte{}, mp4Header...), bytes.Repeat([]byte{1}, 1000)...)
+	mediaServer := newMediaServer(media)
+	defer mediaServer.Close()
+
+	dir, err := ioutil.TempDir("", "youtube")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	y := NewYoutube(false)
+	y.VideoID = "rFejpH_tAHM"
+	title := strings.Repeat("Simplicity is Complicated ", 12)
+	y.StreamList = []stream{{ItagNo: 18, Type: "video/mp4", URL: mediaServer.URL, Title: title, Author: "dotconferences"}}
+	y.playerResponse = &PlayerResponseData{}
+
+	bundle, err := y.DownloadBundle(context.Background(), BundleOptions{
+		DownloadOptions: DownloadOptions{OutputDir: dir, Filesystem: NTFS},
+		Metadata:        true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the sidecars are paired with the media by its base name
+	base := strings.TrimSuffix(bundle.Media, ".mp4")
+	if len(filepath.Base(base)) >= len(strings.TrimSpace(title)) {
+		t.Errorf("media %s should be shortened for ntfs", bundle.Media)
+	}
+	if bundle.Metadata != base+".info.json" {
+		t.Errorf("metadata %s doesn't match the media %s", bundle.Metadata, bundle.Media)
+	}
+	for _, path := range []string{bundle.Media, bundle.Metadata} {
+		if _, err := os.Stat(path); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+// concatProcessor merges by concatenating the files.
+type concatProcessor struct{}
+
+func (concatProcessor) Merge(ctx context.Context, videoFile, audioFile, destFile string) error {
+	video, err := ioutil.ReadFile(videoFile)
+	if err != nil {
+		return err
+	}
+	audio, err := ioutil.ReadFile(audioFile)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(destFile, append(video, audio...), 0644)
+}
+
+func TestYoutube_DownloadBundle_Merge(t *testing.T) {
+	video := append(append([]byte{}, mp4Header...), bytes.Repeat([]byte{1}, 100)...)
+	audio := append(append([]byte{}, mp4Header...), bytes.Repeat([]byte{2}, 100)...)
+	videoServer := newMediaServer(video)
+	defer videoServer.Close()
+	audioServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "audio/mp4")
+		w.Write(audio)
+	}))
+	defer audioServer.Close()
+
+	dir, err := ioutil.TempDir("", "youtube")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	y := NewYoutube(false)
+	y.VideoID = "rFejpH_tAHM"
+	y.StreamList = []stream{
+		{ItagNo: 137, Type: "video/mp4", URL: videoServer.URL, Title: "talk", Author: "dotconferences", Adaptive: true},
+		{ItagNo: 140, Type: "audio/mp4", URL: audioServer.URL, Title: "talk", Author: "dotconferences", Adaptive: true, Bitrate: 128000},
+	}
+	if err := json.Unmarshal([]byte(`{"videoDetails": {"lengthSeconds": "1401", "shortDescription": "Go talk"}}`), &y.playerResponse); err != nil {
+		t.Fatal(err)
+	}
+
+	bundle, err := y.DownloadBundle(context.Background(), BundleOptions{
+		DownloadOptions: DownloadOptions{OutputDir: dir, ItagNo: 137},
+		NFO:             true,
+		Merge:           concatProcessor{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := ioutil.ReadFile(bundle.Media); !bytes.Equal(got, append(video, audio...)) {
+		t.Errorf("media holds %d bytes, want the merged video and audio", len(got))
+	}
+	nfo, _ := ioutil.ReadFile(bundle.NFO)
+	for _, want := range []string{"<title>talk</title>", "<plot>Go talk</plot>", "<studio>dotconferences</studio>", "<runtime>23</runtime>", `<uniqueid type="youtube" default="true">rFejpH_tAHM</uniqueid>`} {
+		if !bytes.Contains(nfo, []byte(want)) {
+			t.Errorf("nfo = %s, want %s", nfo, want)
+		}
+	}
+	if entries, _ := ioutil.ReadDir(dir); len(entries) != 2 {
+		t.Errorf("%d files in the output directory, want the media and its nfo", len(entries))
+	}
+}
+
+func TestFinalizeBundle(t *testing.T) {
+	staging, err := ioutil.TempDir("", "youtube")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(staging)
+	outputDir, err := ioutil.TempDir("", "youtube")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(outputDir)
+
+	// the last sidecar is missing, the media must not have been moved
+	for _, name := range []string{"talk.mp4", "talk.en.vtt"} {
+		ioutil.WriteFile(filepath.Join(staging, name), nil, 0644)
+	}
+	if err := finalizeBundle(staging, outputDir, []string{"talk.mp4", "talk.en.vtt", "talk.nfo"}); err == nil {
+		t.Fatal("finalizeBundle() succeeded with a missing file")
+	}
+	if entries, _ := ioutil.ReadDir(outputDir); len(entries) != 0 {
+		t.Errorf("%d files left in the output directory after a failure", len(entries))
+	}
+	if _, err := os.Stat(filepath.Join(staging, "talk.mp4")); err != nil {
+		t.Error("the media was moved before its sidecars")
+	}
+}
@@ -0,0 +1,142 @@
+package youtube
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// defaultCacheTTL keeps the metadata well under the lifetime of the stream urls it holds.
+const defaultCacheTTL = time.Hour
+
+// CacheStore stores the entries of a MetadataCache, it may be backed by a
+// storage shared by several processes.
+type CacheStore interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte, ttl time.Duration)
+}
+
+// MetadataCache caches the video info fetched when decoding a video, so
+// decoding the same video again doesn't hit youtube. Share it between the
+// Youtube of a process through Options.MetadataCache.
+//
+// The keys are salted hashes of the video id and of the proxy the info was
+// fetched through, entries can't be looked up or planted for a given video
+// without the salt. Concurrent misses of the same key are coalesced into a
+// single upstream request.
+type MetadataCache struct {
+	Store CacheStore
+	TTL   time.Duration
+	// Salt is random by default, processes sharing a Store must use the same salt.
+	Salt []byte
+
+	flight flightGroup
+}
+
+// NewMetadataCache returns a cache keeping the entries in memory for ttl,
+// defaultCacheTTL when zero, with a random salt.
+func NewMetadataCache(ttl time.Duration) (*MetadataCache, error) {
+	if ttl == 0 {
+		ttl = defaultCacheTTL
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return &MetadataCache{Store: NewMemoryCacheStore(), TTL: ttl, Salt: salt}, nil
+}
+
+// key returns the salted key of the entry kind of the video.
+func (c *MetadataCache) key(kind, videoID, socks5Proxy string) string {
+	h := sha256.New()
+	h.Write(c.Salt)
+	for _, part := range []string{kind, videoID, socks5Proxy} {
+		h.Write([]byte{0})
+		h.Write([]byte(part))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// get returns the entry key, fetching it on a miss. The concurrent misses of
+// key wait for the fetch of the first one, which is canceled once all of
+// them gave up.
+func (c *MetadataCache) get(ctx context.Context, key string, fetch func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	if value, ok := c.Store.Get(key); ok {
+		return value, nil
+	}
+	value, err, _ := c.flight.doContext(ctx, key, func(ctx context.Context) (interface{}, error) {
+		if value, ok := c.Store.Get(key); ok {
+			return value, nil
+		}
+		value, err := fetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+		c.Store.Set(key, value, c.TTL)
+		return value, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.([]byte), nil
+}
+
+// refresh fetches the entry key again and stores it.
+func (c *MetadataCache) refresh(ctx context.Context, key string, fetch func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	value, err := fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.Store.Set(key, value, c.TTL)
+	return value, nil
+}
+
+// memoryCacheStore is a CacheStore keeping the entries in memory.
+type memoryCacheStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryCacheEntry
+	// sweepAt is the number of entries triggering a sweep of the expired ones.
+	sweepAt int
+}
+
+type memoryCacheEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+// NewMemoryCacheStore returns a CacheStore keeping the entries in memory.
+func NewMemoryCacheStore() CacheStore {
+	return &memoryCacheStore{entries: make(map[string]memoryCacheEntry)}
+}
+
+func (s *memoryCacheStore) Get(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(s.entries, key)
+		return nil, false
+	}
+	return entry.value, true
+}
+
+func (s *memoryCacheStore) Set(key string, value []byte, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if len(s.entries) >= s.sweepAt {
+		for key, entry := range s.entries {
+			if now.After(entry.expires) {
+				delete(s.entries, key)
+			}
+		}
+		s.sweepAt = 2*len(s.entries) + 64
+	}
+	s.entries[key] = memoryCacheEntry{value: value, expires: now.Add(ttl)}
+}
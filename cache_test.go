@@ -0,0 +1,128 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMetadataCache_key(t *testing.T) {
+	c, err := NewMetadataCache(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewMetadataCache(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := c.key("video_info", "rFejpH_tAHM", "")
+	if key != c.key("video_info", "rFejpH_tAHM", "") {
+		t.Error("the key of a video should be stable")
+	}
+	if key == c.key("video_info", "54e6lBE3BoQ", "") || key == c.key("video_info", "rFejpH_tAHM", "10.10.10.10:7878") {
+		t.Error("the keys should differ per video and per proxy")
+	}
+	if key == other.key("video_info", "rFejpH_tAHM", "") {
+		t.Error("caches with different salts should use different keys")
+	}
+	other.Salt = c.Salt
+	if key != other.key("video_info", "rFejpH_tAHM", "") {
+		t.Error("caches sharing a salt should use the same keys")
+	}
+}
+
+func TestMetadataCache_get(t *testing.T) {
+	c, err := NewMetadataCache(time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fetches int32
+	release := make(chan struct{})
+	fetch := func(ctx context.Context) ([]byte, error) {
+		atomic.AddInt32(&fetches, 1)
+		<-release
+		return []byte("status=ok"), nil
+	}
+
+	// a stampede of misses triggers a single fetch
+	const callers = 20
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := c.get(context.Background(), "key", fetch)
+			if err != nil || string(value) != "status=ok" {
+				t.Errorf("get() = %q, %v", value, err)
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if fetches != 1 {
+		t.Errorf("fetched %d times, want 1", fetches)
+	}
+
+	if _, err := c.get(context.Background(), "key", fetch); err != nil || fetches != 1 {
+		t.Errorf("a cached entry should not be fetched again, fetched %d times", fetches)
+	}
+
+	// a caller giving up doesn't cancel the fetch of the others
+	started, slowRelease := make(chan struct{}), make(chan struct{})
+	slow := func(ctx context.Context) ([]byte, error) {
+		close(started)
+		select {
+		case <-slowRelease:
+			return []byte("status=ok"), nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := make(chan error, 1)
+	go func() {
+		_, err := c.get(ctx, "slow", slow)
+		canceled <- err
+	}()
+	<-started
+	waiting := make(chan error, 1)
+	go func() {
+		_, err := c.get(context.Background(), "slow", slow)
+		waiting <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	if err := <-canceled; err != context.Canceled {
+		t.Errorf("canceled get() error = %v, want %v", err, context.Canceled)
+	}
+	close(slowRelease)
+	if err := <-waiting; err != nil {
+		t.Errorf("get() error = %v", err)
+	}
+
+	errFetch := errors.New("unexpected status code: 429")
+	failing := func(ctx context.Context) ([]byte, error) { return nil, errFetch }
+	if _, err := c.get(context.Background(), "failing", failing); err != errFetch {
+		t.Errorf("get() error = %v, want %v", err, errFetch)
+	}
+	if _, ok := c.Store.Get("failing"); ok {
+		t.Error("failures should not be cached")
+	}
+}
+
+func TestMemoryCacheStore(t *testing.T) {
+	s := NewMemoryCacheStore()
+	s.Set("fresh", []byte("a"), time.Hour)
+	s.Set("expired", []byte("b"), -time.Second)
+	if value, ok := s.Get("fresh"); !ok || string(value) != "a" {
+		t.Errorf("Get(fresh) = %q, %v", value, ok)
+	}
+	if _, ok := s.Get("expired"); ok {
+		t.Error("expired entries should not be returned")
+	}
+}
@@ -0,0 +1,57 @@
+package youtube
+
+import (
+	"os/exec"
+
+	"github.com/kkdai/youtube/version"
+)
+
+// Authentication modes reported in CapabilityInfo.AuthModes.
+const (
+	AuthModeNone = "none"
+	// AuthModePOToken is the proof of origin tokens of a POTokenProvider.
+	AuthModePOToken = "po_token"
+)
+
+// CapabilityInfo describes what the current build supports, frontends can use
+// it to hide the options which are not available.
+type CapabilityInfo struct {
+	Version   string
+	Commit    string
+	BuildTime string
+	// HLS reports whether live HLS manifests can be downloaded.
+	HLS bool
+	// DASH reports whether the adaptive (video-only and audio-only) streams
+	// can be downloaded, they can't by the metadataonly build.
+	DASH bool
+	// Merge reports whether adaptive video and audio streams can be merged
+	// into one file: the ffmpeg binary, which github.com/kkdai/youtube/ffmpeg
+	// merges them with, is found in PATH. A program merging them with another
+	// Processor can merge them regardless, unless built with metadataonly.
+	Merge bool
+	// Cipher reports whether ciphered stream urls can be deciphered.
+	Cipher    bool
+	AuthModes []string
+}
+
+// lookPath finds the ffmpeg binary, it is replaced in tests.
+var lookPath = exec.LookPath
+
+// Capabilities returns the capabilities of the current build.
+func Capabilities() CapabilityInfo {
+	merge := false
+	if downloadable {
+		_, err := lookPath("ffmpeg")
+		merge = err == nil
+	}
+	return CapabilityInfo{
+		Version:   version.Version(),
+		Commit:    version.Commit(),
+		BuildTime: version.BuildTime(),
+		HLS:       false,
+		DASH:      downloadable,
+		Merge:     merge,
+		Cipher:    true,
+		AuthModes: []string{AuthModeNone, AuthModePOToken},
+	}
+}
@@ -0,0 +1,7 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package youtube
+
+// downloadable reports whether the build downloads the streams.
+const downloadable = true
@@ -0,0 +1,8 @@
+//go:build metadataonly
+// +build metadataonly
+
+package youtube
+
+// downloadable reports whether the build downloads the streams, the
+// metadataonly build leaves the downloads out.
+const downloadable = false
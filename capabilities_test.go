@@ -0,0 +1,19 @@
+package youtube
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCapabilities_Merge(t *testing.T) {
+	defer func(f func(string) (string, error)) { lookPath = f }(lookPath)
+
+	lookPath = func(file string) (string, error) { return "/usr/bin/" + file, nil }
+	if caps := Capabilities(); caps.Merge != downloadable || caps.DASH != downloadable {
+		t.Errorf("Merge = %t, DASH = %t with ffmpeg in PATH, want %t", caps.Merge, caps.DASH, downloadable)
+	}
+	lookPath = func(file string) (string, error) { return "", errors.New("not found") }
+	if Capabilities().Merge {
+		t.Error("Merge should not be reported without ffmpeg")
+	}
+}
@@ -0,0 +1,67 @@
+/*
+Package client is the entry point of the v2 layout described in
+VERSIONING.md: a client holding the options only, safe for concurrent use,
+which decodes the videos of the video package and the lists of the playlist
+package. The download package downloads them.
+
+The v1 Youtube struct remains available through video.FromYoutube and
+Video.Youtube, so both APIs can be mixed while moving to this one.
+*/
+package client
+
+import (
+	"context"
+
+	"github.com/kkdai/youtube"
+	"github.com/kkdai/youtube/playlist"
+	"github.com/kkdai/youtube/video"
+)
+
+// Client decodes videos and lists. It is safe for concurrent use.
+type Client struct {
+	options youtube.Options
+}
+
+// New validates opts and returns a client with them. The videos decoded by
+// the client share a session, the one of opts when set.
+func New(opts youtube.Options) (*Client, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.Session == nil {
+		opts.Session = youtube.NewSession()
+	}
+	return &Client{options: opts}, nil
+}
+
+// Options returns the options of the client.
+func (c *Client) Options() youtube.Options {
+	return c.options
+}
+
+// Youtube returns a v1 Youtube with the options of the client.
+func (c *Client) Youtube() *youtube.Youtube {
+	// the options were validated by New
+	y, _ := youtube.NewYoutubeWithOptions(c.options)
+	return y
+}
+
+// Video decodes the video of url, which is a video id or any url accepted by
+// Youtube.DecodeURLWithContext.
+func (c *Client) Video(ctx context.Context, url string) (*video.Video, error) {
+	y := c.Youtube()
+	if err := y.DecodeURLWithContext(ctx, url); err != nil {
+		return nil, err
+	}
+	return video.FromYoutube(y), nil
+}
+
+// Album enumerates the tracks of the YouTube Music album of url, see
+// Youtube.MusicAlbum.
+func (c *Client) Album(ctx context.Context, url string) (*playlist.Playlist, error) {
+	album, err := c.Youtube().MusicAlbum(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	return playlist.FromMusicAlbum(album), nil
+}
@@ -0,0 +1,97 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/kkdai/youtube"
+)
+
+// redirectTransport sends every request to server, whatever its host.
+type redirectTransport struct {
+	server *httptest.Server
+}
+
+func (t redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	target, _ := url.Parse(t.server.URL)
+	req.URL.Scheme, req.URL.Host = target.Scheme, target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func newVideoInfoServer(t *testing.T) *httptest.Server {
+	playerResponse, err := json.Marshal(map[string]interface{}{
+		"playabilityStatus": map[string]string{"status": "OK"},
+		"videoDetails":      map[string]string{"title": "Simplicity is Complicated", "author": "dotconferences"},
+		"streamingData": map[string]interface{}{
+			"formats": []map[string]interface{}{
+				{"itag": 18, "url": "https://r1.googlevideo.com/videoplayback?itag=18", "mimeType": "video/mp4", "quality": "medium"},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	videoInfo := url.Values{"status": {"ok"}, "player_response": {string(playerResponse)}}.Encode()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/get_video_info" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(videoInfo))
+	}))
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(youtube.Options{Socks5Proxy: "no-port"}); err == nil {
+		t.Error("New() accepted invalid options")
+	}
+	c, err := New(youtube.Options{RateLimit: 1024})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts := c.Options(); opts.RateLimit != 1024 || opts.Session == nil {
+		t.Errorf("Options() = %+v, want the options with a shared session", opts)
+	}
+	if y := c.Youtube(); y.RateLimit != 1024 || y.Session != c.Options().Session {
+		t.Errorf("Youtube() = %+v, want the options of the client", y)
+	}
+}
+
+func TestClient_Video(t *testing.T) {
+	server := newVideoInfoServer(t)
+	defer server.Close()
+	c, err := New(youtube.Options{HTTPClient: &http.Client{Transport: redirectTransport{server}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the client is shared by concurrent decodes
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := c.Video(context.Background(), "https://www.youtube.com/watch?v=rFejpH_tAHM")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if v.ID != "rFejpH_tAHM" || v.Title != "Simplicity is Complicated" || v.Author != "dotconferences" {
+				t.Errorf("Video() = %+v", v)
+			}
+			if len(v.Formats) != 1 || v.Formats[0].ItagNo != 18 {
+				t.Errorf("Video() formats = %+v", v.Formats)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if _, err := c.Video(context.Background(), "?"); err == nil {
+		t.Error("Video() accepted an invalid url")
+	}
+}
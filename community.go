@@ -0,0 +1,184 @@
+package youtube
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// postIDPattern matches the ids of the community posts, such as UgkxT3...
+var postIDPattern = regexp.MustCompile(`^Ug[A-Za-z0-9_-]{10,}$`)
+
+// CommunityPost is a post of the community tab of a channel.
+type CommunityPost struct {
+	ID        string `json:"id"`
+	ChannelID string `json:"channel_id,omitempty"`
+	Author    string `json:"author"`
+	Text      string `json:"text"`
+	// Published is relative to the time of the request, such as "2 days ago".
+	Published string `json:"published,omitempty"`
+	// Images are the images attached to the post, in their order, each in
+	// its largest size.
+	Images []Thumbnail `json:"images"`
+}
+
+// CommunityPost resolves a community post url, such as
+// https://www.youtube.com/post/Ugkx..., the community tab of a channel
+// highlighting a post with ?lb=Ugkx..., or a bare post id.
+func (y *Youtube) CommunityPost(ctx context.Context, postURL string) (*CommunityPost, error) {
+	id, err := postID(postURL)
+	if err != nil {
+		return nil, err
+	}
+	page, err := y.httpGetBody(ctx, baseURL+"/post/"+id+"?hl=en")
+	if err != nil {
+		return nil, err
+	}
+	data, err := initialData(page)
+	if err != nil {
+		return nil, err
+	}
+
+	renderers := findJSON(data, "backstagePostRenderer")
+	if len(renderers) == 0 {
+		return nil, ErrPostNotFound
+	}
+	renderer := renderers[0]
+	for _, r := range renderers {
+		if stringAt(r, "postId") == id {
+			renderer = r
+			break
+		}
+	}
+
+	post := &CommunityPost{
+		ID:        stringAt(renderer, "postId"),
+		ChannelID: stringAt(renderer, "authorEndpoint", "browseEndpoint", "browseId"),
+		Author:    musicText(valueAt(renderer, "authorText")),
+		Text:      musicText(valueAt(renderer, "contentText")),
+		Published: musicText(valueAt(renderer, "publishedTimeText")),
+	}
+	// a single image and the images of a carousel have the same renderer
+	for _, image := range findJSON(valueAt(renderer, "backstageAttachment"), "backstageImageRenderer") {
+		list, _ := valueAt(image, "image", "thumbnails").([]interface{})
+		var largest Thumbnail
+		for _, t := range list {
+			width, _ := valueAt(t, "width").(float64)
+			height, _ := valueAt(t, "height").(float64)
+			if largest.URL == "" || int(width) > largest.Width {
+				largest = Thumbnail{URL: stringAt(t, "url"), Width: int(width), Height: int(height)}
+			}
+		}
+		if strings.HasPrefix(largest.URL, "//") {
+			largest.URL = "https:" + largest.URL
+		}
+		if largest.URL != "" {
+			post.Images = append(post.Images, largest)
+		}
+	}
+	return post, nil
+}
+
+// DownloadPostImages downloads the images of post into outputDir, the
+// default output directory when empty, as "<post id>-1.jpg", "<post id>-2.png"
+// and so on, the extension following the image format, along with the post
+// as a json sidecar "<post id>.info.json". It returns the paths of the files
+// written, the sidecar last, up to the first failure.
+func (y *Youtube) DownloadPostImages(ctx context.Context, post *CommunityPost, outputDir string) ([]string, error) {
+	if outputDir == "" {
+		var err error
+		if outputDir, err = defaultOutputDir(); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for i, image := range post.Images {
+		data, err := y.httpGetBody(ctx, image.URL)
+		if err != nil {
+			return paths, err
+		}
+		name := fmt.Sprintf("%s-%d%s", post.ID, i+1, imageExtension(data))
+		dest := filepath.Join(outputDir, y.Filesystem.FileName(outputDir, name))
+		if err := ioutil.WriteFile(dest, data, 0644); err != nil {
+			return paths, err
+		}
+		paths = append(paths, dest)
+	}
+
+	data, err := json.MarshalIndent(post, "", "  ")
+	if err != nil {
+		return paths, err
+	}
+	dest := filepath.Join(outputDir, y.Filesystem.FileName(outputDir, post.ID+".info.json"))
+	if err := ioutil.WriteFile(dest, data, 0644); err != nil {
+		return paths, err
+	}
+	return append(paths, dest), nil
+}
+
+// postID returns the id of the post at rawURL, or of a bare post id.
+func postID(rawURL string) (string, error) {
+	id := rawURL
+	if strings.Contains(rawURL, "/") {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return "", ErrInvalidPostURL
+		}
+		parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+		switch {
+		case u.Query().Get("lb") != "":
+			id = u.Query().Get("lb")
+		case len(parts) == 2 && parts[0] == "post":
+			id = parts[1]
+		default:
+			return "", ErrInvalidPostURL
+		}
+	}
+	if !postIDPattern.MatchString(id) {
+		return "", ErrInvalidPostURL
+	}
+	return id, nil
+}
+
+// initialData returns the decoded ytInitialData object embedded in a page.
+func initialData(page []byte) (interface{}, error) {
+	i := bytes.Index(page, []byte("ytInitialData"))
+	if i < 0 {
+		return nil, ErrPostNotFound
+	}
+	start := bytes.IndexByte(page[i:], '{')
+	if start < 0 {
+		return nil, ErrPostNotFound
+	}
+	// the decoder stops at the end of the object, before the rest of the script
+	var data interface{}
+	if err := json.NewDecoder(bytes.NewReader(page[i+start:])).Decode(&data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// imageExtension returns the extension of the image format of data, .jpg by default.
+func imageExtension(data []byte) string {
+	switch http.DetectContentType(data) {
+	case "image/png":
+		return ".png"
+	case "image/webp":
+		return ".webp"
+	case "image/gif":
+		return ".gif"
+	}
+	return ".jpg"
+}
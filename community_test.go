@@ -0,0 +1,128 @@
+package youtube
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testPostID = "UgkxT3kPqbbp1eAdhmLEOYuVZhxyGLbSaGm7"
+
+func TestPostID(t *testing.T) {
+	tests := []struct {
+		url     string
+		want    string
+		wantErr bool
+	}{
+		{"https://www.youtube.com/post/" + testPostID, testPostID, false},
+		{"https://www.youtube.com/channel/UCrob/community?lb=" + testPostID, testPostID, false},
+		{testPostID, testPostID, false},
+		{"https://www.youtube.com/watch?v=rFejpH_tAHM", "", true},
+		{"rFejpH_tAHM", "", true},
+	}
+	for _, tt := range tests {
+		got, err := postID(tt.url)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("postID(%q) = %q, %v, want %q", tt.url, got, err, tt.want)
+		}
+	}
+}
+
+func TestCommunityPost(t *testing.T) {
+	png := append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 32)...)
+	jpeg := append([]byte("\xff\xd8\xff\xe0"), bytes.Repeat([]byte{0}, 32)...)
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		image := func(path string) map[string]interface{} {
+			return map[string]interface{}{"backstageImageRenderer": map[string]interface{}{
+				"image": map[string]interface{}{"thumbnails": []interface{}{
+					map[string]interface{}{"url": server.URL + path + "?small", "width": 288, "height": 288},
+					map[string]interface{}{"url": server.URL + path, "width": 1080, "height": 1080},
+				}},
+			}}
+		}
+		switch r.URL.Path {
+		case "/post/" + testPostID:
+			data, _ := json.Marshal(map[string]interface{}{"contents": map[string]interface{}{
+				"backstagePostRenderer": map[string]interface{}{
+					"postId":            testPostID,
+					"authorText":        map[string]interface{}{"runs": []interface{}{map[string]string{"text": "Gopher"}}},
+					"authorEndpoint":    map[string]interface{}{"browseEndpoint": map[string]string{"browseId": "UCgopher"}},
+					"contentText":       map[string]interface{}{"runs": []interface{}{map[string]string{"text": "New "}, map[string]string{"text": "logo"}}},
+					"publishedTimeText": map[string]interface{}{"runs": []interface{}{map[string]string{"text": "2 days ago"}}},
+					"backstageAttachment": map[string]interface{}{"postMultiImageRenderer": map[string]interface{}{
+						"images": []interface{}{image("/one"), image("/two")},
+					}},
+				},
+			}})
+			fmt.Fprintf(w, `<html><script>var ytInitialData = %s;</script></html>`, data)
+		case "/one":
+			w.Write(png)
+		case "/two":
+			w.Write(jpeg)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	defer func(url string) { baseURL = url }(baseURL)
+	baseURL = server.URL
+
+	y := NewYoutube(false)
+	post, err := y.CommunityPost(context.Background(), "https://www.youtube.com/post/"+testPostID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &CommunityPost{
+		ID:        testPostID,
+		ChannelID: "UCgopher",
+		Author:    "Gopher",
+		Text:      "New logo",
+		Published: "2 days ago",
+		Images: []Thumbnail{
+			{URL: server.URL + "/one", Width: 1080, Height: 1080},
+			{URL: server.URL + "/two", Width: 1080, Height: 1080},
+		},
+	}
+	if !reflect.DeepEqual(post, want) {
+		t.Fatalf("CommunityPost() = %+v, want %+v", post, want)
+	}
+
+	dir, err := ioutil.TempDir("", "youtube")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	paths, err := y.DownloadPostImages(context.Background(), post, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantPaths := []string{
+		filepath.Join(dir, testPostID+"-1.png"),
+		filepath.Join(dir, testPostID+"-2.jpg"),
+		filepath.Join(dir, testPostID+".info.json"),
+	}
+	if !reflect.DeepEqual(paths, wantPaths) {
+		t.Fatalf("DownloadPostImages() = %q, want %q", paths, wantPaths)
+	}
+	if data, _ := ioutil.ReadFile(paths[0]); !bytes.Equal(data, png) {
+		t.Errorf("%s holds %q, want the png image", paths[0], data)
+	}
+	var sidecar CommunityPost
+	data, _ := ioutil.ReadFile(paths[2])
+	if err := json.Unmarshal(data, &sidecar); err != nil || !reflect.DeepEqual(&sidecar, want) {
+		t.Errorf("sidecar = %s, %v", data, err)
+	}
+
+	if _, err := y.CommunityPost(context.Background(), "UgkxAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"); err == nil {
+		t.Error("CommunityPost() of a missing post succeeded")
+	}
+}
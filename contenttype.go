@@ -0,0 +1,13 @@
+package youtube
+
+// ExtensionMismatch reports a downloaded file whose content is another
+// container than its extension says, such as webm bytes served as video/mp4.
+type ExtensionMismatch struct {
+	Path string
+	// Extension is the extension of Path, Sniffed the one of its content.
+	Extension string
+	Sniffed   string
+	// Renamed is the path the file was moved to by DownloadOptions.FixExtension,
+	// empty when it was left in place.
+	Renamed string
+}
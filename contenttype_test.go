@@ -0,0 +1,199 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package youtube
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// mp4Header is the beginning of an mp4 file, enough for http.DetectContentType.
+var mp4Header = []byte("\x00\x00\x00\x18ftypmp42\x00\x00\x00\x00mp42isom")
+
+func TestVerifyContentType(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		wantErr     bool
+	}{
+		{
+			name:        "matching media",
+			contentType: "video/mp4",
+			body:        mp4Header,
+		},
+		{
+			name:        "octet stream",
+			contentType: "application/octet-stream",
+			body:        mp4Header,
+		},
+		{
+			name:        "html error page",
+			contentType: "text/html; charset=utf-8",
+			body:        []byte("<html><body>Sorry...</body></html>"),
+			wantErr:     true,
+		},
+		{
+			name:        "html served as media",
+			contentType: "video/mp4",
+			body:        []byte("<!DOCTYPE html><html><body>Sorry...</body></html>"),
+			wantErr:     true,
+		},
+		{
+			name:        "empty body",
+			contentType: "video/mp4",
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{
+				Header: http.Header{"Content-Type": []string{tt.contentType}},
+				Body:   ioutil.NopCloser(bytes.NewReader(tt.body)),
+			}
+			body, err := verifyContentType(resp, `video/mp4; codecs="avc1.42001E, mp4a.40.2"`, false)
+			if tt.wantErr {
+				if !errors.As(err, &ErrUnexpectedContentType{}) {
+					t.Errorf("verifyContentType() error = %v, want ErrUnexpectedContentType", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("verifyContentType() error = %v", err)
+			}
+			got, _ := ioutil.ReadAll(body)
+			if !bytes.Equal(got, tt.body) {
+				t.Errorf("verifyContentType() body = %q, want %q", got, tt.body)
+			}
+		})
+	}
+}
+
+func TestYoutube_videoDLWorker_HTMLAnswer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html><body>Sorry...</body></html>"))
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "youtube")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	destFile := filepath.Join(dir, "video.mp4")
+	y := NewYoutube(false)
+	err = y.videoDLWorker(context.Background(), destFile, server.URL, "video/mp4", DownloadOptions{})
+	if !errors.As(err, &ErrUnexpectedContentType{}) {
+		t.Errorf("videoDLWorker() error = %v, want ErrUnexpectedContentType", err)
+	}
+	if _, err := os.Stat(destFile); !os.IsNotExist(err) {
+		t.Error("no file should be created for an html answer")
+	}
+}
+
+func TestYoutube_StartDownloadToWriter(t *testing.T) {
+	media := append(append([]byte{}, mp4Header...), bytes.Repeat([]byte{0}, 1024)...)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "video/mp4")
+		w.Write(media)
+	}))
+	defer server.Close()
+
+	y := NewYoutube(false)
+	y.StreamList = []stream{{ItagNo: 18, Type: "video/mp4", URL: server.URL}}
+	var buf bytes.Buffer
+	if err := y.StartDownloadToWriter(context.Background(), &buf, DownloadOptions{}); err != nil {
+		t.Fatalf("StartDownloadToWriter() error = %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), media) {
+		t.Errorf("StartDownloadToWriter() wrote %d bytes, want %d", buf.Len(), len(media))
+	}
+}
+
+// webmHeader is the beginning of a webm file, its EBML header.
+var webmHeader = []byte("\x1a\x45\xdf\xa3\x9f\x42\x86\x81\x01\x42\xf7\x81\x01\x42\xf2\x81\x04\x42\xf3\x81\x08\x42\x82\x84webm")
+
+func TestSniffExtension(t *testing.T) {
+	tests := []struct {
+		name string
+		head []byte
+		want string
+	}{
+		{name: "mp4", head: mp4Header, want: ".mp4"},
+		{name: "m4a", head: []byte("\x00\x00\x00\x1cftypM4A \x00\x00\x00\x00"), want: ".m4a"},
+		{name: "3gp", head: []byte("\x00\x00\x00\x14ftyp3gp4\x00\x00\x00\x00"), want: ".3gp"},
+		{name: "webm", head: webmHeader, want: ".webm"},
+		{name: "matroska", head: []byte("\x1a\x45\xdf\xa3\x42\x82\x88matroska"), want: ".mkv"},
+		{name: "flv", head: []byte("FLV\x01\x05"), want: ".flv"},
+		{name: "mp3", head: []byte("ID3\x03\x00"), want: ".mp3"},
+		{name: "unknown", head: []byte("hello"), want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sniffExtension(tt.head); got != tt.want {
+				t.Errorf("sniffExtension() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestYoutube_download_ExtensionMismatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		media      []byte
+		outputFile string
+		fix        bool
+		wantFile   string
+		wantCall   bool
+	}{
+		{name: "matching", media: mp4Header, outputFile: "video.mp4", fix: true, wantFile: "video.mp4"},
+		{name: "m4a holding mp4", media: mp4Header, outputFile: "video.m4a", fix: true, wantFile: "video.m4a"},
+		{name: "reported", media: webmHeader, outputFile: "video.mp4", wantFile: "video.mp4", wantCall: true},
+		{name: "fixed", media: webmHeader, outputFile: "video.mp4", fix: true, wantFile: "video.webm", wantCall: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "video/mp4")
+				w.Write(append(append([]byte{}, tt.media...), bytes.Repeat([]byte{0}, 1024)...))
+			}))
+			defer server.Close()
+			dir, err := ioutil.TempDir("", "youtube")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+
+			var mismatch *ExtensionMismatch
+			y := NewYoutube(false)
+			y.OnExtensionMismatch = func(m ExtensionMismatch) { mismatch = &m }
+			y.StreamList = []stream{{ItagNo: 18, Type: "video/mp4", URL: server.URL}}
+			path, err := y.download(context.Background(), DownloadOptions{OutputDir: dir, OutputFile: tt.outputFile, FixExtension: tt.fix})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := filepath.Join(dir, tt.wantFile); path != want {
+				t.Errorf("download() = %q, want %q", path, want)
+			}
+			if _, err := os.Stat(path); err != nil {
+				t.Error(err)
+			}
+			if (mismatch != nil) != tt.wantCall {
+				t.Fatalf("mismatch = %+v, want reported %v", mismatch, tt.wantCall)
+			}
+			if mismatch != nil && (mismatch.Sniffed != ".webm" || (mismatch.Renamed != "") != tt.fix) {
+				t.Errorf("mismatch = %+v", mismatch)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+//go:build integration
+// +build integration
+
+package youtube
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"text/tabwriter"
+	"time"
+)
+
+// corpusCase is a public video of the test corpus along with what this
+// package is expected to make of it. The corpus is curated: a video taken
+// down or no longer showing its edge case is replaced by another one, never
+// dropped, so that every edge case stays covered.
+type corpusCase struct {
+	name    string
+	videoID string
+	// decodes is whether the video is expected to be extracted, check then
+	// verifies the decoded video shows the edge case. Otherwise the failure
+	// must be one of failures, not a network error.
+	decodes  bool
+	check    func(y *Youtube) error
+	failures []string
+	// reasons are the acceptable failures, when the outcome depends on where
+	// the test runs, such as a region lock.
+	reasons []string
+}
+
+var corpus = []corpusCase{
+	{name: "muxed", videoID: "rFejpH_tAHM", decodes: true, check: hasStreams(false)},
+	{name: "adaptive", videoID: "rFejpH_tAHM", decodes: true, check: hasStreams(true)},
+	{name: "ciphered", videoID: "54e6lBE3BoQ", decodes: true, check: func(y *Youtube) error {
+		for _, s := range y.StreamList {
+			if s.Ciphered {
+				return nil
+			}
+		}
+		return fmt.Errorf("no ciphered stream")
+	}},
+	// the package doesn't log in, age-gated videos aren't playable
+	{name: "age-gated", videoID: "HtVdAasjOgU", decodes: false, failures: playabilityFailures},
+	{name: "live", videoID: "jfKfPfyJRdk", decodes: true, check: func(y *Youtube) error {
+		if !y.playerResponse.VideoDetails.IsLiveContent {
+			return fmt.Errorf("not live content")
+		}
+		return nil
+	}},
+	// a premiere already aired plays as a regular video
+	{name: "premiere", videoID: "n3kPvBCYT3E", decodes: true, check: hasStreams(true)},
+	{name: "vertical", videoID: "BGQWPY4IigY", decodes: true, check: func(y *Youtube) error {
+		for _, f := range y.playerResponse.StreamingData.AdaptiveFormats {
+			if f.Width > 0 && f.Height > f.Width {
+				return nil
+			}
+		}
+		return fmt.Errorf("no vertical format")
+	}},
+	{name: "multi-audio", videoID: "9bqk6ZUsKyA", decodes: true, check: func(y *Youtube) error {
+		if tracks := len(y.playerResponse.Captions.PlayerCaptionsTracklistRenderer.AudioTracks); tracks < 2 {
+			return fmt.Errorf("%d audio tracks", tracks)
+		}
+		return nil
+	}},
+	{name: "region-locked", videoID: "sJL6WA-aGkQ", decodes: true, check: hasStreams(false), reasons: []string{"country", "region"}},
+}
+
+// playabilityFailures are the failures of the videos youtube refuses to play.
+var playabilityFailures = []string{"'fail' response status", "Cannot playback"}
+
+func hasStreams(adaptive bool) func(y *Youtube) error {
+	return func(y *Youtube) error {
+		for _, s := range y.StreamList {
+			if s.Adaptive == adaptive {
+				return nil
+			}
+		}
+		return fmt.Errorf("no stream with adaptive %v", adaptive)
+	}
+}
+
+// TestCorpus decodes every video of the corpus and reports the matrix of
+// the edge cases passing.
+func TestCorpus(t *testing.T) {
+	var matrix strings.Builder
+	w := tabwriter.NewWriter(&matrix, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "CASE\tVIDEO\tRESULT\tDETAIL")
+	for _, tt := range corpus {
+		result, detail := "pass", ""
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			y := NewYoutube(false)
+			err := y.DecodeURLWithContext(ctx, tt.videoID)
+			switch {
+			case err != nil && acceptable(err, tt.reasons):
+				result, detail = "skip", err.Error()
+				t.Skip(err)
+			case err != nil && tt.decodes:
+				result, detail = "FAIL", err.Error()
+				t.Error(err)
+			case err == nil && !tt.decodes:
+				result, detail = "FAIL", "decoded, want a failure"
+				t.Error(detail)
+			case err != nil && !acceptable(err, tt.failures):
+				result, detail = "FAIL", err.Error()
+				t.Error(err)
+			case err == nil && tt.check != nil:
+				if err := tt.check(y); err != nil {
+					result, detail = "FAIL", err.Error()
+					t.Error(err)
+				}
+			}
+		})
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", tt.name, tt.videoID, result, strings.Join(strings.Fields(detail), " "))
+	}
+	w.Flush()
+	t.Log("capability matrix:\n" + matrix.String())
+}
+
+func acceptable(err error, reasons []string) bool {
+	for _, reason := range reasons {
+		if strings.Contains(err.Error(), reason) {
+			return true
+		}
+	}
+	return false
+}
@@ -6,6 +6,11 @@ type FormatBase struct {
 	MimeType string `json:"mimeType"`
 	Quality  string `json:"quality"`
 	Cipher   string `json:"signatureCipher"`
+	Bitrate  int    `json:"bitrate"`
+	Width    int    `json:"width,omitempty"`
+	Height   int    `json:"height,omitempty"`
+	// ContentLength is the size in bytes of the stream, when known.
+	ContentLength string `json:"contentLength,omitempty"`
 }
 type PlayerResponseData struct {
 	PlayabilityStatus struct {
@@ -18,11 +23,7 @@ type PlayerResponseData struct {
 		ExpiresInSeconds string `json:"expiresInSeconds"`
 		Formats          []struct {
 			FormatBase
-			Bitrate          int    `json:"bitrate"`
-			Width            int    `json:"width"`
-			Height           int    `json:"height"`
 			LastModified     string `json:"lastModified"`
-			ContentLength    string `json:"contentLength,omitempty"`
 			QualityLabel     string `json:"qualityLabel"`
 			ProjectionType   string `json:"projectionType"`
 			AverageBitrate   int    `json:"averageBitrate,omitempty"`
@@ -33,9 +34,6 @@ type PlayerResponseData struct {
 		} `json:"formats"`
 		AdaptiveFormats []struct {
 			FormatBase
-			Bitrate   int `json:"bitrate"`
-			Width     int `json:"width,omitempty"`
-			Height    int `json:"height,omitempty"`
 			InitRange struct {
 				Start string `json:"start"`
 				End   string `json:"end"`
@@ -45,7 +43,6 @@ type PlayerResponseData struct {
 				End   string `json:"end"`
 			} `json:"indexRange"`
 			LastModified     string `json:"lastModified"`
-			ContentLength    string `json:"contentLength"`
 			Fps              int    `json:"fps,omitempty"`
 			QualityLabel     string `json:"qualityLabel,omitempty"`
 			ProjectionType   string `json:"projectionType"`
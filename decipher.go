@@ -1,39 +1,48 @@
 package youtube
 
 import (
+	"context"
 	"fmt"
-	"io/ioutil"
 	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
 )
 
-func (y *Youtube) parseDecipherOpsAndArgs() (operations []string, args []int, err error) {
-	// try to get whole page
-	client, err := y.getHTTPClient()
-	if err != nil {
-		return nil, nil, fmt.Errorf("get http client error=%s", err)
+// cipherPlan returns the operations deciphering the signatures of the
+// current player, the player and its plan are kept in the session.
+func (y *Youtube) cipherPlan(ctx context.Context) (cipherPlan, error) {
+	session := y.session()
+	playerPath, ok := session.player()
+	if !ok {
+		var err error
+		if playerPath, err = y.playerPath(ctx); err != nil {
+			return cipherPlan{}, err
+		}
+		session.setPlayer(playerPath)
 	}
-
-	if y.VideoID == "" {
-		return nil, nil, fmt.Errorf("video id is empty , err=%s", err)
+	if plan, ok := session.plan(playerPath); ok {
+		return plan, nil
 	}
-	embedUrl := fmt.Sprintf("https://youtube.com/embed/%s?hl=en", y.VideoID)
-
-	embeddedPageResp, err := client.Get(embedUrl)
+	operations, args, err := y.parseDecipherOpsAndArgs(ctx, playerPath)
 	if err != nil {
-		return nil, nil, err
+		return cipherPlan{}, err
 	}
-	defer embeddedPageResp.Body.Close()
+	plan := cipherPlan{Operations: operations, Args: args}
+	session.setPlan(playerPath, plan)
+	return plan, nil
+}
 
-	if embeddedPageResp.StatusCode != 200 {
-		return nil, nil, err
+// playerPath returns the path of the player javascript of the embed page of the video.
+func (y *Youtube) playerPath(ctx context.Context) (string, error) {
+	if y.VideoID == "" {
+		return "", fmt.Errorf("video id is empty")
 	}
+	embedUrl := fmt.Sprintf("%s/embed/%s?hl=en", baseURL, y.VideoID)
 
-	embeddedPageBodyBytes, err := ioutil.ReadAll(embeddedPageResp.Body)
+	embeddedPageBodyBytes, err := y.httpGetBody(ctx, embedUrl)
 	if err != nil {
-		return nil, nil, err
+		return "", err
 	}
 	embeddedPage := string(embeddedPageBodyBytes)
 
@@ -43,20 +52,17 @@ func (y *Youtube) parseDecipherOpsAndArgs() (operations []string, args []int, er
 	basejsPattern := regexp.MustCompile(`"js":"\\/s\\/player(.*)base\.js`)
 	// eg: "js":\"\/s\/player\/f676c671\/player_ias.vflset\/en_US\/base.js
 	escapedBasejsUrl := basejsPattern.FindString(playerConfig)
+	if escapedBasejsUrl == "" {
+		return "", fmt.Errorf("player javascript not found in the embed page")
+	}
 	// eg: ["js", "\/s\/player\/f676c671\/player_ias.vflset\/en_US\/base.js]
 	arr := strings.Split(escapedBasejsUrl, ":\"")
-	basejsUrl := "https://youtube.com" + strings.ReplaceAll(arr[len(arr)-1], "\\", "")
-	basejsUrlResp, err := client.Get(basejsUrl)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	defer basejsUrlResp.Body.Close()
-	if basejsUrlResp.StatusCode != 200 {
-		return nil, nil, err
-	}
+	return strings.ReplaceAll(arr[len(arr)-1], "\\", ""), nil
+}
 
-	basejsBodyBytes, err := ioutil.ReadAll(basejsUrlResp.Body)
+func (y *Youtube) parseDecipherOpsAndArgs(ctx context.Context, playerPath string) (operations []string, args []int, err error) {
+	basejsUrl := baseURL + playerPath
+	basejsBodyBytes, err := y.httpGetBody(ctx, basejsUrl)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -66,7 +72,7 @@ func (y *Youtube) parseDecipherOpsAndArgs() (operations []string, args []int, er
 	decipherFuncNamePattern := regexp.MustCompile(`(\w+)=function\(\w+\){(\w+)=(\w+)\.split\(\x22{2}\);.*?return\s+(\w+)\.join\(\x22{2}\)}`)
 
 	// Ft=function(a){a=a.split("");Et.vw(a,2);Et.Zm(a,4);Et.Zm(a,46);Et.vw(a,2);Et.Zm(a,34);Et.Zm(a,59);Et.cn(a,42);return a.join("")} => get Ft
-	arr = decipherFuncNamePattern.FindStringSubmatch(basejs)
+	arr := decipherFuncNamePattern.FindStringSubmatch(basejs)
 	funcName := arr[1]
 	decipherFuncBodyPattern := regexp.MustCompile(fmt.Sprintf(`[^h\.]%s=function\(\w+\)\{(.*?)\}`, funcName))
 
@@ -149,7 +155,7 @@ func (y *Youtube) parseDecipherOpsAndArgs() (operations []string, args []int, er
 	return funcSeq, funcArgs, nil
 }
 
-func (y *Youtube) decipher(cipher string) (string, error) {
+func (y *Youtube) decipher(ctx context.Context, cipher string) (string, error) {
 	queryParams, err := url.ParseQuery(cipher)
 	if err != nil {
 		return "", err
@@ -196,18 +202,18 @@ func (y *Youtube) decipher(cipher string) (string, error) {
 			r--
 		}
 	}
-	operations, args, err := y.parseDecipherOpsAndArgs()
+	plan, err := y.cipherPlan(ctx)
 	if err != nil {
 		return "", err
 	}
-	for i, op := range operations {
+	for i, op := range plan.Operations {
 		switch op {
 		case "splice":
-			splice(args[i])
+			splice(plan.Args[i])
 		case "swap":
-			swap(args[i])
+			swap(plan.Args[i])
 		case "reverse":
-			reverse(args[i])
+			reverse(plan.Args[i])
 		}
 	}
 	cipherMap["s"] = string(bs)
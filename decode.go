@@ -0,0 +1,97 @@
+package youtube
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// decodes coalesces the concurrent decodes of the same video in the process,
+// a popular video requested many times at once is only extracted once.
+var decodes flightGroup
+
+// decodedVideo is the outcome of a decode, shared with the concurrent decodes of the same video.
+type decodedVideo struct {
+	videoInfo      string
+	playerResponse *PlayerResponseData
+	streams        []stream
+}
+
+// decode fetches and parses the info of y.VideoID, or waits for a concurrent
+// decode of the same video with the same options and takes its outcome. The
+// shared decode runs until every caller waiting for it gave up, each caller
+// waits for it until its own ctx is done. A refresh ignores the cached info.
+func (y *Youtube) decode(ctx context.Context, refresh bool) error {
+	key, ok := y.decodeKey(refresh)
+	if !ok {
+		// the options can't be told apart from the ones of another client
+		key = fmt.Sprintf("%p", y)
+	}
+	decoder, err := y.decoder()
+	if err != nil {
+		return err
+	}
+	val, err, _ := decodes.doContext(ctx, key, func(ctx context.Context) (interface{}, error) {
+		if err := decoder.getVideoInfo(ctx, refresh); err != nil {
+			return nil, fmt.Errorf("getVideoInfo error=%s", err)
+		}
+		if err := decoder.parseVideoInfo(ctx); err != nil {
+			return nil, fmt.Errorf("parse video info failed, err=%s", err)
+		}
+		return decodedVideo{
+			videoInfo:      decoder.videoInfo,
+			playerResponse: decoder.playerResponse,
+			streams:        decoder.StreamList,
+		}, nil
+	})
+	if err != nil {
+		return err
+	}
+	decoded := val.(decodedVideo)
+	y.videoInfo = decoded.videoInfo
+	y.playerResponse = decoded.playerResponse
+	y.StreamList = append([]stream(nil), decoded.streams...)
+	return nil
+}
+
+// decoder returns the Youtube decoding y.VideoID for the concurrent decodes
+// of the video, with the options, the session and the connections of y. The
+// decode may outlive the call of y, so it doesn't write to y.
+func (y *Youtube) decoder() (*Youtube, error) {
+	httpClient, err := y.getHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+	d := newYoutubeWithOptions(y.options())
+	d.HTTPClient = httpClient
+	d.VideoID = y.VideoID
+	return d, nil
+}
+
+// decodeKey returns the key of the decodes of y.VideoID sharing the options
+// of y which change what is fetched and how, false when one of them has no
+// identity to compare.
+func (y *Youtube) decodeKey(refresh bool) (string, bool) {
+	key := fmt.Sprintf("%s\x00%s\x00%t\x00%+v\x00%+v", y.VideoID, y.Socks5Proxy, refresh, y.Limits, y.Jitter)
+	for _, option := range []interface{}{y.HTTPClient, y.Session, y.MetadataCache, y.POTokenProvider} {
+		id, ok := identity(option)
+		if !ok {
+			return "", false
+		}
+		key += "\x00" + id
+	}
+	return key, true
+}
+
+// identity returns the type and the address of the pointer-like value v,
+// false for the other values.
+func identity(v interface{}) (string, bool) {
+	if v == nil {
+		return "", true
+	}
+	switch value := reflect.ValueOf(v); value.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Chan, reflect.UnsafePointer:
+		return fmt.Sprintf("%T@%x", v, value.Pointer()), true
+	}
+	return "", false
+}
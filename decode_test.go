@@ -0,0 +1,171 @@
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newVideoInfoServer serves a video info with a single muxed stream, each
+// answer waits for release to be closed.
+func newVideoInfoServer(t *testing.T, requests *int32, release chan struct{}) *httptest.Server {
+	playerResponse, err := json.Marshal(map[string]interface{}{
+		"playabilityStatus": map[string]string{"status": "OK"},
+		"videoDetails":      map[string]string{"title": "Simplicity is Complicated", "author": "dotconferences"},
+		"streamingData": map[string]interface{}{
+			"formats": []map[string]interface{}{
+				{"itag": 18, "url": "https://r1.googlevideo.com/videoplayback?itag=18", "mimeType": "video/mp4", "quality": "medium"},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	videoInfo := url.Values{"status": {"ok"}, "player_response": {string(playerResponse)}}.Encode()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/get_video_info" {
+			http.NotFound(w, r)
+			return
+		}
+		atomic.AddInt32(requests, 1)
+		<-release
+		w.Write([]byte(videoInfo))
+	}))
+}
+
+func TestYoutube_DecodeURLWithContext_Coalesced(t *testing.T) {
+	var requests int32
+	release := make(chan struct{})
+	server := newVideoInfoServer(t, &requests, release)
+	defer server.Close()
+	defer func(url string) { baseURL = url }(baseURL)
+	baseURL = server.URL
+
+	const callers = 10
+	var wg sync.WaitGroup
+	clients := make([]*Youtube, callers)
+	for i := range clients {
+		clients[i] = NewYoutube(false)
+		wg.Add(1)
+		go func(y *Youtube) {
+			defer wg.Done()
+			if err := y.DecodeURLWithContext(context.Background(), "rFejpH_tAHM"); err != nil {
+				t.Errorf("DecodeURLWithContext() error = %v", err)
+			}
+		}(clients[i])
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if requests != 1 {
+		t.Errorf("video info fetched %d times, want 1", requests)
+	}
+	for i, y := range clients {
+		if len(y.StreamList) != 1 || y.StreamList[0].ItagNo != 18 || y.GetItagInfo().Title != "Simplicity is Complicated" {
+			t.Errorf("client %d decoded %+v", i, y.StreamList)
+		}
+	}
+
+	// the decodes following the coalesced one fetch the info again
+	if err := NewYoutube(false).DecodeURLWithContext(context.Background(), "rFejpH_tAHM"); err != nil || requests != 2 {
+		t.Errorf("DecodeURLWithContext() error = %v, fetched %d times, want 2", err, requests)
+	}
+}
+
+func TestYoutube_DecodeURLWithContext_CanceledCaller(t *testing.T) {
+	var requests int32
+	release := make(chan struct{})
+	server := newVideoInfoServer(t, &requests, release)
+	defer server.Close()
+	defer func(url string) { baseURL = url }(baseURL)
+	baseURL = server.URL
+
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := make(chan error, 1)
+	go func() {
+		canceled <- NewYoutube(false).DecodeURLWithContext(ctx, "rFejpH_tAHM")
+	}()
+	for atomic.LoadInt32(&requests) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	follower := NewYoutube(false)
+	followed := make(chan error, 1)
+	go func() {
+		followed <- follower.DecodeURLWithContext(context.Background(), "rFejpH_tAHM")
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	// the first caller gives up, the decode goes on for the other one
+	cancel()
+	if err := <-canceled; err != context.Canceled {
+		t.Errorf("canceled DecodeURLWithContext() error = %v, want %v", err, context.Canceled)
+	}
+	close(release)
+	if err := <-followed; err != nil {
+		t.Fatalf("DecodeURLWithContext() error = %v", err)
+	}
+	if requests != 1 || len(follower.StreamList) != 1 {
+		t.Errorf("video info fetched %d times, decoded %+v", requests, follower.StreamList)
+	}
+}
+
+func TestYoutube_decodeKey(t *testing.T) {
+	y := NewYoutube(false)
+	y.VideoID = "rFejpH_tAHM"
+	key, ok := y.decodeKey(false)
+	if !ok {
+		t.Fatal("decodeKey() of the default options should be ok")
+	}
+	same := NewYoutube(true)
+	same.VideoID = y.VideoID
+	if sameKey, _ := same.decodeKey(false); sameKey != key {
+		t.Errorf("decodeKey() = %q, want %q", sameKey, key)
+	}
+
+	tests := []struct {
+		name   string
+		option func(y *Youtube)
+	}{
+		{"refresh", nil},
+		{"Socks5Proxy", func(y *Youtube) { y.Socks5Proxy = "10.10.10.10:7878" }},
+		{"HTTPClient", func(y *Youtube) { y.HTTPClient = &http.Client{} }},
+		{"Session", func(y *Youtube) { y.Session = NewSession() }},
+		{"Limits", func(y *Youtube) { y.Limits.MaxResponseSize = 1024 }},
+		{"Jitter", func(y *Youtube) { y.Jitter.Delay = time.Second }},
+		{"POTokenProvider", func(y *Youtube) { y.POTokenProvider = &fakeTokenProvider{} }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			other := NewYoutube(false)
+			other.VideoID = y.VideoID
+			refresh := tt.option == nil
+			if !refresh {
+				tt.option(other)
+			}
+			if otherKey, ok := other.decodeKey(refresh); !ok || otherKey == key {
+				t.Errorf("decodeKey() = %q, %t, want another key", otherKey, ok)
+			}
+		})
+	}
+}
+
+func TestYoutube_DecodeURLWithContext_MalformedPlayerResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("status=ok&player_response={bad"))
+	}))
+	defer server.Close()
+	defer func(url string) { baseURL = url }(baseURL)
+	baseURL = server.URL
+
+	if err := NewYoutube(false).DecodeURLWithContext(context.Background(), "rFejpH_tAHM"); err == nil {
+		t.Error("DecodeURLWithContext() of a malformed player response should fail")
+	}
+}
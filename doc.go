@@ -1,4 +1,11 @@
 /*
 Package youtube implement youtube download package in go.
+
+The APIs marked deprecated keep working until the next major version, see
+VERSIONING.md for the compatibility promise and the v2 layout of the client,
+video, playlist and download packages.
+
+Built with the metadataonly tag, the package leaves out the download
+machinery and only parses video ids and extracts their metadata.
 */
 package youtube
@@ -0,0 +1,270 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package youtube
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+//StartDownload : Starting download video by arguments
+//
+// Deprecated: use StartDownloadWithOptions, which can be canceled and
+// supports every download option.
+func (y *Youtube) StartDownload(outputDir, outputFile, quality string, itagNo int) error {
+	return y.StartDownloadWithOptions(context.Background(), DownloadOptions{
+		OutputDir:  outputDir,
+		OutputFile: outputFile,
+		Quality:    quality,
+		ItagNo:     itagNo,
+	})
+}
+
+// StartDownloadWithOptions starts downloading the video, the non-zero fields
+// of opts override the client defaults for this download only.
+func (y *Youtube) StartDownloadWithOptions(ctx context.Context, opts DownloadOptions) error {
+	_, err := y.download(ctx, opts)
+	return err
+}
+
+// download downloads the video according to opts and returns the path of the file written.
+func (y *Youtube) download(ctx context.Context, opts DownloadOptions) (string, error) {
+	opts, stream, err := y.prepareDownload(opts)
+	if err != nil {
+		return "", err
+	}
+	destFile, err := destination(opts, stream)
+	if err != nil {
+		return "", err
+	}
+	// another process, or another download of this one, may write the same file
+	lock, err := lockFile(destFile, false)
+	if err != nil {
+		return destFile, err
+	}
+	defer lock.unlock()
+	streamURL := stream.URL
+	y.log(fmt.Sprintln("Download url=", streamURL))
+	y.log(fmt.Sprintln("Download to file=", destFile))
+	for attempt := 0; ; attempt++ {
+		if err := y.waitTransferWindow(ctx); err != nil {
+			return destFile, err
+		}
+		stream, err = y.freshStream(ctx, stream)
+		if err != nil {
+			return destFile, err
+		}
+		err = y.videoDLWorker(ctx, destFile, stream.URL, stream.Type, opts)
+		y.Health.record(stream.ItagNo, err)
+		if err == nil {
+			return y.checkExtension(destFile, opts.FixExtension), nil
+		}
+		if attempt >= opts.Retries || !retryable(ctx, err) {
+			return destFile, err
+		}
+		y.log(fmt.Sprintf("download attempt %d failed, retrying: %s", attempt+1, err))
+		if err := sleepContext(ctx, backoff(attempt)); err != nil {
+			return destFile, err
+		}
+	}
+}
+
+// destination returns the path stream is downloaded to according to opts,
+// which defaults were applied.
+func destination(opts DownloadOptions, stream stream) (string, error) {
+	outputDir := opts.OutputDir
+	if outputDir == "" {
+		var err error
+		outputDir, err = defaultOutputDir()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	outputFile := opts.Filesystem.FileName(outputDir, opts.OutputFile)
+	if outputFile == "" {
+		outputFile = opts.Filesystem.FileName(outputDir, stream.Title+pickIdealFileExtension(stream.Type))
+	}
+	return filepath.Join(outputDir, outputFile), nil
+}
+
+// StartDownloadToWriter downloads the video into w instead of a file, the
+// output directory and file of opts are ignored. It is the only way to
+// download where no filesystem is available, such as js/wasm.
+func (y *Youtube) StartDownloadToWriter(ctx context.Context, w io.Writer, opts DownloadOptions) error {
+	opts, stream, err := y.prepareDownload(opts)
+	if err != nil {
+		return err
+	}
+
+	if err := y.waitTransferWindow(ctx); err != nil {
+		return err
+	}
+	stream, err = y.freshStream(ctx, stream)
+	if err != nil {
+		return err
+	}
+	y.log(fmt.Sprintln("Download url=", stream.URL))
+	err = y.streamWorker(ctx, stream.URL, stream.Type, opts, 0, func(int64) (io.Writer, error) {
+		return w, nil
+	})
+	y.Health.record(stream.ItagNo, err)
+	return err
+}
+
+func (y *Youtube) Write(p []byte) (n int, err error) {
+	n = len(p)
+	y.totalWrittenBytes = y.totalWrittenBytes + float64(n)
+	currentPercent := y.progress.percent(y.totalWrittenBytes / y.contentLength)
+	if (y.downloadLevel <= currentPercent) && (y.downloadLevel < 100) {
+		y.downloadLevel++
+		// nothing may read DownloadPercent, a full channel must not block the download
+		select {
+		case y.DownloadPercent <- int64(y.downloadLevel):
+		default:
+		}
+		if y.OnProgress != nil {
+			y.OnProgress(Progress{Phase: PhaseDownload, Percent: int64(y.downloadLevel)})
+		}
+	}
+	return
+}
+
+func (y *Youtube) videoDLWorker(ctx context.Context, destFile string, target string, mimeType string, opts DownloadOptions) error {
+	if opts.Resume {
+		return y.resumeDLWorker(ctx, destFile, target, mimeType, opts)
+	}
+	var out *os.File
+	err := y.streamWorker(ctx, target, mimeType, opts, 0, func(int64) (io.Writer, error) {
+		if err := y.trashExisting(destFile); err != nil {
+			return nil, err
+		}
+		var err error
+		out, err = createDestination(destFile)
+		return out, err
+	})
+	if out == nil {
+		return err
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// streamWorker downloads target from offset into the writer returned by open,
+// which is only called once the answer was verified to be the expected media.
+// open receives the offset the answer actually starts at, 0 when the server
+// ignored the requested range.
+func (y *Youtube) streamWorker(ctx context.Context, target string, mimeType string, opts DownloadOptions, offset int64, open func(start int64) (io.Writer, error)) error {
+
+	httpClient, err := y.getHTTPClientWithProxy(opts.Socks5Proxy)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stall := newStallDetector(cancel, stallTimeout)
+	defer stall.stop()
+
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		y.log(fmt.Sprintf("Http.Get\nerror: %s\ntarget: %s\n", err, target))
+		if stall.stalled() {
+			return ErrDownloadStalled
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	start, complete, err := resumeOffset(resp, offset)
+	if err != nil {
+		y.log(fmt.Sprintf("reading answer: unexpected [code=%v] status code received: '%v'", resp.StatusCode, err))
+		return err
+	}
+	if complete {
+		return nil
+	}
+	maxStreamSize := y.Limits.MaxStreamSize
+	if maxStreamSize > 0 && start+resp.ContentLength > maxStreamSize {
+		return ErrLimitExceeded{Limit: "MaxStreamSize", Max: maxStreamSize}
+	}
+	y.contentLength = float64(start + resp.ContentLength)
+	y.totalWrittenBytes = float64(start)
+	if y.progress == nil {
+		y.downloadLevel = 0
+	}
+
+	body, err := verifyContentType(resp, mimeType, start > 0)
+	if err != nil {
+		y.log(fmt.Sprintf("verifying answer: %s", err))
+		if stall.stalled() {
+			return ErrDownloadStalled
+		}
+		return err
+	}
+	if maxStreamSize > 0 {
+		body = newLimitedReader(body, maxStreamSize-start, "MaxStreamSize")
+	}
+	body = stall.reader(body)
+	out, err := open(start)
+	if err != nil {
+		return err
+	}
+	if opts.RateLimit > 0 {
+		body = newRateLimitedReader(body, opts.RateLimit)
+	}
+	mw := io.MultiWriter(out, y)
+	_, err = io.Copy(mw, body)
+	if err != nil {
+		y.log(fmt.Sprintln("download video err=", err))
+		if stall.stalled() {
+			return ErrDownloadStalled
+		}
+		return err
+	}
+	return nil
+}
+
+// waitTransferWindow waits for a transfer window of y to open, or until ctx is done.
+func (y *Youtube) waitTransferWindow(ctx context.Context) error {
+	wait := y.TransferWindows.until(time.Now())
+	if wait <= 0 {
+		return nil
+	}
+	y.log(fmt.Sprintf("waiting %s for the next transfer window", wait.Round(time.Second)))
+	return sleepContext(ctx, wait)
+}
+
+// trashExisting moves the regular file at path into the trash of y, when y
+// has a trash and the file exists.
+func (y *Youtube) trashExisting(path string) error {
+	if y.Trash == nil {
+		return nil
+	}
+	fi, err := os.Stat(path)
+	if err != nil || !fi.Mode().IsRegular() {
+		return nil
+	}
+	trashed, err := y.Trash.Put(path)
+	if err != nil {
+		return err
+	}
+	y.log("moved the replaced " + path + " to " + trashed)
+	return nil
+}
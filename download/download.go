@@ -0,0 +1,49 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+/*
+Package download downloads the videos and the lists of the client package,
+in the v2 layout described in VERSIONING.md. Every download has its own
+state, so several can run at once, even of the same video.
+*/
+package download
+
+import (
+	"context"
+	"io"
+
+	"github.com/kkdai/youtube"
+	"github.com/kkdai/youtube/client"
+	"github.com/kkdai/youtube/playlist"
+	"github.com/kkdai/youtube/video"
+)
+
+// File downloads the format of v selected by opts and returns the path of
+// the file written.
+func File(ctx context.Context, v *video.Video, opts youtube.DownloadOptions) (string, error) {
+	y := v.Youtube()
+	planned, err := y.PlanDownload(opts)
+	if err != nil {
+		return "", err
+	}
+	// DownloadFormats returns the path, renamed by FixExtension
+	opts = planned.Options
+	opts.ItagNo = 0
+	paths, err := y.DownloadFormats(ctx, []int{planned.Format.ItagNo}, opts)
+	if err != nil {
+		return planned.Path, err
+	}
+	return paths[0], nil
+}
+
+// Writer downloads the format of v selected by opts into w, the output
+// directory and file of opts are ignored.
+func Writer(ctx context.Context, w io.Writer, v *video.Video, opts youtube.DownloadOptions) error {
+	return v.Youtube().StartDownloadToWriter(ctx, w, opts)
+}
+
+// Playlist downloads the items of p one after the other with the options of
+// c, see Youtube.DownloadList.
+func Playlist(ctx context.Context, c *client.Client, p *playlist.Playlist, opts youtube.DownloadOptions) ([]youtube.ListItemResult, error) {
+	return c.Youtube().DownloadList(ctx, p.Items, opts)
+}
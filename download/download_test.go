@@ -0,0 +1,151 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package download
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kkdai/youtube"
+	"github.com/kkdai/youtube/client"
+	"github.com/kkdai/youtube/playlist"
+	"github.com/kkdai/youtube/video"
+)
+
+var media = append([]byte("\x00\x00\x00\x18ftypmp42\x00\x00\x00\x00mp42isom"), bytes.Repeat([]byte{1}, 64*1024)...)
+
+// redirectTransport sends every request to server, whatever its host.
+type redirectTransport struct {
+	server *httptest.Server
+}
+
+func (t redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	target, _ := url.Parse(t.server.URL)
+	req.URL.Scheme, req.URL.Host = target.Scheme, target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// newServer serves the video info of a video with a single muxed stream, and
+// the stream.
+func newServer(t *testing.T) *httptest.Server {
+	playerResponse, err := json.Marshal(map[string]interface{}{
+		"playabilityStatus": map[string]string{"status": "OK"},
+		"videoDetails":      map[string]string{"title": "talk", "author": "dotconferences"},
+		"streamingData": map[string]interface{}{
+			"formats": []map[string]interface{}{
+				{"itag": 18, "url": "https://r1.googlevideo.com/videoplayback?itag=18", "mimeType": "video/mp4", "quality": "medium"},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	videoInfo := url.Values{"status": {"ok"}, "player_response": {string(playerResponse)}}.Encode()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/get_video_info":
+			w.Write([]byte(videoInfo))
+		case "/videoplayback":
+			w.Header().Set("Content-Type", "video/mp4")
+			http.ServeContent(w, r, "video.mp4", time.Time{}, bytes.NewReader(media))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func newVideo(t *testing.T, server *httptest.Server) (*client.Client, *video.Video) {
+	c, err := client.New(youtube.Options{HTTPClient: &http.Client{Transport: redirectTransport{server}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := c.Video(context.Background(), "rFejpH_tAHM")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, v
+}
+
+func TestFile(t *testing.T) {
+	server := newServer(t)
+	defer server.Close()
+	_, v := newVideo(t, server)
+	dir, err := ioutil.TempDir("", "youtube")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the downloads of the same video don't share their state
+	names := []string{"a.mp4", "b.mp4", "c.mp4"}
+	var wg sync.WaitGroup
+	for _, name := range names {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			path, err := File(context.Background(), v, youtube.DownloadOptions{OutputDir: dir, OutputFile: name})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if path != filepath.Join(dir, name) {
+				t.Errorf("File() = %s, want %s", path, filepath.Join(dir, name))
+			}
+		}(name)
+	}
+	wg.Wait()
+	for _, name := range names {
+		if got, _ := ioutil.ReadFile(filepath.Join(dir, name)); !bytes.Equal(got, media) {
+			t.Errorf("%s holds %d bytes, want the media", name, len(got))
+		}
+	}
+
+	if _, err := File(context.Background(), v, youtube.DownloadOptions{ItagNo: 22}); err != youtube.ErrItagNotFound {
+		t.Errorf("File() error = %v, want ErrItagNotFound", err)
+	}
+}
+
+func TestWriter(t *testing.T) {
+	server := newServer(t)
+	defer server.Close()
+	_, v := newVideo(t, server)
+
+	var buf bytes.Buffer
+	if err := Writer(context.Background(), &buf, v, youtube.DownloadOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), media) {
+		t.Errorf("Writer() wrote %d bytes, want the media", buf.Len())
+	}
+}
+
+func TestPlaylist(t *testing.T) {
+	server := newServer(t)
+	defer server.Close()
+	c, _ := newVideo(t, server)
+	dir, err := ioutil.TempDir("", "youtube")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	p := &playlist.Playlist{Items: []youtube.ListItem{{Index: 0, VideoID: "rFejpH_tAHM"}}}
+	results, err := Playlist(context.Background(), c, p, youtube.DownloadOptions{OutputDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Err != nil || results[0].Path != filepath.Join(dir, "talk.mp4") {
+		t.Errorf("Playlist() = %+v", results)
+	}
+}
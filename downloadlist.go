@@ -0,0 +1,84 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package youtube
+
+import (
+	"context"
+	"time"
+)
+
+// DownloadList downloads the items one after the other, each named after
+// its title in the output directory of opts, whose OutputFile is ignored.
+// The lifecycle of each item is reported to OnItemStart, then to either
+// OnItemComplete or OnItemSkipped, so a GUI can show a live table of the
+// items. The items are synced with the Archive of y when set.
+//
+// A failed item doesn't stop the list, the results hold the outcome of
+// every item. DownloadList stops when ctx is done, returning the results of
+// the items processed so far.
+func (y *Youtube) DownloadList(ctx context.Context, items []ListItem, opts DownloadOptions) ([]ListItemResult, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	opts.OutputFile = ""
+	return y.runList(ctx, items, func(ListItem) DownloadOptions { return opts })
+}
+
+// runList downloads the items one after the other, each with its options
+// returned by itemOptions, see DownloadList.
+func (y *Youtube) runList(ctx context.Context, items []ListItem, itemOptions func(ListItem) DownloadOptions) ([]ListItemResult, error) {
+	results := make([]ListItemResult, 0, len(items))
+	for _, item := range items {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		if y.OnItemStart != nil {
+			y.OnItemStart(item)
+		}
+		result := y.downloadItem(ctx, item, itemOptions(item))
+		results = append(results, result)
+		switch {
+		case result.Skipped != "":
+			if y.OnItemSkipped != nil {
+				y.OnItemSkipped(result)
+			}
+		case y.OnItemComplete != nil:
+			y.OnItemComplete(result)
+		}
+	}
+	return results, nil
+}
+
+func (y *Youtube) downloadItem(ctx context.Context, item ListItem, opts DownloadOptions) (result ListItemResult) {
+	started := time.Now()
+	result.Item = item
+	defer func() { result.Elapsed = time.Since(started) }()
+
+	video, err := NewYoutubeWithOptions(y.options())
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	if result.Err = video.DecodeURLWithContext(ctx, item.VideoID); result.Err != nil {
+		return result
+	}
+	if info := video.GetItagInfo(); info != nil {
+		result.Title, result.Author = info.Title, info.Author
+	}
+	if video.Archive != nil {
+		var entry ArchiveEntry
+		var action SyncAction
+		entry, action, result.Err = video.Sync(ctx, opts)
+		result.Path = entry.Path
+		if action == SyncSkipped {
+			result.Skipped = SkipArchived
+		}
+	} else {
+		result.Path, result.Err = video.download(ctx, opts)
+	}
+	if result.Err == ErrLocked {
+		result.Skipped, result.Err = SkipLocked, nil
+	}
+	return result
+}
@@ -3,6 +3,7 @@ package youtube
 import (
 	"errors"
 	"fmt"
+	"strings"
 )
 
 var (
@@ -11,6 +12,22 @@ var (
 	ErrCipherNotFound             = errors.New("cipher not found")
 	ErrInvalidCharactersInVideoId = errors.New("invalid characters in video id")
 	ErrVideoIdMinLength           = errors.New("the video id must be at least 10 characters long")
+	ErrDownloadStalled            = errors.New("download stalled, no data received")
+	ErrQueueClosed                = errors.New("queue is closed")
+	ErrJobNotFound                = errors.New("job not found")
+	ErrJobFinished                = errors.New("job already finished")
+	ErrFormatIndexOutOfRange      = errors.New("chosen format index out of range")
+	ErrNoAudioFormat              = errors.New("no audio only format")
+	ErrInvalidMusicURL            = errors.New("not a youtube music album or artist url")
+	ErrStateVersion               = errors.New("unsupported session state version")
+	ErrCaptionNotFound            = errors.New("no caption track in this language")
+	ErrThumbnailNotFound          = errors.New("no thumbnail")
+	ErrInvalidPostURL             = errors.New("not a youtube community post url")
+	ErrPostNotFound               = errors.New("community post not found in the page")
+	ErrManifestVersion            = errors.New("unsupported manifest version")
+	ErrNoArchive                  = errors.New("no archive to sync with")
+	ErrLocked                     = errors.New("file locked by another download")
+	ErrPlanVersion                = errors.New("unsupported plan version")
 )
 
 type ErrDecodingStreamInfo struct {
@@ -20,3 +37,72 @@ type ErrDecodingStreamInfo struct {
 func (err ErrDecodingStreamInfo) Error() string {
 	return fmt.Sprintf("An error occurred while decoding one of the video's stream's information: stream %d.\n", err.streamPos)
 }
+
+// ErrInvalidOption reports an option which value can't be used.
+type ErrInvalidOption struct {
+	Option string
+	Reason string
+}
+
+func (err ErrInvalidOption) Error() string {
+	return fmt.Sprintf("invalid option %s: %s", err.Option, err.Reason)
+}
+
+// ErrInvalidOptions aggregates every invalid option found during validation.
+type ErrInvalidOptions []error
+
+func (errs ErrInvalidOptions) Error() string {
+	msgs := make([]string, 0, len(errs))
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("%d invalid options: %s", len(errs), strings.Join(msgs, "; "))
+}
+
+// Is reports whether any of the aggregated errors matches target.
+func (errs ErrInvalidOptions) Is(target error) bool {
+	for _, err := range errs {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrUnexpectedContentType is returned when the stream server answers with something else than the selected media.
+type ErrUnexpectedContentType struct {
+	Expected string
+	Got      string
+}
+
+func (err ErrUnexpectedContentType) Error() string {
+	return fmt.Sprintf("unexpected content type: expected %s, got %s", err.Expected, err.Got)
+}
+
+// ErrUnexpectedStatusCode is returned when a request is answered with a non 200 status code.
+type ErrUnexpectedStatusCode int
+
+func (err ErrUnexpectedStatusCode) Error() string {
+	return fmt.Sprintf("unexpected status code: %d", int(err))
+}
+
+// ErrLimitExceeded is returned when an input exceeds one of the Limits.
+type ErrLimitExceeded struct {
+	Limit string
+	Max   int64
+}
+
+func (err ErrLimitExceeded) Error() string {
+	return fmt.Sprintf("limit exceeded: %s is %d", err.Limit, err.Max)
+}
+
+// ErrMirrorDamaged is returned by Verify when files of a mirror are missing
+// or don't match their checksum anymore.
+type ErrMirrorDamaged struct {
+	Missing   []string
+	Corrupted []string
+}
+
+func (err ErrMirrorDamaged) Error() string {
+	return fmt.Sprintf("mirror damaged: %d missing files, %d corrupted files", len(err.Missing), len(err.Corrupted))
+}
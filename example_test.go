@@ -1,3 +1,6 @@
+//go:build !metadataonly
+// +build !metadataonly
+
 package youtube_test
 
 import (
@@ -0,0 +1,96 @@
+package youtube
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// baseURL is the origin the metadata is fetched from, tests point it to a fake server.
+var baseURL = "https://youtube.com"
+
+// httpGetBody fetches url asking explicitly for a compressed answer, which
+// cuts the bandwidth of the large player responses and base.js, and returns
+// the decoded body.
+func (y *Youtube) httpGetBody(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return y.httpBody(ctx, req)
+}
+
+// httpPostJSON posts body encoded as json to url and returns the decoded answer.
+func (y *Youtube) httpPostJSON(ctx context.Context, url string, body interface{}) ([]byte, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return y.httpBody(ctx, req)
+}
+
+// httpBody sends req and returns its decoded body, see httpGetBody.
+func (y *Youtube) httpBody(ctx context.Context, req *http.Request) ([]byte, error) {
+	if err := y.Jitter.wait(ctx); err != nil {
+		return nil, err
+	}
+	httpClient, err := y.getHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, ErrUnexpectedStatusCode(resp.StatusCode)
+	}
+
+	body, err := decodeBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return ioutil.ReadAll(newLimitedReader(body, y.Limits.MaxResponseSize, "MaxResponseSize"))
+}
+
+// decodeBody returns the body of resp decoded according to its Content-Encoding.
+func decodeBody(resp *http.Response) (io.ReadCloser, error) {
+	switch encoding := strings.ToLower(resp.Header.Get("Content-Encoding")); encoding {
+	case "", "identity":
+		return resp.Body, nil
+	case "gzip":
+		return gzip.NewReader(resp.Body)
+	case "deflate":
+		// deflate should be zlib wrapped, but some servers send raw deflate data
+		body := bufio.NewReader(resp.Body)
+		header, err := body.Peek(2)
+		if err != nil {
+			return nil, err
+		}
+		if header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+			return zlib.NewReader(body)
+		}
+		return flate.NewReader(body), nil
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %q", encoding)
+	}
+}
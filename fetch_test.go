@@ -0,0 +1,74 @@
+package youtube
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestYoutube_httpGetBody(t *testing.T) {
+	payload := []byte(`{"videoDetails":{"title":"test"}}`)
+	compress := func(encoding string) []byte {
+		var buf bytes.Buffer
+		var w io.WriteCloser
+		switch encoding {
+		case "gzip":
+			w = gzip.NewWriter(&buf)
+		case "deflate":
+			w = zlib.NewWriter(&buf)
+		case "raw deflate":
+			w, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+		default:
+			return payload
+		}
+		w.Write(payload)
+		w.Close()
+		return buf.Bytes()
+	}
+
+	for _, encoding := range []string{"", "gzip", "deflate", "raw deflate"} {
+		t.Run("encoding "+encoding, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if got := r.Header.Get("Accept-Encoding"); got != "gzip, deflate" {
+					t.Errorf("Accept-Encoding = %q, want compressed answers", got)
+				}
+				if encoding != "" {
+					w.Header().Set("Content-Encoding", map[string]string{
+						"gzip":        "gzip",
+						"deflate":     "deflate",
+						"raw deflate": "deflate",
+					}[encoding])
+				}
+				w.Write(compress(encoding))
+			}))
+			defer server.Close()
+
+			got, err := NewYoutube(false).httpGetBody(context.Background(), server.URL)
+			if err != nil {
+				t.Fatalf("httpGetBody() error = %v", err)
+			}
+			if !bytes.Equal(got, payload) {
+				t.Errorf("httpGetBody() = %q, want %q", got, payload)
+			}
+		})
+	}
+}
+
+func TestYoutube_httpGetBody_StatusCode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	_, err := NewYoutube(false).httpGetBody(context.Background(), server.URL)
+	if !errors.Is(err, ErrUnexpectedStatusCode(http.StatusForbidden)) {
+		t.Errorf("httpGetBody() error = %v, want %v", err, ErrUnexpectedStatusCode(http.StatusForbidden))
+	}
+}
@@ -0,0 +1,127 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+/*
+Package ffmpeg implements youtube.Processor by running the ffmpeg command line tool.
+
+It doesn't use cgo, the ffmpeg binary only has to be installed where the program runs.
+*/
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kkdai/youtube"
+)
+
+var (
+	_ youtube.Processor      = (*FFmpeg)(nil)
+	_ youtube.AudioConverter = (*FFmpeg)(nil)
+	_ youtube.Segmenter      = (*FFmpeg)(nil)
+)
+
+// FFmpeg runs the ffmpeg binary found at Path.
+type FFmpeg struct {
+	Path string
+}
+
+// New returns an FFmpeg running the ffmpeg binary found in PATH.
+func New() (*FFmpeg, error) {
+	path, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return nil, err
+	}
+	return &FFmpeg{Path: path}, nil
+}
+
+// Merge muxes the video stream of videoFile and the audio stream of audioFile
+// into destFile without re-encoding them.
+func (f *FFmpeg) Merge(ctx context.Context, videoFile, audioFile, destFile string) error {
+	return f.run(ctx,
+		"-i", videoFile,
+		"-i", audioFile,
+		"-map", "0:v:0",
+		"-map", "1:a:0",
+		"-c", "copy",
+		destFile,
+	)
+}
+
+// ConvertAudio decodes the audio stream of srcFile and writes it to destFile
+// as 16 bits PCM WAV, resampled to sampleRate and mixed to channels.
+func (f *FFmpeg) ConvertAudio(ctx context.Context, srcFile, destFile string, sampleRate, channels int) error {
+	return f.run(ctx,
+		"-i", srcFile,
+		"-vn",
+		"-ac", strconv.Itoa(channels),
+		"-ar", strconv.Itoa(sampleRate),
+		"-c:a", "pcm_s16le",
+		"-f", "wav",
+		destFile,
+	)
+}
+
+// Segment writes the segments of srcFile into destDir, named segment00000.ts
+// (along with index.m3u8) or segment00000.mp4 according to opts.Format.
+func (f *FFmpeg) Segment(ctx context.Context, srcFile, destDir string, opts youtube.SegmentOptions) ([]string, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return nil, err
+	}
+
+	seconds := strconv.FormatFloat(opts.Duration.Seconds(), 'f', -1, 64)
+	args := []string{"-i", srcFile, "-map", "0"}
+	if opts.KeyframeAligned {
+		args = append(args, "-c", "copy")
+	} else {
+		args = append(args, "-c:v", "libx264", "-c:a", "aac", "-force_key_frames", "expr:gte(t,n_forced*"+seconds+")")
+	}
+
+	ext := ".ts"
+	if opts.Format == youtube.SegmentMP4 {
+		ext = ".mp4"
+		args = append(args,
+			"-f", "segment",
+			"-segment_time", seconds,
+			"-reset_timestamps", "1",
+			filepath.Join(destDir, "segment%05d"+ext),
+		)
+	} else {
+		args = append(args,
+			"-f", "hls",
+			"-hls_time", seconds,
+			"-hls_playlist_type", "vod",
+			"-hls_segment_filename", filepath.Join(destDir, "segment%05d"+ext),
+			filepath.Join(destDir, "index.m3u8"),
+		)
+	}
+	if err := f.run(ctx, args...); err != nil {
+		return nil, err
+	}
+
+	// the zero padded names sort in playing order
+	segments, err := filepath.Glob(filepath.Join(destDir, "segment*"+ext))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(segments)
+	return segments, nil
+}
+
+func (f *FFmpeg) run(ctx context.Context, args ...string) error {
+	args = append([]string{"-hide_banner", "-loglevel", "error", "-y"}, args...)
+	output, err := exec.CommandContext(ctx, f.Path, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ffmpeg %s: %s: %s", strings.Join(args, " "), err, output)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package ffmpeg
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kkdai/youtube"
+)
+
+func newTestFFmpeg(t *testing.T) *FFmpeg {
+	f, err := New()
+	if err != nil {
+		t.Skip("ffmpeg is not installed:", err)
+	}
+	return f
+}
+
+func TestFFmpeg_Merge(t *testing.T) {
+	f := newTestFFmpeg(t)
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "ffmpeg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	videoFile := filepath.Join(dir, "video.mp4")
+	audioFile := filepath.Join(dir, "audio.m4a")
+	if err := f.run(ctx, "-f", "lavfi", "-i", "testsrc=duration=1:size=64x64", videoFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.run(ctx, "-f", "lavfi", "-i", "sine=duration=1", audioFile); err != nil {
+		t.Fatal(err)
+	}
+
+	destFile := filepath.Join(dir, "merged.mp4")
+	if err := f.Merge(ctx, videoFile, audioFile, destFile); err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+	if fi, err := os.Stat(destFile); err != nil || fi.Size() == 0 {
+		t.Errorf("Merge() didn't produce %s", destFile)
+	}
+}
+
+func TestFFmpeg_ConvertAudio(t *testing.T) {
+	f := newTestFFmpeg(t)
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "ffmpeg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	srcFile := filepath.Join(dir, "audio.webm")
+	if err := f.run(ctx, "-f", "lavfi", "-i", "sine=duration=1", "-ac", "2", "-c:a", "libopus", srcFile); err != nil {
+		t.Fatal(err)
+	}
+	destFile := filepath.Join(dir, "audio.wav")
+	if err := f.ConvertAudio(ctx, srcFile, destFile, 16000, 1); err != nil {
+		t.Fatalf("ConvertAudio() error = %v", err)
+	}
+	// a second of 16 kHz mono 16 bits PCM, after the 44 bytes of the header
+	if fi, err := os.Stat(destFile); err != nil || fi.Size() < 32000 {
+		t.Errorf("ConvertAudio() didn't produce a second of audio in %s", destFile)
+	}
+}
+
+func TestFFmpeg_Segment(t *testing.T) {
+	f := newTestFFmpeg(t)
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "ffmpeg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	srcFile := filepath.Join(dir, "video.mp4")
+	if err := f.run(ctx, "-f", "lavfi", "-i", "testsrc=duration=4:size=64x64:rate=10", "-g", "10", srcFile); err != nil {
+		t.Fatal(err)
+	}
+	for _, opts := range []youtube.SegmentOptions{
+		{Duration: time.Second, Format: youtube.SegmentHLS, KeyframeAligned: true},
+		{Duration: time.Second, Format: youtube.SegmentMP4},
+	} {
+		destDir := filepath.Join(dir, string(opts.Format))
+		segments, err := f.Segment(ctx, srcFile, destDir, opts)
+		if err != nil {
+			t.Fatalf("Segment(%s) error = %v", opts.Format, err)
+		}
+		if len(segments) != 4 {
+			t.Errorf("Segment(%s) = %q, want 4 segments", opts.Format, segments)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "hls", "index.m3u8")); err != nil {
+		t.Errorf("Segment(hls) should write the playlist: %v", err)
+	}
+}
+
+func TestFFmpeg_MissingBinary(t *testing.T) {
+	f := &FFmpeg{Path: filepath.Join("does", "not", "exist")}
+	if err := f.Merge(context.Background(), "video.mp4", "audio.m4a", "merged.mp4"); err == nil {
+		t.Error("Merge() should fail without an ffmpeg binary")
+	}
+}
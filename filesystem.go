@@ -0,0 +1,137 @@
+package youtube
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Filesystem is the filesystem the downloads are written to, the names of
+// the files follow its restrictions. The zero value applies SanitizeFilename,
+// a lowest common denominator which doesn't bound the length of the names.
+type Filesystem string
+
+// The supported filesystems.
+const (
+	// Ext4 only forbids slashes, names are up to 255 bytes.
+	Ext4 Filesystem = "ext4"
+	// NTFS follows the Windows rules: no <>:"/\|?* nor control characters,
+	// no reserved device names such as CON or NUL, no trailing dot or
+	// space, names up to 255 UTF-16 units and paths up to 259.
+	NTFS Filesystem = "ntfs"
+	// ExFAT has the characters and lengths of NTFS, the Windows rules
+	// apply as exFAT drives are mostly shared with Windows.
+	ExFAT Filesystem = "exfat"
+	// SMB shares follow the Windows rules, the filesystem behind the share
+	// is unknown and Windows clients must be able to open the files.
+	SMB Filesystem = "smb"
+	// ASCII follows the Windows rules with the names transliterated to
+	// ASCII, for the old FAT drives, players and tools which mangle the
+	// other characters.
+	ASCII Filesystem = "ascii"
+)
+
+// filesystemProfile is the naming restrictions of a filesystem.
+type filesystemProfile struct {
+	forbidden *regexp.Regexp
+	// windows forbids the reserved device names and the trailing dots and spaces.
+	windows bool
+	// utf16 measures the lengths in UTF-16 units rather than in bytes.
+	utf16 bool
+	// ascii transliterates the names to ASCII.
+	ascii   bool
+	maxName int
+	maxPath int
+}
+
+var (
+	windowsForbidden = regexp.MustCompile(`[<>:"/\\|?*\x00-\x1f]`)
+	windowsReserved  = regexp.MustCompile(`(?i)^(con|prn|aux|nul|com[0-9]|lpt[0-9])$`)
+	windowsProfile   = filesystemProfile{forbidden: windowsForbidden, windows: true, utf16: true, maxName: 255, maxPath: 259}
+	whitespaces      = regexp.MustCompile(`\s+`)
+)
+
+var filesystems = map[Filesystem]filesystemProfile{
+	Ext4:  {forbidden: regexp.MustCompile(`[/\x00]`), maxName: 255, maxPath: 4095},
+	NTFS:  windowsProfile,
+	ExFAT: windowsProfile,
+	SMB:   windowsProfile,
+	ASCII: {forbidden: windowsForbidden, windows: true, ascii: true, maxName: 255, maxPath: 259},
+}
+
+// validate returns the problems of the filesystem.
+func (fs Filesystem) validate() ErrInvalidOptions {
+	if _, ok := filesystems[fs]; fs != "" && !ok {
+		return ErrInvalidOptions{ErrInvalidOption{Option: "Filesystem", Reason: "unknown filesystem " + string(fs)}}
+	}
+	return nil
+}
+
+// FileName returns name made valid on fs. Its base is shortened, keeping its
+// extension, to fit the name length and the path length of fs in dir.
+func (fs Filesystem) FileName(dir, name string) string {
+	return fs.fileName(dir, name, "")
+}
+
+// fileName returns the valid name on fs of name with suffix inserted ahead of
+// its extension, the suffix is kept when the base is shortened.
+func (fs Filesystem) fileName(dir, name, suffix string) string {
+	ext := filepath.Ext(name)
+	if strings.Contains(ext, " ") {
+		// a dot in a title rather than an extension
+		ext = ""
+	}
+	name = strings.TrimSuffix(name, ext) + suffix + ext
+	profile, ok := filesystems[fs]
+	if !ok {
+		return SanitizeFilename(name)
+	}
+
+	name = fileNameText(name)
+	if profile.ascii {
+		name = transliterate(name)
+	}
+	name = profile.forbidden.ReplaceAllString(name, "")
+	name = strings.TrimSpace(whitespaces.ReplaceAllString(name, " "))
+	if profile.windows {
+		name = strings.TrimRight(name, ". ")
+		base := strings.SplitN(name, ".", 2)[0]
+		if windowsReserved.MatchString(strings.TrimSpace(base)) {
+			name = "_" + name
+		}
+	}
+
+	max := profile.maxName
+	if dir != "" {
+		// the separator joining dir and name takes one unit
+		if left := profile.maxPath - profile.length(dir) - 1; left < max {
+			max = left
+		}
+	}
+	if profile.length(name) <= max {
+		return name
+	}
+	tail := profile.forbidden.ReplaceAllString(suffix+ext, "")
+	if !strings.HasSuffix(name, tail) || profile.length(tail) >= max {
+		tail = ""
+	}
+	base := strings.TrimSuffix(name, tail)
+	for profile.length(base)+profile.length(tail) > max && base != "" {
+		_, size := utf8.DecodeLastRuneInString(base)
+		base = base[:len(base)-size]
+	}
+	if profile.windows {
+		base = strings.TrimRight(base, ". ")
+	}
+	return base + tail
+}
+
+// length returns the length of s as measured by the filesystem.
+func (p filesystemProfile) length(s string) int {
+	if p.utf16 {
+		return len(utf16.Encode([]rune(s)))
+	}
+	return len(s)
+}
@@ -0,0 +1,71 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package youtube
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFilesystemFileName(t *testing.T) {
+	longTitle := strings.Repeat("a", 300)
+	longEmoji := strings.Repeat("😀", 200)
+	tests := []struct {
+		name string
+		fs   Filesystem
+		dir  string
+		file string
+		want string
+	}{
+		{name: "default", fs: "", file: `a:b?c  d.mp4`, want: "abc d.mp4"},
+		{name: "ext4 keeps windows characters", fs: Ext4, file: `a:b?c*.mp4`, want: "a:b?c*.mp4"},
+		{name: "ext4 strips slashes", fs: Ext4, file: "a/b.mp4", want: "ab.mp4"},
+		{name: "ntfs strips characters", fs: NTFS, file: "a<b>c:d\"e|f?g*h\x01.mp4", want: "abcdefgh.mp4"},
+		{name: "ntfs reserved name", fs: NTFS, file: "con.mp4", want: "_con.mp4"},
+		{name: "ntfs reserved name with extensions", fs: ExFAT, file: "LPT1.tar.gz", want: "_LPT1.tar.gz"},
+		{name: "ntfs trailing dots", fs: SMB, file: "talk. . ", want: "talk"},
+		{name: "ext4 bytes", fs: Ext4, file: longEmoji + ".mp4", want: strings.Repeat("😀", 62) + ".mp4"},
+		{name: "ntfs utf-16 units", fs: NTFS, file: longEmoji + ".mp4", want: strings.Repeat("😀", 125) + ".mp4"},
+		{name: "ntfs name length", fs: NTFS, file: longTitle + ".webm", want: strings.Repeat("a", 250) + ".webm"},
+		{name: "ntfs path length", fs: NTFS, dir: `C:\` + strings.Repeat("d", 200), file: longTitle + ".mp4", want: strings.Repeat("a", 51) + ".mp4"},
+		{name: "ext4 path length", fs: Ext4, dir: "/" + strings.Repeat("d", 4000), file: longTitle + ".mp4", want: strings.Repeat("a", 89) + ".mp4"},
+		{name: "default normalizes", fs: "", file: "Cafe\u0301\u200b.mp4", want: "Café.mp4"},
+		{name: "ascii transliterates", fs: ASCII, file: "Café: 日本 \u202etalk.mp4", want: "Cafe _ talk.mp4"},
+		{name: "dot in title", fs: NTFS, file: longTitle + ". part two", want: strings.Repeat("a", 255)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.fs.FileName(tt.dir, tt.file); got != tt.want {
+				t.Errorf("FileName(%q) = %q, want %q", tt.file, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatFileNamesFilesystem(t *testing.T) {
+	title := strings.Repeat("a", 300)
+	streams := []stream{
+		{ItagNo: 22, Type: "video/mp4", Title: title},
+		{ItagNo: 137, Type: "video/mp4", Title: title},
+	}
+	names := formatFileNames(streams, NTFS, "")
+	for i, suffix := range []string{" (22).mp4", " (137).mp4"} {
+		if !strings.HasSuffix(names[i], suffix) || len(names[i]) != 255 {
+			t.Errorf("names[%d] = %q, want 255 characters ending with %q", i, names[i], suffix)
+		}
+	}
+}
+
+func TestOptionsFilesystem(t *testing.T) {
+	if err := (Options{Filesystem: "fat12"}).Validate(); err == nil {
+		t.Error("Validate() accepted an unknown filesystem")
+	}
+	y, err := NewYoutubeWithOptions(Options{Filesystem: NTFS})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts := y.withDefaults(DownloadOptions{}); opts.Filesystem != NTFS {
+		t.Errorf("withDefaults().Filesystem = %q, want %q", opts.Filesystem, NTFS)
+	}
+}
@@ -0,0 +1,44 @@
+package youtube
+
+// Format describes one of the streams of the decoded video.
+type Format struct {
+	ItagNo   int
+	Quality  string
+	MimeType string
+	Height   int
+	Bitrate  int
+	// Adaptive formats hold either the video or the audio, muxed ones hold both.
+	Adaptive bool
+}
+
+// Formats returns the formats of the decoded video, in the order of StreamList.
+func (y *Youtube) Formats() []Format {
+	formats := make([]Format, 0, len(y.StreamList))
+	for _, stream := range y.StreamList {
+		formats = append(formats, stream.format())
+	}
+	return formats
+}
+
+func (s stream) format() Format {
+	return Format{
+		ItagNo:   s.ItagNo,
+		Quality:  s.Quality,
+		MimeType: s.Type,
+		Height:   s.Height,
+		Bitrate:  s.Bitrate,
+		Adaptive: s.Adaptive,
+	}
+}
+
+// chooseStream asks y.ChooseFormat for the stream to download.
+func (y *Youtube) chooseStream() (stream, error) {
+	index, err := y.ChooseFormat(y.Formats())
+	if err != nil {
+		return stream{}, err
+	}
+	if index < 0 || index >= len(y.StreamList) {
+		return stream{}, ErrFormatIndexOutOfRange
+	}
+	return y.StreamList[index], nil
+}
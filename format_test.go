@@ -0,0 +1,54 @@
+package youtube
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestYoutube_selectStream_ChooseFormat(t *testing.T) {
+	errCanceled := errors.New("canceled by the user")
+	tests := []struct {
+		name     string
+		opts     DownloadOptions
+		index    int
+		err      error
+		wantItag int
+		wantErr  error
+		called   bool
+	}{
+		{"chosen", DownloadOptions{}, 1, nil, 22, nil, true},
+		{"out of range", DownloadOptions{}, 3, nil, 0, ErrFormatIndexOutOfRange, true},
+		{"canceled", DownloadOptions{}, 0, errCanceled, 0, errCanceled, true},
+		{"itag given", DownloadOptions{ItagNo: 140}, 0, nil, 140, nil, false},
+		{"quality given", DownloadOptions{Quality: "hd720"}, 0, nil, 22, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			y := NewYoutube(false)
+			y.StreamList = []stream{
+				{ItagNo: 18, Quality: "medium", Type: "video/mp4", Height: 360},
+				{ItagNo: 22, Quality: "hd720", Type: "video/mp4", Height: 720},
+				{ItagNo: 140, Quality: "tiny", Type: "audio/mp4", Adaptive: true},
+			}
+			y.ChooseFormat = func(formats []Format) (int, error) {
+				called = true
+				if len(formats) != 3 || formats[1].Height != 720 || !formats[2].Adaptive {
+					t.Errorf("ChooseFormat() called with %+v", formats)
+				}
+				return tt.index, tt.err
+			}
+
+			got, err := y.selectStream(tt.opts)
+			if err != tt.wantErr {
+				t.Fatalf("selectStream() error = %v, want %v", err, tt.wantErr)
+			}
+			if got.ItagNo != tt.wantItag {
+				t.Errorf("selectStream() itag = %d, want %d", got.ItagNo, tt.wantItag)
+			}
+			if called != tt.called {
+				t.Errorf("ChooseFormat called = %v, want %v", called, tt.called)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package youtube
+
+import (
+	"errors"
+	"sync"
+)
+
+// A format is failing chronically once it failed at least
+// chronicFailureCount times and for at least half of its downloads.
+const (
+	chronicFailureCount = 2
+	chronicFailureRate  = 0.5
+)
+
+// FormatHealth tracks the download failures (403s, stalls...) of each itag
+// during a session, share it between the Youtube objects of a batch.
+type FormatHealth struct {
+	mu    sync.Mutex
+	stats map[int]*itagHealth
+}
+
+type itagHealth struct {
+	attempts int
+	failures int
+}
+
+// NewFormatHealth returns an empty FormatHealth.
+func NewFormatHealth() *FormatHealth {
+	return &FormatHealth{stats: make(map[int]*itagHealth)}
+}
+
+// RecordSuccess records a completed download of itagNo.
+func (h *FormatHealth) RecordSuccess(itagNo int) {
+	h.add(itagNo, false)
+}
+
+// RecordFailure records a failed download of itagNo.
+func (h *FormatHealth) RecordFailure(itagNo int) {
+	h.add(itagNo, true)
+}
+
+// FailureRate returns the share of the downloads of itagNo which failed.
+func (h *FormatHealth) FailureRate(itagNo int) float64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	stats, ok := h.stats[itagNo]
+	if !ok || stats.attempts == 0 {
+		return 0
+	}
+	return float64(stats.failures) / float64(stats.attempts)
+}
+
+func (h *FormatHealth) add(itagNo int, failed bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	stats, ok := h.stats[itagNo]
+	if !ok {
+		stats = &itagHealth{}
+		h.stats[itagNo] = stats
+	}
+	stats.attempts++
+	if failed {
+		stats.failures++
+	}
+}
+
+// chronic reports whether itagNo keeps failing.
+func (h *FormatHealth) chronic(itagNo int) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	stats, ok := h.stats[itagNo]
+	if !ok || stats.failures < chronicFailureCount {
+		return false
+	}
+	return float64(stats.failures)/float64(stats.attempts) >= chronicFailureRate
+}
+
+// record updates the health of itagNo with the outcome of a download, errors
+// which aren't caused by the stream server are ignored.
+func (h *FormatHealth) record(itagNo int, err error) {
+	if h == nil {
+		return
+	}
+	switch {
+	case err == nil:
+		h.RecordSuccess(itagNo)
+	case errors.As(err, new(ErrUnexpectedStatusCode)),
+		errors.As(err, &ErrUnexpectedContentType{}),
+		errors.Is(err, ErrDownloadStalled):
+		h.RecordFailure(itagNo)
+	}
+}
+
+// healthiest returns the first of streams which isn't failing chronically,
+// or the one with the lowest failure rate when they all are.
+func (h *FormatHealth) healthiest(streams []stream) stream {
+	if h == nil {
+		return streams[0]
+	}
+	best := streams[0]
+	for _, stream := range streams {
+		if !h.chronic(stream.ItagNo) {
+			return stream
+		}
+		if h.FailureRate(stream.ItagNo) < h.FailureRate(best.ItagNo) {
+			best = stream
+		}
+	}
+	return best
+}
@@ -0,0 +1,92 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package youtube
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestYoutube_selectStream_Health(t *testing.T) {
+	y := NewYoutube(false)
+	y.StreamList = []stream{
+		{ItagNo: 22, Quality: "hd720"},
+		{ItagNo: 18, Quality: "medium"},
+		{ItagNo: 137, Quality: "hd1080", Adaptive: true},
+	}
+
+	got, err := y.selectStream(DownloadOptions{})
+	if err != nil || got.ItagNo != 22 {
+		t.Fatalf("selectStream() = %d, %v, want itag 22 without health tracking", got.ItagNo, err)
+	}
+
+	y.Health = NewFormatHealth()
+	y.Health.RecordFailure(22)
+	if got, _ := y.selectStream(DownloadOptions{}); got.ItagNo != 22 {
+		t.Errorf("selectStream() = %d, a single failure should not avoid itag 22", got.ItagNo)
+	}
+
+	y.Health.RecordFailure(22)
+	if got, _ := y.selectStream(DownloadOptions{}); got.ItagNo != 18 {
+		t.Errorf("selectStream() = %d, want the healthy muxed itag 18", got.ItagNo)
+	}
+	if got, _ := y.selectStream(DownloadOptions{ItagNo: 22}); got.ItagNo != 22 {
+		t.Errorf("selectStream() = %d, an explicit itag must be honored", got.ItagNo)
+	}
+
+	y.Health.RecordFailure(18)
+	y.Health.RecordFailure(18)
+	y.Health.RecordSuccess(18)
+	if got, _ := y.selectStream(DownloadOptions{}); got.ItagNo != 18 {
+		t.Errorf("selectStream() = %d, want itag 18 with the lowest failure rate", got.ItagNo)
+	}
+}
+
+func TestFormatHealth_record(t *testing.T) {
+	h := NewFormatHealth()
+	h.record(18, ErrUnexpectedStatusCode(http.StatusForbidden))
+	h.record(18, ErrDownloadStalled)
+	h.record(18, os.ErrPermission)
+	h.record(18, nil)
+	if got := h.FailureRate(18); got != 2.0/3 {
+		t.Errorf("FailureRate() = %v, want %v", got, 2.0/3)
+	}
+}
+
+func TestYoutube_videoDLWorker_Stall(t *testing.T) {
+	defer func(timeout time.Duration) { stallTimeout = timeout }(stallTimeout)
+	stallTimeout = 100 * time.Millisecond
+
+	release := make(chan struct{})
+	defer close(release)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "video/mp4")
+		w.Write(mp4Header)
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "youtube")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	y := NewYoutube(false)
+	err = y.videoDLWorker(context.Background(), filepath.Join(dir, "video.mp4"), server.URL, "video/mp4", DownloadOptions{})
+	if !errors.Is(err, ErrDownloadStalled) {
+		t.Errorf("videoDLWorker() error = %v, want %v", err, ErrDownloadStalled)
+	}
+}
@@ -0,0 +1,63 @@
+package youtube
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// Jitter randomizes the timing and the ordering of the requests slightly, a
+// long running mirror enumerating many videos looks less like a bot and
+// triggers fewer bot checks. The zero value keeps the requests as they are.
+type Jitter struct {
+	// Delay is the upper bound of the random wait before each metadata
+	// request: the video info, the player javascript and the browse pages.
+	Delay time.Duration
+	// Window is the number of the next queued jobs a Queue picks its next
+	// job from at random, instead of following the order they were added.
+	Window int
+}
+
+// validate returns the problems of the jitter.
+func (j Jitter) validate() ErrInvalidOptions {
+	var errs ErrInvalidOptions
+	if j.Delay < 0 {
+		errs = append(errs, ErrInvalidOption{Option: "Jitter.Delay", Reason: "must not be negative"})
+	}
+	if j.Window < 0 {
+		errs = append(errs, ErrInvalidOption{Option: "Jitter.Window", Reason: "must not be negative"})
+	}
+	return errs
+}
+
+// wait sleeps a random duration up to Delay, or until ctx is done.
+func (j Jitter) wait(ctx context.Context) error {
+	if j.Delay <= 0 {
+		return nil
+	}
+	return sleepContext(ctx, time.Duration(rand.Int63n(int64(j.Delay))))
+}
+
+// pick returns the index of the next of n queued jobs.
+func (j Jitter) pick(n int) int {
+	window := j.Window
+	if window > n {
+		window = n
+	}
+	if window <= 1 {
+		return 0
+	}
+	return rand.Intn(window)
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
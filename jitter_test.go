@@ -0,0 +1,54 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestJitter_validate(t *testing.T) {
+	if errs := (Jitter{Delay: time.Second, Window: 3}).validate(); len(errs) != 0 {
+		t.Errorf("validate() = %v", errs)
+	}
+	if errs := (Jitter{Delay: -time.Second, Window: -1}).validate(); len(errs) != 2 {
+		t.Errorf("validate() = %v, want both fields rejected", errs)
+	}
+}
+
+func TestJitter_pick(t *testing.T) {
+	tests := []struct {
+		jitter Jitter
+		n      int
+		max    int
+	}{
+		{Jitter{}, 10, 0},
+		{Jitter{Window: 1}, 10, 0},
+		{Jitter{Window: 3}, 10, 2},
+		{Jitter{Window: 30}, 4, 3},
+	}
+	for _, tt := range tests {
+		seen := make(map[int]bool)
+		for i := 0; i < 200; i++ {
+			got := tt.jitter.pick(tt.n)
+			if got < 0 || got > tt.max {
+				t.Fatalf("%+v.pick(%d) = %d, want at most %d", tt.jitter, tt.n, got, tt.max)
+			}
+			seen[got] = true
+		}
+		if len(seen) != tt.max+1 {
+			t.Errorf("%+v.pick(%d) picked %d different jobs, want %d", tt.jitter, tt.n, len(seen), tt.max+1)
+		}
+	}
+}
+
+func TestJitter_wait(t *testing.T) {
+	if err := (Jitter{}).wait(context.Background()); err != nil {
+		t.Errorf("wait() error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := (Jitter{Delay: time.Hour}).wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("wait() error = %v, want the context error", err)
+	}
+}
@@ -0,0 +1,89 @@
+package youtube
+
+import (
+	"io"
+	"net/http"
+)
+
+// Limits guards a service exposing the library to its users against
+// adversarial inputs, such as huge playlists or endless redirects. The zero
+// value of a field means no limit.
+type Limits struct {
+	// MaxResponseSize caps the decoded size of the metadata responses:
+	// the video info, the embed page and the player javascript.
+	MaxResponseSize int64
+	// MaxRedirects caps the redirects followed by a request, net/http
+	// follows 10 when unset.
+	MaxRedirects int
+	// MaxStreamSize caps the size of a downloaded stream.
+	MaxStreamSize int64
+	// MaxPlaylistSize caps the number of videos enumerated from a playlist,
+	// such as the tracks of a MusicAlbum, a longer one fails as a whole.
+	MaxPlaylistSize int
+}
+
+// validate returns the problems of the limits.
+func (l Limits) validate() ErrInvalidOptions {
+	var errs ErrInvalidOptions
+	if l.MaxResponseSize < 0 {
+		errs = append(errs, ErrInvalidOption{Option: "Limits.MaxResponseSize", Reason: "must not be negative"})
+	}
+	if l.MaxRedirects < 0 {
+		errs = append(errs, ErrInvalidOption{Option: "Limits.MaxRedirects", Reason: "must not be negative"})
+	}
+	if l.MaxStreamSize < 0 {
+		errs = append(errs, ErrInvalidOption{Option: "Limits.MaxStreamSize", Reason: "must not be negative"})
+	}
+	if l.MaxPlaylistSize < 0 {
+		errs = append(errs, ErrInvalidOption{Option: "Limits.MaxPlaylistSize", Reason: "must not be negative"})
+	}
+	return errs
+}
+
+// withRedirects returns httpClient following at most MaxRedirects redirects,
+// the shared client is copied rather than modified.
+func (l Limits) withRedirects(httpClient *http.Client) *http.Client {
+	if l.MaxRedirects == 0 {
+		return httpClient
+	}
+	limited := *httpClient
+	limited.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) > l.MaxRedirects {
+			return ErrLimitExceeded{Limit: "MaxRedirects", Max: int64(l.MaxRedirects)}
+		}
+		return nil
+	}
+	return &limited
+}
+
+// limitedReader fails with ErrLimitExceeded once more than max bytes were read.
+type limitedReader struct {
+	r     io.Reader
+	left  int64
+	limit ErrLimitExceeded
+}
+
+// newLimitedReader returns a reader of r failing after max bytes, r is
+// returned as is when max is not positive.
+func newLimitedReader(r io.Reader, max int64, limit string) io.Reader {
+	if max <= 0 {
+		return r
+	}
+	return &limitedReader{r: r, left: max, limit: ErrLimitExceeded{Limit: limit, Max: max}}
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.left < 0 {
+		return 0, l.limit
+	}
+	// read one byte more than allowed to tell an exact fit from an overflow
+	if int64(len(p)) > l.left+1 {
+		p = p[:l.left+1]
+	}
+	n, err := l.r.Read(p)
+	l.left -= int64(n)
+	if l.left < 0 {
+		return n + int(l.left), l.limit
+	}
+	return n, err
+}
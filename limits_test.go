@@ -0,0 +1,123 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package youtube
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLimitedReader(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    int
+		max     int64
+		wantErr bool
+	}{
+		{"no limit", 100, 0, false},
+		{"under", 99, 100, false},
+		{"exact fit", 100, 100, false},
+		{"over", 101, 100, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := bytes.Repeat([]byte{'a'}, tt.size)
+			got, err := ioutil.ReadAll(newLimitedReader(bytes.NewReader(data), tt.max, "MaxResponseSize"))
+			if tt.wantErr {
+				if !errors.As(err, &ErrLimitExceeded{}) {
+					t.Fatalf("ReadAll() error = %v, want ErrLimitExceeded", err)
+				}
+				if int64(len(got)) != tt.max {
+					t.Errorf("read %d bytes, want at most %d", len(got), tt.max)
+				}
+				return
+			}
+			if err != nil || len(got) != tt.size {
+				t.Errorf("ReadAll() = %d bytes, %v, want %d bytes", len(got), err, tt.size)
+			}
+		})
+	}
+}
+
+func TestLimits(t *testing.T) {
+	media := append(append([]byte{}, mp4Header...), bytes.Repeat([]byte{0}, 4096)...)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/info":
+			w.Write(bytes.Repeat([]byte{'a'}, 2048))
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		case "/media":
+			w.Header().Set("Content-Type", "video/mp4")
+			w.Write(media)
+		case "/chunked":
+			w.Header().Set("Content-Type", "video/mp4")
+			w.Write(media[:1024])
+			w.(http.Flusher).Flush()
+			w.Write(media[1024:])
+		}
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "youtube")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	music := newMusicServer(t)
+	defer music.Close()
+	defer func(url string) { musicURL = url }(musicURL)
+	musicURL = music.URL
+
+	y, err := NewYoutubeWithOptions(Options{Limits: Limits{MaxResponseSize: 1024, MaxRedirects: 3, MaxStreamSize: 2048}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the album of the music server has 3 tracks
+	albums, err := NewYoutubeWithOptions(Options{Limits: Limits{MaxPlaylistSize: 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	tests := []struct {
+		name  string
+		run   func() error
+		limit string
+	}{
+		{"response size", func() error { _, err := y.httpGetBody(ctx, server.URL+"/info"); return err }, "MaxResponseSize"},
+		{"redirects", func() error { _, err := y.httpGetBody(ctx, server.URL+"/loop"); return err }, "MaxRedirects"},
+		{"announced stream size", func() error {
+			return y.videoDLWorker(ctx, dir+"/announced.mp4", server.URL+"/media", "video/mp4", DownloadOptions{})
+		}, "MaxStreamSize"},
+		{"chunked stream size", func() error {
+			return y.videoDLWorker(ctx, dir+"/chunked.mp4", server.URL+"/chunked", "video/mp4", DownloadOptions{})
+		}, "MaxStreamSize"},
+		{"playlist size", func() error { _, err := albums.MusicAlbum(ctx, "MPREb_album"); return err }, "MaxPlaylistSize"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.run()
+			var limitErr ErrLimitExceeded
+			if !errors.As(err, &limitErr) || limitErr.Limit != tt.limit {
+				t.Errorf("error = %v, want %s exceeded", err, tt.limit)
+			}
+			if retryable(ctx, err) {
+				t.Error("an exceeded limit should not be retried")
+			}
+		})
+	}
+
+	_, err = NewYoutubeWithOptions(Options{Limits: Limits{MaxRedirects: -1, MaxStreamSize: -1}})
+	if err == nil || !strings.Contains(err.Error(), "Limits.MaxRedirects") || !strings.Contains(err.Error(), "Limits.MaxStreamSize") {
+		t.Errorf("NewYoutubeWithOptions() error = %v, want both negative limits reported", err)
+	}
+}
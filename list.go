@@ -0,0 +1,45 @@
+package youtube
+
+import "time"
+
+// ListItem is a video of a list downloaded by DownloadList, such as the
+// track of an album, along with the metadata known from the listing.
+type ListItem struct {
+	// Index is the position of the item in the list, from 0.
+	Index   int
+	VideoID string
+	Title   string
+	Author  string
+}
+
+// Skip reasons of ListItemResult.
+const (
+	// SkipArchived is an item the Archive holds already, see Youtube.Sync.
+	SkipArchived = "archived"
+	// SkipLocked is an item downloaded by another process at the same time.
+	SkipLocked = "locked"
+)
+
+// ListItemResult is the outcome of a ListItem: downloaded into Path, skipped
+// or failed with Err.
+type ListItemResult struct {
+	Item ListItem
+	// Title and Author are the ones of the decoded video, empty when it
+	// couldn't be decoded.
+	Title  string
+	Author string
+	Path   string
+	// Skipped is the reason the item wasn't downloaded, such as SkipArchived.
+	Skipped string
+	Err     error
+	Elapsed time.Duration
+}
+
+// Items returns the tracks of the album as the items of a list.
+func (a *MusicAlbum) Items() []ListItem {
+	items := make([]ListItem, len(a.Tracks))
+	for i, track := range a.Tracks {
+		items[i] = ListItem{Index: i, VideoID: track.VideoID, Title: track.Title, Author: track.Artist}
+	}
+	return items
+}
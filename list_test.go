@@ -0,0 +1,93 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestYoutube_DownloadList(t *testing.T) {
+	media := newMediaServer(append([]byte{}, mp4Header...))
+	defer media.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		videoID := r.URL.Query().Get("video_id")
+		if videoID == "dQw4w9WgXcQ" {
+			w.Write([]byte(url.Values{"status": {"fail"}, "reason": {"Video unavailable"}}.Encode()))
+			return
+		}
+		playerResponse, _ := json.Marshal(map[string]interface{}{
+			"playabilityStatus": map[string]string{"status": "OK"},
+			"videoDetails":      map[string]string{"title": "title of " + videoID, "author": "gopher"},
+			"streamingData": map[string]interface{}{
+				"formats": []map[string]interface{}{
+					{"itag": 18, "url": media.URL + "/" + videoID, "mimeType": "video/mp4", "quality": "medium"},
+				},
+			},
+		})
+		w.Write([]byte(url.Values{"status": {"ok"}, "player_response": {string(playerResponse)}}.Encode()))
+	}))
+	defer server.Close()
+	defer func(url string) { baseURL = url }(baseURL)
+	baseURL = server.URL
+
+	dir, err := ioutil.TempDir("", "youtube")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	archive, err := OpenArchive(filepath.Join(dir, "archive.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive.Record(ArchiveEntry{VideoID: "rFejpH_tAHM", Path: filepath.Join(dir, "archived.mp4")})
+
+	var events []string
+	y, err := NewYoutubeWithOptions(Options{
+		Archive:        archive,
+		OnItemStart:    func(item ListItem) { events = append(events, "start "+item.VideoID) },
+		OnItemComplete: func(r ListItemResult) { events = append(events, "complete "+r.Item.VideoID) },
+		OnItemSkipped:  func(r ListItemResult) { events = append(events, "skipped "+r.Item.VideoID+" "+r.Skipped) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	album := &MusicAlbum{Tracks: []MusicTrack{
+		{VideoID: "BaW_jenozKc", Title: "first"},
+		{VideoID: "rFejpH_tAHM", Title: "second"},
+		{VideoID: "dQw4w9WgXcQ", Title: "third"},
+	}}
+	results, err := y.DownloadList(context.Background(), album.Items(), DownloadOptions{OutputDir: dir, OutputFile: "ignored.mp4"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"start BaW_jenozKc", "complete BaW_jenozKc",
+		"start rFejpH_tAHM", "skipped rFejpH_tAHM archived",
+		"start dQw4w9WgXcQ", "complete dQw4w9WgXcQ",
+	}
+	if len(events) != len(want) {
+		t.Fatalf("events = %q, want %q", events, want)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("events = %q, want %q", events, want)
+			break
+		}
+	}
+	if r := results[0]; r.Err != nil || r.Title != "title of BaW_jenozKc" || filepath.Base(r.Path) != "title of BaW_jenozKc.mp4" {
+		t.Errorf("first item = %+v", r)
+	}
+	if r := results[2]; r.Err == nil || r.Item.Index != 2 {
+		t.Errorf("unavailable item = %+v, want its failure", r)
+	}
+}
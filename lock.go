@@ -0,0 +1,44 @@
+package youtube
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// lockSuffix is appended to the name of a file to name its lock file.
+const lockSuffix = ".lock"
+
+// fileLock is an advisory lock on a file shared by the processes, such as a
+// cron run of youtubedr and youtubed writing to the same archive or output
+// directory. It is held on a lock file next to the file, removed on unlock.
+type fileLock struct {
+	// f is nil when nothing was locked.
+	f *os.File
+}
+
+// lockFile locks path, waiting for the other processes to release it when
+// wait is set, failing with ErrLocked otherwise. A FIFO or a device, such as
+// /dev/stdout, is not locked: its directory may not be writable, and the
+// bytes written to it are not a file to protect.
+func lockFile(path string, wait bool) (*fileLock, error) {
+	if fi, err := os.Stat(path); err == nil && isSpecialFile(fi) {
+		return &fileLock{}, nil
+	}
+	lockPath := path + lockSuffix
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0755); err != nil {
+		return nil, err
+	}
+	f, err := acquireLock(lockPath, wait)
+	if err != nil {
+		return nil, err
+	}
+	return &fileLock{f: f}, nil
+}
+
+// unlock removes the lock file and releases the lock.
+func (l *fileLock) unlock() error {
+	if l.f == nil {
+		return nil
+	}
+	return releaseLock(l.f)
+}
@@ -0,0 +1,52 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+// +build linux darwin freebsd netbsd openbsd dragonfly
+
+package youtube
+
+import (
+	"os"
+	"syscall"
+)
+
+// acquireLock flocks the lock file at lockPath, the lock is released by the
+// kernel when the process dies.
+func acquireLock(lockPath string, wait bool) (*os.File, error) {
+	how := syscall.LOCK_EX
+	if !wait {
+		how |= syscall.LOCK_NB
+	}
+	for {
+		f, err := os.OpenFile(lockPath, os.O_RDWR|os.O_CREATE, 0644)
+		if err != nil {
+			return nil, err
+		}
+		if err := syscall.Flock(int(f.Fd()), how); err != nil {
+			f.Close()
+			if err == syscall.EWOULDBLOCK {
+				return nil, ErrLocked
+			}
+			return nil, err
+		}
+		// the holder may have removed the lock file on unlock while we were
+		// waiting, the lock is then on a file nobody else will open
+		locked, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		if current, err := os.Stat(lockPath); err == nil && os.SameFile(locked, current) {
+			return f, nil
+		}
+		f.Close()
+	}
+}
+
+// releaseLock removes the lock file before closing it, so that a process
+// opening the lock file afterwards never gets the lock of the removed one.
+func releaseLock(f *os.File) error {
+	err := os.Remove(f.Name())
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
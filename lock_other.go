@@ -0,0 +1,41 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly
+
+package youtube
+
+import (
+	"os"
+	"time"
+)
+
+// lockPollInterval is how often acquireLock tries again to create the lock file.
+const lockPollInterval = 50 * time.Millisecond
+
+// acquireLock creates the lock file at lockPath, the lock is held as long as
+// it exists. The lock file of a process which died holding it has to be
+// removed by hand.
+func acquireLock(lockPath string, wait bool) (*os.File, error) {
+	for {
+		f, err := os.OpenFile(lockPath, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			return f, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if !wait {
+			return nil, ErrLocked
+		}
+		time.Sleep(lockPollInterval)
+	}
+}
+
+// releaseLock closes the lock file before removing it, an open file can't be
+// removed on windows.
+func releaseLock(f *os.File) error {
+	err := f.Close()
+	if removeErr := os.Remove(f.Name()); err == nil {
+		err = removeErr
+	}
+	return err
+}
@@ -0,0 +1,114 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package youtube
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLockFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "youtube")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "talk.mp4")
+
+	lock, err := lockFile(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := lockFile(path, false); err != ErrLocked {
+		t.Errorf("lockFile() of a locked file = %v, want ErrLocked", err)
+	}
+
+	locked := make(chan *fileLock)
+	go func() {
+		waiting, err := lockFile(path, true)
+		if err != nil {
+			t.Error(err)
+		}
+		locked <- waiting
+	}()
+	select {
+	case <-locked:
+		t.Fatal("lockFile() didn't wait for the lock")
+	case <-time.After(100 * time.Millisecond):
+	}
+	if err := lock.unlock(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case waiting := <-locked:
+		waiting.unlock()
+	case <-time.After(5 * time.Second):
+		t.Fatal("lockFile() still waiting after the unlock")
+	}
+	if _, err := os.Stat(path + lockSuffix); !os.IsNotExist(err) {
+		t.Error("the lock file was left behind")
+	}
+}
+
+func TestArchive_shared(t *testing.T) {
+	dir, err := ioutil.TempDir("", "youtube")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "archive.jsonl")
+
+	// two processes sharing the archive
+	cron, _ := OpenArchive(path)
+	daemon, _ := OpenArchive(path)
+	if err := cron.Record(ArchiveEntry{VideoID: "BaW_jenozKc", Path: "a.mp4"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := daemon.Entry("BaW_jenozKc"); !ok {
+		t.Error("Entry() missed the record of the other process")
+	}
+
+	// a crash of the cron in the middle of a record
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	f.WriteString(`{"video_id":"9bZkp7q19f0","pa`)
+	f.Close()
+	if err := daemon.Record(ArchiveEntry{VideoID: "rFejpH_tAHM", Path: "b.mp4"}); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := OpenArchive(path)
+	if err != nil {
+		t.Fatalf("OpenArchive() after a record following a crash: %v", err)
+	}
+	for _, videoID := range []string{"BaW_jenozKc", "rFejpH_tAHM"} {
+		if _, ok := reopened.Entry(videoID); !ok {
+			t.Errorf("Entry(%s) not found", videoID)
+		}
+	}
+	if _, err := os.Stat(path + lockSuffix); !os.IsNotExist(err) {
+		t.Error("the lock file was left behind")
+	}
+}
+
+func TestYoutube_download_locked(t *testing.T) {
+	dir, err := ioutil.TempDir("", "youtube")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	lock, err := lockFile(filepath.Join(dir, "talk.mp4"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lock.unlock()
+
+	y := NewYoutube(false)
+	y.StreamList = []stream{{ItagNo: 18, Type: "video/mp4", URL: "http://127.0.0.1:1/never-requested"}}
+	if _, err := y.download(context.Background(), DownloadOptions{OutputDir: dir, OutputFile: "talk.mp4"}); err != ErrLocked {
+		t.Errorf("download() of a file being downloaded = %v, want ErrLocked", err)
+	}
+}
@@ -0,0 +1,182 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package youtube
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// manifestVersion is the version of the manifests written by NewManifest,
+// manifests of another version are rejected by Verify.
+const manifestVersion = 1
+
+// Manifest records the checksum of every file of a mirror, such as the
+// downloads of a playlist or a channel, so Verify can audit the mirror later
+// for missing or rotten files.
+type Manifest struct {
+	Version   int            `json:"version"`
+	CreatedAt time.Time      `json:"created_at"`
+	ItemCount int            `json:"item_count"`
+	TotalSize int64          `json:"total_size"`
+	Items     []ManifestItem `json:"items"`
+}
+
+// ManifestItem is a mirrored video along with its files, the media and the
+// sidecars of a bundle, or the formats of a multi-format download.
+type ManifestItem struct {
+	// ID identifies the video, such as its id or its url.
+	ID    string         `json:"id"`
+	Files []ManifestFile `json:"files"`
+}
+
+// ManifestFile is a file of a mirror, its Path is relative to the directory
+// of the mirror and slash separated.
+type ManifestFile struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// NewManifest hashes the files of items, given by their path in dir or
+// relative to it, and returns the manifest of the mirror in dir.
+func NewManifest(dir string, items []ManifestItem) (*Manifest, error) {
+	manifest := &Manifest{Version: manifestVersion, CreatedAt: time.Now().UTC(), ItemCount: len(items)}
+	for _, item := range items {
+		files := make([]ManifestFile, 0, len(item.Files))
+		for _, file := range item.Files {
+			rel, err := manifestPath(dir, file.Path)
+			if err != nil {
+				return nil, err
+			}
+			size, sum, err := hashFile(filepath.Join(dir, filepath.FromSlash(rel)))
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, ManifestFile{Path: rel, Size: size, SHA256: sum})
+			manifest.TotalSize += size
+		}
+		manifest.Items = append(manifest.Items, ManifestItem{ID: item.ID, Files: files})
+	}
+	return manifest, nil
+}
+
+// ReadManifest reads a manifest written by Manifest.WriteFile.
+func ReadManifest(path string) (*Manifest, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// WriteFile writes the manifest to path as json.
+func (m *Manifest) WriteFile(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// Verify checks every file of manifest in dir, it returns ErrMirrorDamaged
+// listing the missing files and the files whose size or checksum changed.
+func Verify(dir string, manifest *Manifest) error {
+	if manifest.Version != manifestVersion {
+		return ErrManifestVersion
+	}
+	var damaged ErrMirrorDamaged
+	for _, item := range manifest.Items {
+		for _, file := range item.Files {
+			path := filepath.Join(dir, filepath.FromSlash(file.Path))
+			fi, err := os.Stat(path)
+			if os.IsNotExist(err) {
+				damaged.Missing = append(damaged.Missing, file.Path)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if fi.Size() != file.Size {
+				damaged.Corrupted = append(damaged.Corrupted, file.Path)
+				continue
+			}
+			_, sum, err := hashFile(path)
+			if err != nil {
+				return err
+			}
+			if sum != file.SHA256 {
+				damaged.Corrupted = append(damaged.Corrupted, file.Path)
+			}
+		}
+	}
+	if len(damaged.Missing) > 0 || len(damaged.Corrupted) > 0 {
+		return damaged
+	}
+	return nil
+}
+
+// manifestPath returns path relative to dir, slash separated.
+func manifestPath(dir, path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return "", err
+	}
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside of the mirror %s", path, dir)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// hashFile returns the size and the hex encoded SHA-256 of the file at path.
+func hashFile(path string) (int64, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	size, err := io.Copy(hash, f)
+	if err != nil {
+		return 0, "", err
+	}
+	return size, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Manifest returns the manifest of the files downloaded by the finished jobs
+// of the queue into dir, the jobs are the items of the manifest, identified
+// by their url.
+func (q *Queue) Manifest(dir string) (*Manifest, error) {
+	var items []ManifestItem
+	for _, job := range q.Jobs() {
+		if job.Status != JobDone {
+			continue
+		}
+		paths := job.Paths
+		if len(paths) == 0 {
+			paths = []string{job.Path}
+		}
+		item := ManifestItem{ID: job.URL}
+		for _, path := range paths {
+			item.Files = append(item.Files, ManifestFile{Path: path})
+		}
+		items = append(items, item)
+	}
+	return NewManifest(dir, items)
+}
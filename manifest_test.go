@@ -0,0 +1,123 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package youtube
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "youtube")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"first.mp4":        "first video",
+		"first.info.json":  "{}",
+		"list/second.webm": "second video",
+		"list/third.m4a":   "third audio",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	manifest, err := NewManifest(dir, []ManifestItem{
+		{ID: "first", Files: []ManifestFile{{Path: filepath.Join(dir, "first.mp4")}, {Path: "first.info.json"}}},
+		{ID: "second", Files: []ManifestFile{{Path: filepath.Join(dir, "list", "second.webm")}}},
+		{ID: "third", Files: []ManifestFile{{Path: filepath.Join("list", "third.m4a")}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.ItemCount != 3 || manifest.TotalSize != 36 || manifest.Items[1].Files[0].Path != "list/second.webm" {
+		t.Errorf("NewManifest() = %+v", manifest)
+	}
+	if _, err := NewManifest(dir, []ManifestItem{{ID: "outside", Files: []ManifestFile{{Path: "../outside.mp4"}}}}); err == nil {
+		t.Error("NewManifest() accepted a file outside of the mirror")
+	}
+
+	path := filepath.Join(dir, "manifest.json")
+	if err := manifest.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadManifest(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(dir, read); err != nil {
+		t.Fatalf("Verify() of an intact mirror = %v", err)
+	}
+
+	// rot a byte of one file, grow another and remove a third
+	ioutil.WriteFile(filepath.Join(dir, "first.mp4"), []byte("first vidEo"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "list", "third.m4a"), []byte("third audio!"), 0644)
+	os.Remove(filepath.Join(dir, "list", "second.webm"))
+	err = Verify(dir, read)
+	var damaged ErrMirrorDamaged
+	if !errors.As(err, &damaged) {
+		t.Fatalf("Verify() error = %v, want ErrMirrorDamaged", err)
+	}
+	if want := []string{"list/second.webm"}; !reflect.DeepEqual(damaged.Missing, want) {
+		t.Errorf("Missing = %q, want %q", damaged.Missing, want)
+	}
+	if want := []string{"first.mp4", "list/third.m4a"}; !reflect.DeepEqual(damaged.Corrupted, want) {
+		t.Errorf("Corrupted = %q, want %q", damaged.Corrupted, want)
+	}
+
+	read.Version = 0
+	if err := Verify(dir, read); err != ErrManifestVersion {
+		t.Errorf("Verify() error = %v, want %v", err, ErrManifestVersion)
+	}
+}
+
+func TestQueue_Manifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "youtube")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	q, err := NewQueue(Options{}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.run = func(ctx context.Context, job *Job) (string, error) {
+		if job.URL == "fail" {
+			return "", errors.New("decoding failed")
+		}
+		path := filepath.Join(dir, job.URL+".mp4")
+		return path, ioutil.WriteFile(path, []byte(job.URL), 0644)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go q.Run(ctx)
+	for _, url := range []string{"first", "fail", "third"} {
+		if _, err := q.Add(url, DownloadOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	waitJobs(t, q)
+
+	manifest, err := q.Manifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.ItemCount != 2 || manifest.Items[0].ID != "first" || manifest.Items[1].Files[0].Path != "third.mp4" {
+		t.Errorf("Manifest() = %+v", manifest)
+	}
+	if err := Verify(dir, manifest); err != nil {
+		t.Errorf("Verify() = %v", err)
+	}
+}
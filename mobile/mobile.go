@@ -0,0 +1,192 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+/*
+Package mobile is a gomobile friendly facade of the youtube package, its API
+only uses strings, ints, []byte and callback interfaces.
+
+	gomobile bind -target=android github.com/kkdai/youtube/mobile
+*/
+package mobile
+
+import (
+	"bytes"
+	"context"
+	"sync"
+
+	"github.com/kkdai/youtube"
+)
+
+// ProgressListener receives the progress of a download.
+type ProgressListener interface {
+	OnProgress(percent int)
+}
+
+// Format describes one of the formats of the decoded video.
+type Format struct {
+	ItagNo   int
+	Quality  string
+	MimeType string
+}
+
+// Downloader decodes and downloads one video at a time.
+type Downloader struct {
+	y *youtube.Youtube
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+}
+
+// NewDownloader returns a Downloader, socks5Proxy is used when not empty.
+func NewDownloader(socks5Proxy string) (*Downloader, error) {
+	y, err := youtube.NewYoutubeWithOptions(youtube.Options{Socks5Proxy: socks5Proxy})
+	if err != nil {
+		return nil, err
+	}
+	return &Downloader{y: y}, nil
+}
+
+// Decode retrieves the information of the video at url, or of the video id.
+func (d *Downloader) Decode(url string) error {
+	ctx := d.start()
+	defer d.done()
+	return d.y.DecodeURLWithContext(ctx, url)
+}
+
+// RefreshFormats resolves the stream urls of the decoded video again once
+// they expired, the title and author are kept.
+func (d *Downloader) RefreshFormats() error {
+	ctx := d.start()
+	defer d.done()
+	return d.y.RefreshFormats(ctx)
+}
+
+// Title returns the title of the decoded video.
+func (d *Downloader) Title() string {
+	if info := d.y.GetItagInfo(); info != nil {
+		return info.Title
+	}
+	return ""
+}
+
+// Author returns the author of the decoded video.
+func (d *Downloader) Author() string {
+	if info := d.y.GetItagInfo(); info != nil {
+		return info.Author
+	}
+	return ""
+}
+
+// FormatCount returns the number of formats of the decoded video.
+func (d *Downloader) FormatCount() int {
+	if info := d.y.GetItagInfo(); info != nil {
+		return len(info.Itags)
+	}
+	return 0
+}
+
+// Format returns the format at index, or nil when index is out of range.
+func (d *Downloader) Format(index int) *Format {
+	info := d.y.GetItagInfo()
+	if info == nil || index < 0 || index >= len(info.Itags) {
+		return nil
+	}
+	itag := info.Itags[index]
+	return &Format{ItagNo: itag.ItagNo, Quality: itag.Quality, MimeType: itag.Type}
+}
+
+// Download downloads the decoded video into outputDir, empty arguments select
+// the defaults of the youtube package. listener may be nil.
+func (d *Downloader) Download(outputDir, outputFile, quality string, itagNo int, listener ProgressListener) error {
+	ctx := d.start()
+	defer d.done()
+	stop := d.forwardProgress(listener)
+	defer stop()
+
+	return d.y.StartDownloadWithOptions(ctx, youtube.DownloadOptions{
+		OutputDir:  outputDir,
+		OutputFile: outputFile,
+		Quality:    quality,
+		ItagNo:     itagNo,
+	})
+}
+
+// DownloadBytes downloads the format itagNo of the decoded video in memory,
+// 0 selects the default format. listener may be nil.
+func (d *Downloader) DownloadBytes(itagNo int, listener ProgressListener) ([]byte, error) {
+	ctx := d.start()
+	defer d.done()
+	stop := d.forwardProgress(listener)
+	defer stop()
+
+	var buf bytes.Buffer
+	if err := d.y.StartDownloadToWriter(ctx, &buf, youtube.DownloadOptions{ItagNo: itagNo}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Cancel aborts the running decode or download.
+func (d *Downloader) Cancel() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.cancel != nil {
+		d.cancel()
+	}
+}
+
+func (d *Downloader) start() context.Context {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+	return ctx
+}
+
+func (d *Downloader) done() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.cancel != nil {
+		d.cancel()
+		d.cancel = nil
+	}
+}
+
+// forwardProgress calls listener with the progress of the download until
+// the returned function is called.
+func (d *Downloader) forwardProgress(listener ProgressListener) func() {
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case percent := <-d.y.DownloadPercent:
+				if listener != nil {
+					listener.OnProgress(int(percent))
+				}
+			case <-stop:
+				d.drainProgress(listener)
+				return
+			}
+		}
+	}()
+	return func() {
+		close(stop)
+		<-stopped
+	}
+}
+
+// drainProgress forwards the progress left in the channel once the download is over.
+func (d *Downloader) drainProgress(listener ProgressListener) {
+	for {
+		select {
+		case percent := <-d.y.DownloadPercent:
+			if listener != nil {
+				listener.OnProgress(int(percent))
+			}
+		default:
+			return
+		}
+	}
+}
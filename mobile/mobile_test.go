@@ -0,0 +1,26 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package mobile
+
+import (
+	"testing"
+)
+
+func TestNewDownloader(t *testing.T) {
+	if _, err := NewDownloader("no-port"); err == nil {
+		t.Error("an invalid proxy should be rejected")
+	}
+
+	d, err := NewDownloader("")
+	if err != nil {
+		t.Fatalf("NewDownloader() error = %v", err)
+	}
+	if d.FormatCount() != 0 || d.Format(0) != nil || d.Title() != "" {
+		t.Error("a downloader without decoded video should have no format")
+	}
+	if _, err := d.DownloadBytes(0, nil); err == nil {
+		t.Error("a downloader without decoded video should not download")
+	}
+	d.Cancel()
+}
@@ -0,0 +1,79 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package youtube
+
+import (
+	"context"
+	"fmt"
+)
+
+// DownloadFormats downloads several formats of the decoded video, such as a
+// video and an audio only format, without decoding it again. DownloadPercent
+// reports the progress of the whole set. It returns the paths of the files
+// written, in the order of itags, up to the first failure.
+func (y *Youtube) DownloadFormats(ctx context.Context, itags []int, opts DownloadOptions) ([]string, error) {
+	opts.Itags = itags
+	return y.downloadFormats(ctx, opts)
+}
+
+// downloadFormats downloads the formats of opts.Itags.
+func (y *Youtube) downloadFormats(ctx context.Context, opts DownloadOptions) ([]string, error) {
+	if len(y.StreamList) == 0 {
+		return nil, ErrEmptyStreamList
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if len(opts.Itags) == 0 {
+		return nil, ErrInvalidOptions{ErrInvalidOption{Option: "Itags", Reason: "no itag given"}}
+	}
+
+	streams := make([]stream, 0, len(opts.Itags))
+	for _, itag := range opts.Itags {
+		s, err := y.selectStream(DownloadOptions{ItagNo: itag})
+		if err != nil {
+			return nil, err
+		}
+		streams = append(streams, s)
+	}
+
+	y.progress = newAggregateProgress(streams)
+	defer func() { y.progress = nil }()
+	y.downloadLevel = 0
+
+	names := formatFileNames(streams, opts.Filesystem, opts.OutputDir)
+	paths := make([]string, 0, len(streams))
+	for i, s := range streams {
+		single := opts
+		single.Itags = nil
+		single.ItagNo = s.ItagNo
+		if single.OutputFile == "" {
+			single.OutputFile = names[i]
+		}
+		path, err := y.download(ctx, single)
+		if err != nil {
+			return paths, err
+		}
+		paths = append(paths, path)
+		y.progress.next()
+	}
+	return paths, nil
+}
+
+// formatFileNames returns the file names of streams on fs in dir, the itag is
+// appended to the names which would collide otherwise.
+func formatFileNames(streams []stream, fs Filesystem, dir string) []string {
+	names := make([]string, len(streams))
+	count := make(map[string]int)
+	for i, s := range streams {
+		names[i] = fs.FileName(dir, s.Title+pickIdealFileExtension(s.Type))
+		count[names[i]]++
+	}
+	for i, s := range streams {
+		if count[names[i]] > 1 {
+			names[i] = fs.fileName(dir, s.Title+pickIdealFileExtension(s.Type), fmt.Sprintf(" (%d)", s.ItagNo))
+		}
+	}
+	return names
+}
@@ -0,0 +1,105 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package youtube
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestYoutube_DownloadFormats(t *testing.T) {
+	video := append(append([]byte{}, mp4Header...), bytes.Repeat([]byte{1}, 3000)...)
+	audio := append(append([]byte{}, mp4Header...), bytes.Repeat([]byte{2}, 1000)...)
+	videoServer, audioServer := newMediaServer(video), newMediaServer(audio)
+	defer videoServer.Close()
+	defer audioServer.Close()
+
+	dir, err := ioutil.TempDir("", "youtube")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	y := NewYoutube(false)
+	y.DownloadPercent = make(chan int64, 200)
+	y.StreamList = []stream{
+		{ItagNo: 137, Type: "video/mp4", URL: videoServer.URL, Title: "talk", Adaptive: true, ContentLength: int64(len(video))},
+		{ItagNo: 18, Type: "video/mp4", URL: audioServer.URL, Title: "talk", Adaptive: true, ContentLength: int64(len(audio))},
+	}
+
+	if _, err := y.DownloadFormats(context.Background(), []int{137, 5}, DownloadOptions{OutputDir: dir}); err != ErrItagNotFound {
+		t.Errorf("DownloadFormats() error = %v, want ErrItagNotFound", err)
+	}
+	if _, err := y.DownloadFormats(context.Background(), []int{137, 18}, DownloadOptions{OutputDir: dir, OutputFile: "talk.mp4"}); err == nil {
+		t.Error("DownloadFormats() should reject a single output file for several formats")
+	}
+
+	paths, err := y.DownloadFormats(context.Background(), []int{137, 18}, DownloadOptions{OutputDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(dir, "talk (137).mp4"), filepath.Join(dir, "talk (18).mp4")}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("DownloadFormats() = %q, want %q", paths, want)
+	}
+	for i, media := range [][]byte{video, audio} {
+		if got, err := ioutil.ReadFile(paths[i]); err != nil || !bytes.Equal(got, media) {
+			t.Errorf("%s holds %d bytes, want %d", paths[i], len(got), len(media))
+		}
+	}
+
+	// the progress rises over both downloads without starting again at 0
+	close(y.DownloadPercent)
+	var last int64
+	for percent := range y.DownloadPercent {
+		if percent <= last {
+			t.Fatalf("progress went from %d to %d", last, percent)
+		}
+		last = percent
+	}
+	if last == 0 {
+		t.Error("no progress reported")
+	}
+}
+
+func TestFormatFileNames(t *testing.T) {
+	streams := []stream{
+		{ItagNo: 22, Type: "video/mp4", Title: "talk"},
+		{ItagNo: 137, Type: "video/mp4", Title: "talk"},
+		{ItagNo: 140, Type: "audio/mp4", Title: "talk"},
+	}
+	want := []string{"talk (22).mp4", "talk (137).mp4", "talk.m4a"}
+	if got := formatFileNames(streams, "", ""); !reflect.DeepEqual(got, want) {
+		t.Errorf("formatFileNames() = %q, want %q", got, want)
+	}
+}
+
+func TestAggregateProgress(t *testing.T) {
+	tests := []struct {
+		name    string
+		streams []stream
+		want    float64
+	}{
+		{"known sizes", []stream{{ContentLength: 300}, {ContentLength: 100}}, 87.5},
+		{"unknown size", []stream{{ContentLength: 300}, {}}, 75},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newAggregateProgress(tt.streams)
+			p.next()
+			if got := p.percent(0.5); got != tt.want {
+				t.Errorf("percent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	var single *aggregateProgress
+	if got := single.percent(0.5); got != 50 {
+		t.Errorf("percent() of a single download = %v, want 50", got)
+	}
+}
@@ -0,0 +1,224 @@
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestMusicBrowseID(t *testing.T) {
+	tests := []struct {
+		url     string
+		want    string
+		wantErr bool
+	}{
+		{"https://music.youtube.com/playlist?list=OLAK5uy_kx9YyxWNxDBCBkWx3Zw7mvrVDtsePsSTI", "VLOLAK5uy_kx9YyxWNxDBCBkWx3Zw7mvrVDtsePsSTI", false},
+		{"https://music.youtube.com/browse/MPREb_4pL8gzRtw1p", "MPREb_4pL8gzRtw1p", false},
+		{"https://music.youtube.com/channel/UCmMUZbaYdNH0bEd1PAlAqsA", "UCmMUZbaYdNH0bEd1PAlAqsA", false},
+		{"https://music.youtube.com/charts", "FEmusic_charts", false},
+		{"MPREb_4pL8gzRtw1p", "MPREb_4pL8gzRtw1p", false},
+		{"https://music.youtube.com/watch?v=rFejpH_tAHM", "", true},
+		{"rFejpH_tAHM", "", true},
+	}
+	for _, tt := range tests {
+		got, err := musicBrowseID(tt.url)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("musicBrowseID(%q) = %q, %v, want %q", tt.url, got, err, tt.want)
+		}
+	}
+}
+
+func TestFindJSON(t *testing.T) {
+	var page interface{}
+	if err := json.Unmarshal([]byte(`{
+		"tabs": [{"header": {"title": "tab"}}],
+		"contents": {"header": {"title": "contents"}, "items": [{"header": {"title": "nested"}}]},
+		"banner": {"header": {"title": "banner"}}
+	}`), &page); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"banner", "contents", "tab"}
+	for i := 0; i < 10; i++ {
+		var got []string
+		for _, header := range findJSON(page, "header") {
+			got = append(got, valueAt(header, "title").(string))
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("findJSON() = %q, want %q", got, want)
+		}
+	}
+}
+
+// musicItem returns an album list item of the track.
+func musicItem(videoID, title string) map[string]interface{} {
+	column := func(text string) map[string]interface{} {
+		return map[string]interface{}{"musicResponsiveListItemFlexColumnRenderer": map[string]interface{}{
+			"text": map[string]interface{}{"runs": []interface{}{map[string]string{"text": text}}},
+		}}
+	}
+	item := map[string]interface{}{"flexColumns": []interface{}{column(title), column("")}}
+	if videoID != "" {
+		item["playlistItemData"] = map[string]string{"videoId": videoID}
+	}
+	return map[string]interface{}{"musicResponsiveListItemRenderer": item}
+}
+
+// newMusicServer serves an album of three tracks over two pages, one of them
+// unavailable, and an artist page.
+func newMusicServer(t *testing.T) *httptest.Server {
+	albumPage := map[string]interface{}{
+		"header": map[string]interface{}{"musicDetailHeaderRenderer": map[string]interface{}{
+			"title": map[string]interface{}{"runs": []interface{}{map[string]string{"text": "Simplicity"}}},
+			"subtitle": map[string]interface{}{"runs": []interface{}{
+				map[string]string{"text": "Album"},
+				map[string]interface{}{"text": "Rob Pike", "navigationEndpoint": map[string]interface{}{"browseEndpoint": map[string]string{"browseId": "UCrob"}}},
+			}},
+			"secondSubtitle": map[string]interface{}{"runs": []interface{}{map[string]string{"text": "3 songs • 9 minutes"}}},
+		}},
+		"contents": map[string]interface{}{"musicPlaylistShelfRenderer": map[string]interface{}{
+			"contents":      []interface{}{musicItem("aaaaaaaaaaa", "Channels"), musicItem("", "Removed"), musicItem("bbbbbbbbbbb", "Interfaces")},
+			"continuations": []interface{}{map[string]interface{}{"nextContinuationData": map[string]string{"continuation": "page2"}}},
+		}},
+	}
+	lastPage := map[string]interface{}{
+		"continuationContents": map[string]interface{}{"musicPlaylistShelfContinuation": map[string]interface{}{
+			"contents": []interface{}{musicItem("ccccccccccc", "Goroutines")},
+		}},
+	}
+	release := func(id, title string) map[string]interface{} {
+		return map[string]interface{}{"musicTwoRowItemRenderer": map[string]interface{}{
+			"title":              map[string]interface{}{"runs": []interface{}{map[string]string{"text": title}}},
+			"navigationEndpoint": map[string]interface{}{"browseEndpoint": map[string]string{"browseId": id}},
+		}}
+	}
+	artistPage := map[string]interface{}{
+		"header": map[string]interface{}{"musicImmersiveHeaderRenderer": map[string]interface{}{
+			"title": map[string]interface{}{"runs": []interface{}{map[string]string{"text": "Rob Pike"}}},
+		}},
+		"contents": []interface{}{release("MPREb_one", "Simplicity"), release("MPREb_two", "Concurrency"), release("MPREb_one", "Simplicity"), release("VLPLplaylist", "Mix")},
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/youtubei/v1/browse" {
+			http.NotFound(w, r)
+			return
+		}
+		var body struct {
+			BrowseID string `json:"browseId"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		page := albumPage
+		switch {
+		case r.URL.Query().Get("continuation") == "page2":
+			page = lastPage
+		case body.BrowseID == "UCrob":
+			page = artistPage
+		case body.BrowseID != "MPREb_album":
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(page)
+	}))
+}
+
+func TestYoutube_MusicAlbum(t *testing.T) {
+	server := newMusicServer(t)
+	defer server.Close()
+	defer func(url string) { musicURL = url }(musicURL)
+	musicURL = server.URL
+
+	y := NewYoutube(false)
+	var events []Progress
+	y.OnProgress = func(p Progress) {
+		p.ETA = 0
+		events = append(events, p)
+	}
+	album, err := y.MusicAlbum(context.Background(), "https://music.youtube.com/browse/MPREb_album")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantEvents := []Progress{
+		{Phase: PhaseEnumerate, Pages: 1, Items: 2, Total: 3},
+		{Phase: PhaseEnumerate, Pages: 2, Items: 3, Total: 3},
+	}
+	if !reflect.DeepEqual(events, wantEvents) {
+		t.Errorf("progress = %+v, want %+v", events, wantEvents)
+	}
+	if album.Title != "Simplicity" || album.Artist != "Rob Pike" {
+		t.Errorf("album = %q by %q", album.Title, album.Artist)
+	}
+	if want := []string{"aaaaaaaaaaa", "bbbbbbbbbbb", "ccccccccccc"}; !reflect.DeepEqual(album.VideoIDs(), want) {
+		t.Errorf("VideoIDs() = %v, want %v", album.VideoIDs(), want)
+	}
+	if track := album.Tracks[2]; track.Title != "Goroutines" || track.Artist != "Rob Pike" {
+		t.Errorf("last track = %+v", track)
+	}
+
+	y.Limits.MaxPlaylistSize = 2
+	if _, err := y.MusicAlbum(context.Background(), "MPREb_album"); err != (ErrLimitExceeded{Limit: "MaxPlaylistSize", Max: 2}) {
+		t.Errorf("MusicAlbum() error = %v, want the MaxPlaylistSize limit", err)
+	}
+	if _, err := y.MusicAlbum(context.Background(), "https://music.youtube.com/channel/UCrob"); err != ErrInvalidMusicURL {
+		t.Errorf("MusicAlbum() error = %v, want ErrInvalidMusicURL for an artist", err)
+	}
+}
+
+func TestYoutube_PreviewMusicAlbum(t *testing.T) {
+	server := newMusicServer(t)
+	defer server.Close()
+	defer func(url string) { musicURL = url }(musicURL)
+	musicURL = server.URL
+
+	tests := []struct {
+		name     string
+		budget   Budget
+		wantIDs  []string
+		wantMore bool
+	}{
+		{name: "no budget", budget: Budget{}, wantIDs: []string{"aaaaaaaaaaa", "bbbbbbbbbbb", "ccccccccccc"}},
+		{name: "first item", budget: Budget{MaxItems: 1}, wantIDs: []string{"aaaaaaaaaaa"}, wantMore: true},
+		{name: "first page filled", budget: Budget{MaxItems: 2}, wantIDs: []string{"aaaaaaaaaaa", "bbbbbbbbbbb"}, wantMore: true},
+		{name: "first page", budget: Budget{MaxPages: 1}, wantIDs: []string{"aaaaaaaaaaa", "bbbbbbbbbbb"}, wantMore: true},
+		{name: "whole album", budget: Budget{MaxItems: 3, MaxPages: 2}, wantIDs: []string{"aaaaaaaaaaa", "bbbbbbbbbbb", "ccccccccccc"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			y := NewYoutube(false)
+			album, err := y.PreviewMusicAlbum(context.Background(), "MPREb_album", tt.budget)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(album.VideoIDs(), tt.wantIDs) || album.More != tt.wantMore || album.Total != 3 {
+				t.Errorf("PreviewMusicAlbum() = %v, more %v, total %d, want %v, more %v, total 3", album.VideoIDs(), album.More, album.Total, tt.wantIDs, tt.wantMore)
+			}
+		})
+	}
+
+	if _, err := NewYoutube(false).PreviewMusicAlbum(context.Background(), "MPREb_album", Budget{MaxItems: -1}); err == nil {
+		t.Error("PreviewMusicAlbum() accepted a negative budget")
+	}
+}
+
+func TestYoutube_MusicArtist(t *testing.T) {
+	server := newMusicServer(t)
+	defer server.Close()
+	defer func(url string) { musicURL = url }(musicURL)
+	musicURL = server.URL
+
+	artist, err := NewYoutube(false).MusicArtist(context.Background(), "https://music.youtube.com/channel/UCrob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &MusicArtist{BrowseID: "UCrob", Name: "Rob Pike", Releases: []MusicRelease{
+		{BrowseID: "MPREb_one", Title: "Simplicity"},
+		{BrowseID: "MPREb_two", Title: "Concurrency"},
+	}}
+	if !reflect.DeepEqual(artist, want) {
+		t.Errorf("MusicArtist() = %+v, want %+v", artist, want)
+	}
+}
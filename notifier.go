@@ -0,0 +1,34 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package youtube
+
+import (
+	"context"
+	"time"
+)
+
+// notifyTimeout bounds the time given to each notifier, notifications are
+// sent even when the queue is being stopped.
+const notifyTimeout = 30 * time.Second
+
+// Notification describes a download which finished, failed or was cancelled.
+type Notification struct {
+	URL      string
+	Title    string
+	Author   string
+	Duration time.Duration
+	Size     int64
+	Path     string
+	// Err is nil when the download succeeded.
+	Err error
+	// CancelReason is set when the download was cancelled rather than
+	// failed, Err is the cancellation error then.
+	CancelReason CancelReason
+}
+
+// Notifier is notified of the outcome of the downloads of a Queue, the
+// notify package has adapters for chat services.
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
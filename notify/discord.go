@@ -0,0 +1,27 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package notify
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/kkdai/youtube"
+)
+
+var _ youtube.Notifier = (*Discord)(nil)
+
+// Discord posts the notifications in a Discord channel through a webhook.
+type Discord struct {
+	// WebhookURL is the url of the webhook created in the channel settings.
+	WebhookURL string
+	HTTPClient *http.Client
+}
+
+// Notify implements youtube.Notifier.
+func (d *Discord) Notify(ctx context.Context, n youtube.Notification) error {
+	return postJSON(ctx, d.HTTPClient, d.WebhookURL, map[string]string{
+		"content": Message(n),
+	})
+}
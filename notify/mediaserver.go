@@ -0,0 +1,89 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package notify
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/kkdai/youtube"
+)
+
+// inLibrary reports whether the successful download of n landed in libraryDir,
+// every download is in the library when libraryDir is empty.
+func inLibrary(n youtube.Notification, libraryDir string) bool {
+	if n.Err != nil || n.Path == "" {
+		return false
+	}
+	if libraryDir == "" {
+		return true
+	}
+	rel, err := filepath.Rel(filepath.Clean(libraryDir), filepath.Clean(n.Path))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+var _ youtube.Notifier = (*Jellyfin)(nil)
+
+// Jellyfin scans the libraries of a Jellyfin (or Emby) server when a download
+// lands in LibraryDir.
+type Jellyfin struct {
+	// URL is the base url of the server, e.g. http://127.0.0.1:8096.
+	URL string
+	// APIKey is created in the dashboard, under API Keys.
+	APIKey string
+	// LibraryDir is the directory watched by the library, every successful
+	// download triggers a scan when empty.
+	LibraryDir string
+	HTTPClient *http.Client
+}
+
+// Notify implements youtube.Notifier.
+func (j *Jellyfin) Notify(ctx context.Context, n youtube.Notification) error {
+	if !inLibrary(n, j.LibraryDir) {
+		return nil
+	}
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(j.URL, "/")+"/Library/Refresh", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Emby-Token", j.APIKey)
+	return send(ctx, j.HTTPClient, req)
+}
+
+var _ youtube.Notifier = (*Plex)(nil)
+
+// Plex scans the folder of a download in a Plex library section when the
+// download lands in LibraryDir.
+type Plex struct {
+	// URL is the base url of the server, e.g. http://127.0.0.1:32400.
+	URL   string
+	Token string
+	// SectionID is the id of the library section to scan.
+	SectionID string
+	// LibraryDir is the directory of the section, every successful download
+	// triggers a scan of the whole section when empty.
+	LibraryDir string
+	HTTPClient *http.Client
+}
+
+// Notify implements youtube.Notifier.
+func (p *Plex) Notify(ctx context.Context, n youtube.Notification) error {
+	if !inLibrary(n, p.LibraryDir) {
+		return nil
+	}
+	query := url.Values{"X-Plex-Token": {p.Token}}
+	if p.LibraryDir != "" {
+		// a partial scan of the folder is much cheaper than scanning the section
+		query.Set("path", filepath.Dir(n.Path))
+	}
+	u := strings.TrimSuffix(p.URL, "/") + "/library/sections/" + url.PathEscape(p.SectionID) + "/refresh?" + query.Encode()
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	return send(ctx, p.HTTPClient, req)
+}
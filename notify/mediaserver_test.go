@@ -0,0 +1,86 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package notify
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kkdai/youtube"
+)
+
+func TestInLibrary(t *testing.T) {
+	failed := testNotification
+	failed.Err = errors.New("unexpected status code: 403")
+	tests := []struct {
+		name       string
+		n          youtube.Notification
+		libraryDir string
+		want       bool
+	}{
+		{"in library", testNotification, "/home/gopher/Movies", true},
+		{"no library", testNotification, "", true},
+		{"outside library", testNotification, "/srv/media", false},
+		{"sibling prefix", testNotification, "/home/gopher/Mov", false},
+		{"failed", failed, "/home/gopher/Movies", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inLibrary(tt.n, tt.libraryDir); got != tt.want {
+				t.Errorf("inLibrary() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJellyfin_Notify(t *testing.T) {
+	var scans int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/Library/Refresh" {
+			t.Errorf("requested %s %s, want POST /Library/Refresh", r.Method, r.URL.Path)
+		}
+		if got := r.Header.Get("X-Emby-Token"); got != "key" {
+			t.Errorf("token = %q, want key", got)
+		}
+		scans++
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	j := &Jellyfin{URL: server.URL + "/", APIKey: "key", LibraryDir: "/home/gopher/Movies"}
+	if err := j.Notify(context.Background(), testNotification); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	j.LibraryDir = "/srv/media"
+	if err := j.Notify(context.Background(), testNotification); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if scans != 1 {
+		t.Errorf("scanned %d times, want 1", scans)
+	}
+}
+
+func TestPlex_Notify(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/library/sections/2/refresh" {
+			t.Errorf("requested %s, want /library/sections/2/refresh", r.URL.Path)
+		}
+		if got := r.URL.Query().Get("X-Plex-Token"); got != "token" {
+			t.Errorf("token = %q, want token", got)
+		}
+		if got := r.URL.Query().Get("path"); got != "/home/gopher/Movies/youtubedr" {
+			t.Errorf("path = %q, want the folder of the download", got)
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	p := &Plex{URL: server.URL, Token: "token", SectionID: "2", LibraryDir: "/home/gopher/Movies"}
+	if err := p.Notify(context.Background(), testNotification); err == nil {
+		t.Error("Notify() should report the unexpected status code")
+	}
+}
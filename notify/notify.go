@@ -0,0 +1,86 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+/*
+Package notify implements youtube.Notifier adapters posting the outcome of
+the downloads of a youtube.Queue to chat services or by email, and refreshing
+media server libraries once new files land in them.
+*/
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/kkdai/youtube"
+)
+
+// Message returns the text posted for n.
+func Message(n youtube.Notification) string {
+	title := n.Title
+	if title == "" {
+		title = n.URL
+	}
+	if n.CancelReason != "" {
+		return fmt.Sprintf("Download cancelled (%s): %s", n.CancelReason, title)
+	}
+	if n.Err != nil {
+		return fmt.Sprintf("Download failed: %s\n%s", title, n.Err)
+	}
+	return fmt.Sprintf("Downloaded: %s (%s, %s)\n%s", title, n.Duration, FormatSize(n.Size), n.Path)
+}
+
+// FormatSize formats size in bytes with a binary unit.
+func FormatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// postJSON posts payload as json to url and checks the answer status.
+func postJSON(ctx context.Context, httpClient *http.Client, url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return send(ctx, httpClient, req)
+}
+
+// send sends req and checks the answer status.
+func send(ctx context.Context, httpClient *http.Client, req *http.Request) error {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req.WithContext(ctx))
+	if urlErr, ok := err.(*url.Error); ok {
+		// the url may hold a secret, such as the token of a Telegram bot
+		return fmt.Errorf("%s request: %s", urlErr.Op, urlErr.Err)
+	}
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		answer, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, answer)
+	}
+	return nil
+}
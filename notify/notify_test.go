@@ -0,0 +1,115 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kkdai/youtube"
+)
+
+var testNotification = youtube.Notification{
+	URL:      "https://www.youtube.com/watch?v=rFejpH_tAHM",
+	Title:    "dotGo 2015 - Rob Pike - Simplicity is Complicated",
+	Duration: 23*time.Minute + 21*time.Second,
+	Size:     52428800,
+	Path:     "/home/gopher/Movies/youtubedr/dotGo.mp4",
+}
+
+func TestMessage(t *testing.T) {
+	got := Message(testNotification)
+	for _, want := range []string{"Downloaded", testNotification.Title, "23m21s", "50.0 MiB", testNotification.Path} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Message() = %q should contain %q", got, want)
+		}
+	}
+
+	failed := testNotification
+	failed.Err = errors.New("unexpected status code: 403")
+	if got := Message(failed); !strings.Contains(got, "failed") || !strings.Contains(got, "403") {
+		t.Errorf("Message() = %q should report the failure", got)
+	}
+
+	cancelled := testNotification
+	cancelled.Err, cancelled.CancelReason = context.Canceled, youtube.CancelUser
+	if got := Message(cancelled); !strings.Contains(got, "cancelled (user)") {
+		t.Errorf("Message() = %q should report the cancellation", got)
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := map[int64]string{
+		0:       "0 B",
+		1023:    "1023 B",
+		1536:    "1.5 KiB",
+		1 << 30: "1.0 GiB",
+	}
+	for size, want := range tests {
+		if got := FormatSize(size); got != want {
+			t.Errorf("FormatSize(%d) = %q, want %q", size, got, want)
+		}
+	}
+}
+
+// newRecorder returns a server recording the json body posted to path.
+func newRecorder(t *testing.T, path string, body *map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != path {
+			t.Errorf("posted to %s, want %s", r.URL.Path, path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+			t.Error(err)
+		}
+	}))
+}
+
+func TestTelegram_Notify(t *testing.T) {
+	var body map[string]string
+	server := newRecorder(t, "/bot123:ABC/sendMessage", &body)
+	defer server.Close()
+
+	telegram := &Telegram{Token: "123:ABC", ChatID: "@downloads", APIURL: server.URL}
+	if err := telegram.Notify(context.Background(), testNotification); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if body["chat_id"] != "@downloads" || body["text"] != Message(testNotification) {
+		t.Errorf("posted %v", body)
+	}
+
+	server.Close()
+	err := telegram.Notify(context.Background(), testNotification)
+	if err == nil || strings.Contains(err.Error(), telegram.Token) {
+		t.Errorf("Notify() error = %v, want a failure without the token", err)
+	}
+}
+
+func TestDiscord_Notify(t *testing.T) {
+	var body map[string]string
+	server := newRecorder(t, "/api/webhooks/1/token", &body)
+	defer server.Close()
+
+	discord := &Discord{WebhookURL: server.URL + "/api/webhooks/1/token"}
+	if err := discord.Notify(context.Background(), testNotification); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if body["content"] != Message(testNotification) {
+		t.Errorf("posted %v", body)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unknown webhook", http.StatusNotFound)
+	}))
+	defer failing.Close()
+	discord.WebhookURL = failing.URL
+	if err := discord.Notify(context.Background(), testNotification); err == nil {
+		t.Error("Notify() should fail when the webhook is rejected")
+	}
+}
@@ -0,0 +1,176 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net/smtp"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/kkdai/youtube"
+)
+
+var _ youtube.Notifier = (*SMTP)(nil)
+
+// The templates used by SMTP when none is given.
+const (
+	DefaultSubjectTemplate = `youtube: {{.Succeeded}} downloaded{{if .Failed}}, {{.Failed}} failed{{end}}{{if .Cancelled}}, {{.Cancelled}} cancelled{{end}}`
+	DefaultBodyTemplate    = `{{range .Notifications}}{{if .CancelReason}}CANCEL  {{or .Title .URL}}
+        {{.CancelReason}}
+{{else if .Err}}FAILED  {{or .Title .URL}}
+        {{.Err}}
+{{else}}OK      {{.Title}} ({{.Duration}}, {{size .Size}})
+        {{.Path}}
+{{end}}{{end}}`
+)
+
+// sendMail is replaced in tests.
+var sendMail = smtp.SendMail
+
+// Digest is the data the SMTP templates are executed with.
+type Digest struct {
+	Notifications []youtube.Notification
+	Succeeded     int
+	Failed        int
+	// Cancelled downloads are not counted as failed.
+	Cancelled int
+}
+
+// DigestNotifier sends several notifications at once.
+type DigestNotifier interface {
+	SendDigest(ctx context.Context, notifications []youtube.Notification) error
+}
+
+// SMTP sends the notifications by email, wrap it in a Batch to receive
+// digests instead of one email per download.
+type SMTP struct {
+	// Addr is the host:port of the SMTP server.
+	Addr string
+	// Auth may be nil when the server doesn't require authentication.
+	Auth smtp.Auth
+	From string
+	To   []string
+	// Subject and Body are text/template templates executed with a Digest,
+	// DefaultSubjectTemplate and DefaultBodyTemplate are used when empty.
+	// The size function formats a size in bytes.
+	Subject string
+	Body    string
+}
+
+// Notify implements youtube.Notifier.
+func (s *SMTP) Notify(ctx context.Context, n youtube.Notification) error {
+	return s.SendDigest(ctx, []youtube.Notification{n})
+}
+
+// SendDigest sends a single email summing up the notifications, nothing when
+// there are none.
+func (s *SMTP) SendDigest(ctx context.Context, notifications []youtube.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	digest := Digest{Notifications: notifications}
+	for _, n := range notifications {
+		switch {
+		case n.CancelReason != "":
+			digest.Cancelled++
+		case n.Err != nil:
+			digest.Failed++
+		default:
+			digest.Succeeded++
+		}
+	}
+
+	subject, err := execute(s.Subject, DefaultSubjectTemplate, digest)
+	if err != nil {
+		return fmt.Errorf("subject template: %s", err)
+	}
+	body, err := execute(s.Body, DefaultBodyTemplate, digest)
+	if err != nil {
+		return fmt.Errorf("body template: %s", err)
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", s.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(s.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", strings.TrimSpace(subject)))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.Replace(body, "\n", "\r\n", -1))
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return sendMail(s.Addr, s.Auth, s.From, s.To, msg.Bytes())
+}
+
+func execute(text, defaultText string, digest Digest) (string, error) {
+	if text == "" {
+		text = defaultText
+	}
+	tmpl, err := template.New("").Funcs(template.FuncMap{"size": FormatSize}).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, digest); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+var _ youtube.Notifier = (*Batch)(nil)
+
+// Batch collects notifications and sends them as digests through Notifier
+// when flushed, Run flushes it periodically.
+type Batch struct {
+	Notifier DigestNotifier
+
+	mu      sync.Mutex
+	pending []youtube.Notification
+}
+
+// Notify implements youtube.Notifier, n is sent by the next flush.
+func (b *Batch) Notify(ctx context.Context, n youtube.Notification) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.pending = append(b.pending, n)
+	return nil
+}
+
+// Flush sends the collected notifications, they are kept for the next flush on failure.
+func (b *Batch) Flush(ctx context.Context) error {
+	b.mu.Lock()
+	pending := b.pending
+	b.pending = nil
+	b.mu.Unlock()
+
+	if err := b.Notifier.SendDigest(ctx, pending); err != nil {
+		b.mu.Lock()
+		b.pending = append(pending, b.pending...)
+		b.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Run flushes the batch every interval until ctx is done, then flushes it a last time.
+func (b *Batch) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			b.Flush(ctx)
+		case <-ctx.Done():
+			return b.Flush(context.Background())
+		}
+	}
+}
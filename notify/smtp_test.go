@@ -0,0 +1,84 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package notify
+
+import (
+	"context"
+	"errors"
+	"net/smtp"
+	"strings"
+	"testing"
+)
+
+// mailRecorder replaces sendMail and records the messages sent.
+type mailRecorder struct {
+	messages []string
+	err      error
+}
+
+func (r *mailRecorder) install() {
+	sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		if r.err != nil {
+			return r.err
+		}
+		r.messages = append(r.messages, string(msg))
+		return nil
+	}
+}
+
+func TestSMTP_Notify(t *testing.T) {
+	defer func() { sendMail = smtp.SendMail }()
+	recorder := &mailRecorder{}
+	recorder.install()
+
+	s := &SMTP{Addr: "localhost:25", From: "youtubed@example.com", To: []string{"gopher@example.com"}}
+	if err := s.Notify(context.Background(), testNotification); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if len(recorder.messages) != 1 {
+		t.Fatalf("sent %d messages, want 1", len(recorder.messages))
+	}
+	msg := recorder.messages[0]
+	for _, want := range []string{"To: gopher@example.com", "Subject: youtube: 1 downloaded\r\n", testNotification.Path, "50.0 MiB"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message %q should contain %q", msg, want)
+		}
+	}
+
+	s.Subject = "{{range .Notifications}}{{.Title}}{{end}}"
+	s.Body = "{{.Unknown}}"
+	if err := s.Notify(context.Background(), testNotification); err == nil {
+		t.Error("Notify() should fail with an invalid template")
+	}
+}
+
+func TestBatch(t *testing.T) {
+	defer func() { sendMail = smtp.SendMail }()
+	recorder := &mailRecorder{err: errors.New("connection refused")}
+	recorder.install()
+
+	failed := testNotification
+	failed.Err = errors.New("unexpected status code: 403")
+	batch := &Batch{Notifier: &SMTP{Addr: "localhost:25", From: "youtubed@example.com", To: []string{"gopher@example.com"}}}
+	batch.Notify(context.Background(), testNotification)
+	batch.Notify(context.Background(), failed)
+
+	if err := batch.Flush(context.Background()); err == nil {
+		t.Fatal("Flush() should report the sending failure")
+	}
+	recorder.err = nil
+	if err := batch.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if len(recorder.messages) != 1 {
+		t.Fatalf("sent %d messages, want 1 digest", len(recorder.messages))
+	}
+	if !strings.Contains(recorder.messages[0], "Subject: youtube: 1 downloaded, 1 failed") {
+		t.Errorf("digest %q should count both notifications", recorder.messages[0])
+	}
+
+	if err := batch.Flush(context.Background()); err != nil || len(recorder.messages) != 1 {
+		t.Error("an empty batch should not send anything")
+	}
+}
@@ -0,0 +1,39 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package notify
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/kkdai/youtube"
+)
+
+var _ youtube.Notifier = (*Telegram)(nil)
+
+// TelegramAPIURL is the endpoint of the Telegram bot API.
+const TelegramAPIURL = "https://api.telegram.org"
+
+// Telegram posts the notifications in a Telegram chat through a bot.
+type Telegram struct {
+	// Token is the token of the bot given by @BotFather.
+	Token string
+	// ChatID is the id of the chat, or @channelusername.
+	ChatID string
+	// APIURL defaults to TelegramAPIURL.
+	APIURL     string
+	HTTPClient *http.Client
+}
+
+// Notify implements youtube.Notifier.
+func (t *Telegram) Notify(ctx context.Context, n youtube.Notification) error {
+	apiURL := t.APIURL
+	if apiURL == "" {
+		apiURL = TelegramAPIURL
+	}
+	return postJSON(ctx, t.HTTPClient, apiURL+"/bot"+t.Token+"/sendMessage", map[string]string{
+		"chat_id": t.ChatID,
+		"text":    Message(n),
+	})
+}
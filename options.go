@@ -0,0 +1,275 @@
+package youtube
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Options configures a Youtube created by NewYoutubeWithOptions.
+//
+// The metadataonly build keeps the fields configuring the downloads, such as
+// Trash, Upgrade, TransferWindows, the OnItem callbacks, OutputDir, Quality
+// and ItagNo, with their types, and ignores them: the same Options, often
+// shared by a program with its tests or its other builds, compile with and
+// without the tag.
+type Options struct {
+	DebugMode   bool
+	Socks5Proxy string
+	// RateLimit caps the download speed in bytes per second, 0 means no limit.
+	RateLimit int64
+	// HTTPClient is shared by the requests instead of a client built for
+	// Socks5Proxy, see NewBatchHTTPClient.
+	HTTPClient *http.Client
+	// Health is shared by the downloads to avoid the formats failing chronically.
+	Health *FormatHealth
+	// ChooseFormat picks the format to download when none is given, see Youtube.ChooseFormat.
+	ChooseFormat func(formats []Format) (int, error)
+	// Limits guards against adversarial inputs, see Limits.
+	Limits Limits
+	// MetadataCache is shared by the decodes to avoid fetching the same video info again.
+	MetadataCache *MetadataCache
+	// ThumbnailCache is shared by the clients to avoid downloading the same thumbnails again.
+	ThumbnailCache *ThumbnailCache
+	// MaxURLAge is the age past which stream urls are resolved again before a download.
+	MaxURLAge time.Duration
+	// Jitter randomizes the requests slightly to look less like a bot, see Jitter.
+	Jitter Jitter
+	// Session is shared by the clients to warm up once, see Youtube.ExportState.
+	Session *Session
+	// Filesystem is the default filesystem the downloads are written to, see Filesystem.
+	Filesystem Filesystem
+	// OnExtensionMismatch is called when a downloaded file holds another container than its extension says.
+	OnExtensionMismatch func(ExtensionMismatch)
+	// OnProgress is called with the progress of the downloads and of the enumerations, see Progress.
+	OnProgress func(Progress)
+	// Trash keeps the files replaced by the downloads instead of overwriting them, see Trash.
+	Trash *Trash
+	// Archive records the videos downloaded, the Queue then skips or upgrades the archived ones, see Youtube.Sync.
+	Archive *Archive
+	// Upgrade decides whether the archived videos are downloaded again, see UpgradePolicy.
+	Upgrade UpgradePolicy
+	// POTokenProvider supplies the proof of origin tokens youtube asks for, see POTokenProvider.
+	POTokenProvider POTokenProvider
+	// OnItemStart, OnItemComplete and OnItemSkipped are called for each video of the lists downloaded by DownloadList.
+	OnItemStart    func(ListItem)
+	OnItemComplete func(ListItemResult)
+	OnItemSkipped  func(ListItemResult)
+	// TransferWindows restricts the downloads of the media to some periods of the day, see TransferWindows.
+	TransferWindows TransferWindows
+	// OutputDir, Quality and ItagNo are used by StartDownload when the
+	// matching argument is left empty.
+	OutputDir string
+	Quality   string
+	ItagNo    int
+}
+
+// Validate checks the options and returns every problem found at once as ErrInvalidOptions.
+func (o Options) Validate() error {
+	errs := validateOptions(o.Socks5Proxy, o.RateLimit, o.Quality, o.ItagNo)
+	errs = append(errs, o.Limits.validate()...)
+	errs = append(errs, o.Filesystem.validate()...)
+	errs = append(errs, o.Trash.validate()...)
+	errs = append(errs, o.Upgrade.validate()...)
+	errs = append(errs, o.TransferWindows.validate()...)
+	return append(errs, o.Jitter.validate()...).err()
+}
+
+// DownloadOptions overrides the client options for a single download,
+// zero values keep the defaults of the client.
+type DownloadOptions struct {
+	OutputDir   string `json:"output_dir,omitempty"`
+	OutputFile  string `json:"output_file,omitempty"`
+	Quality     string `json:"quality,omitempty"`
+	ItagNo      int    `json:"itag,omitempty"`
+	Socks5Proxy string `json:"socks5_proxy,omitempty"`
+	RateLimit   int64  `json:"rate_limit,omitempty"`
+	// Resume downloads into a .part file next to the output file, a later
+	// download of the same file continues where the previous one stopped.
+	Resume bool `json:"resume,omitempty"`
+	// Retries is how many times a download failing on a transient error,
+	// such as a network error or a stall, is retried.
+	Retries int `json:"retries,omitempty"`
+	// Itags are downloaded together, sharing the decoded metadata, instead of
+	// a single format. They can't be used with ItagNo or Quality, nor with
+	// OutputFile for several itags.
+	Itags []int `json:"itags,omitempty"`
+	// Filesystem names the output file after the restrictions of the
+	// filesystem it is written to, see Filesystem.
+	Filesystem Filesystem `json:"filesystem,omitempty"`
+	// FixExtension renames the downloaded file to the extension of the
+	// container its content actually is, such as .webm for webm bytes
+	// served as video/mp4, see ExtensionMismatch.
+	FixExtension bool `json:"fix_extension,omitempty"`
+}
+
+// Validate checks the options and returns every problem found at once as ErrInvalidOptions.
+func (o DownloadOptions) Validate() error {
+	errs := validateOptions(o.Socks5Proxy, o.RateLimit, o.Quality, o.ItagNo)
+	errs = append(errs, o.Filesystem.validate()...)
+	if o.Retries < 0 {
+		errs = append(errs, ErrInvalidOption{Option: "Retries", Reason: "must not be negative"})
+	}
+	if len(o.Itags) > 0 {
+		if o.ItagNo != 0 || o.Quality != "" {
+			errs = append(errs, ErrInvalidOption{Option: "Itags", Reason: "can't be used together with ItagNo or Quality"})
+		}
+		if len(o.Itags) > 1 && o.OutputFile != "" {
+			errs = append(errs, ErrInvalidOption{Option: "Itags", Reason: "can't be used together with OutputFile for several itags"})
+		}
+		for _, itag := range o.Itags {
+			if itag <= 0 {
+				errs = append(errs, ErrInvalidOption{Option: "Itags", Reason: "must be positive"})
+				break
+			}
+		}
+	}
+	return errs.err()
+}
+
+// withDefaults fills the zero fields of opts with the client defaults.
+func (y *Youtube) withDefaults(opts DownloadOptions) DownloadOptions {
+	if opts.OutputDir == "" {
+		opts.OutputDir = y.outputDir
+	}
+	if opts.ItagNo == 0 && opts.Quality == "" {
+		opts.ItagNo, opts.Quality = y.itagNo, y.quality
+	}
+	if opts.Socks5Proxy == "" {
+		opts.Socks5Proxy = y.Socks5Proxy
+	}
+	if opts.RateLimit == 0 {
+		opts.RateLimit = y.RateLimit
+	}
+	if opts.Filesystem == "" {
+		opts.Filesystem = y.Filesystem
+	}
+	return opts
+}
+
+func validateOptions(socks5Proxy string, rateLimit int64, quality string, itagNo int) ErrInvalidOptions {
+	var errs ErrInvalidOptions
+	if rateLimit < 0 {
+		errs = append(errs, ErrInvalidOption{Option: "RateLimit", Reason: "must not be negative"})
+	}
+	if itagNo < 0 {
+		errs = append(errs, ErrInvalidOption{Option: "ItagNo", Reason: "must not be negative"})
+	}
+	if itagNo != 0 && quality != "" {
+		errs = append(errs, ErrInvalidOption{Option: "ItagNo", Reason: "can't be used together with Quality"})
+	}
+	if socks5Proxy != "" {
+		if reason := validateProxyAddress(socks5Proxy); reason != "" {
+			errs = append(errs, ErrInvalidOption{Option: "Socks5Proxy", Reason: reason})
+		}
+	}
+	return errs
+}
+
+// err returns errs as an error, or nil when there is no error.
+func (errs ErrInvalidOptions) err() error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// validateProxyAddress returns why addr is not a valid host:port pair, or an empty string.
+func validateProxyAddress(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err.Error()
+	}
+	if host == "" {
+		return "missing host in address " + addr
+	}
+	if portNo, err := strconv.Atoi(port); err != nil || portNo <= 0 || portNo > 65535 {
+		return "invalid port in address " + addr
+	}
+	return ""
+}
+
+// NewYoutubeWithOptions validates opts and initializes a youtube package object with them.
+func NewYoutubeWithOptions(opts Options) (*Youtube, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	return newYoutubeWithOptions(opts), nil
+}
+
+// newYoutubeWithOptions initializes a youtube package object with opts, which were validated.
+func newYoutubeWithOptions(opts Options) *Youtube {
+	y := NewYoutube(opts.DebugMode)
+	y.Socks5Proxy = opts.Socks5Proxy
+	y.RateLimit = opts.RateLimit
+	y.HTTPClient = opts.HTTPClient
+	y.Health = opts.Health
+	y.ChooseFormat = opts.ChooseFormat
+	y.Limits = opts.Limits
+	y.MetadataCache = opts.MetadataCache
+	y.ThumbnailCache = opts.ThumbnailCache
+	y.MaxURLAge = opts.MaxURLAge
+	y.Jitter = opts.Jitter
+	y.Session = opts.Session
+	y.Filesystem = opts.Filesystem
+	y.OnExtensionMismatch = opts.OnExtensionMismatch
+	y.OnProgress = opts.OnProgress
+	y.Trash = opts.Trash
+	y.Archive = opts.Archive
+	y.Upgrade = opts.Upgrade
+	y.TransferWindows = opts.TransferWindows
+	y.POTokenProvider = opts.POTokenProvider
+	y.OnItemStart = opts.OnItemStart
+	y.OnItemComplete = opts.OnItemComplete
+	y.OnItemSkipped = opts.OnItemSkipped
+	y.outputDir = opts.OutputDir
+	y.quality = opts.Quality
+	y.itagNo = opts.ItagNo
+	return y
+}
+
+// options returns the options y was initialized with, the videos of a list
+// are decoded by Youtube objects sharing them.
+func (y *Youtube) options() Options {
+	return Options{
+		DebugMode:           y.DebugMode,
+		Socks5Proxy:         y.Socks5Proxy,
+		RateLimit:           y.RateLimit,
+		HTTPClient:          y.HTTPClient,
+		Health:              y.Health,
+		ChooseFormat:        y.ChooseFormat,
+		Limits:              y.Limits,
+		MetadataCache:       y.MetadataCache,
+		ThumbnailCache:      y.ThumbnailCache,
+		MaxURLAge:           y.MaxURLAge,
+		Jitter:              y.Jitter,
+		Session:             y.session(),
+		Filesystem:          y.Filesystem,
+		OnExtensionMismatch: y.OnExtensionMismatch,
+		OnProgress:          y.OnProgress,
+		Trash:               y.Trash,
+		Archive:             y.Archive,
+		Upgrade:             y.Upgrade,
+		TransferWindows:     y.TransferWindows,
+		POTokenProvider:     y.POTokenProvider,
+		OnItemStart:         y.OnItemStart,
+		OnItemComplete:      y.OnItemComplete,
+		OnItemSkipped:       y.OnItemSkipped,
+		OutputDir:           y.outputDir,
+		Quality:             y.quality,
+		ItagNo:              y.itagNo,
+	}
+}
+
+// Clone returns a copy of the decoded video sharing the options, the session
+// and the caches of y, with its own download state. The copies, and y, can
+// download the video concurrently, which a single Youtube can't.
+func (y *Youtube) Clone() *Youtube {
+	c := newYoutubeWithOptions(y.options())
+	c.VideoID = y.VideoID
+	c.videoInfo = y.videoInfo
+	c.playerResponse = y.playerResponse
+	c.StreamList = append([]stream(nil), y.StreamList...)
+	return c
+}
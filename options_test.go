@@ -0,0 +1,125 @@
+package youtube
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     Options
+		wantErr  bool
+		wantErrs []error
+	}{
+		{
+			name: "zero options",
+			opts: Options{},
+		},
+		{
+			name: "valid options",
+			opts: Options{Socks5Proxy: "10.10.10.10:7878", RateLimit: 1024, Quality: "medium"},
+		},
+		{
+			name:    "negative rate limit",
+			opts:    Options{RateLimit: -1},
+			wantErr: true,
+			wantErrs: []error{
+				ErrInvalidOption{Option: "RateLimit", Reason: "must not be negative"},
+			},
+		},
+		{
+			name:    "malformed proxy",
+			opts:    Options{Socks5Proxy: "socks5://10.10.10.10:7878"},
+			wantErr: true,
+		},
+		{
+			name:    "several problems",
+			opts:    Options{RateLimit: -1, ItagNo: 18, Quality: "medium"},
+			wantErr: true,
+			wantErrs: []error{
+				ErrInvalidOption{Option: "RateLimit", Reason: "must not be negative"},
+				ErrInvalidOption{Option: "ItagNo", Reason: "can't be used together with Quality"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate()
+			if !tt.wantErr {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+
+			var errs ErrInvalidOptions
+			if !errors.As(err, &errs) {
+				t.Fatalf("Validate() error = %v, want ErrInvalidOptions", err)
+			}
+			for _, want := range tt.wantErrs {
+				if !errors.Is(err, want) {
+					t.Errorf("Validate() error = %v, should contain %v", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNewYoutubeWithOptions(t *testing.T) {
+	if _, err := NewYoutubeWithOptions(Options{Socks5Proxy: "no-port"}); err == nil {
+		t.Error("invalid options should not create a Youtube")
+	}
+
+	y, err := NewYoutubeWithOptions(Options{RateLimit: 1024, OutputDir: "out", ItagNo: 18})
+	if err != nil {
+		t.Fatalf("NewYoutubeWithOptions() error = %v", err)
+	}
+	if y.RateLimit != 1024 || y.outputDir != "out" || y.itagNo != 18 {
+		t.Errorf("options were not applied: %+v", y)
+	}
+}
+
+func TestYoutube_withDefaults(t *testing.T) {
+	y, err := NewYoutubeWithOptions(Options{Socks5Proxy: "10.10.10.10:7878", RateLimit: 1024, OutputDir: "out", Quality: "medium"})
+	if err != nil {
+		t.Fatalf("NewYoutubeWithOptions() error = %v", err)
+	}
+
+	got := y.withDefaults(DownloadOptions{ItagNo: 18, RateLimit: 2048})
+	want := DownloadOptions{OutputDir: "out", ItagNo: 18, Socks5Proxy: "10.10.10.10:7878", RateLimit: 2048}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("withDefaults() = %+v, want %+v", got, want)
+	}
+
+	got = y.withDefaults(DownloadOptions{})
+	want = DownloadOptions{OutputDir: "out", Quality: "medium", Socks5Proxy: "10.10.10.10:7878", RateLimit: 1024}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("withDefaults() = %+v, want %+v", got, want)
+	}
+}
+
+func TestYoutube_Clone(t *testing.T) {
+	y, err := NewYoutubeWithOptions(Options{RateLimit: 1024, OutputDir: "out"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	y.VideoID = "rFejpH_tAHM"
+	y.StreamList = []stream{{ItagNo: 18, Title: "talk"}}
+
+	c := y.Clone()
+	if c.VideoID != y.VideoID || c.RateLimit != 1024 || c.outputDir != "out" || len(c.StreamList) != 1 {
+		t.Errorf("Clone() = %+v, want the video and the options of y", c)
+	}
+	if c.Session == nil || c.Session != y.Session {
+		t.Error("Clone() should share the session of y")
+	}
+	if c.DownloadPercent == y.DownloadPercent {
+		t.Error("Clone() should have its own download state")
+	}
+	c.StreamList[0].ItagNo = 22
+	if y.StreamList[0].ItagNo != 18 {
+		t.Error("Clone() should not share the stream list of y")
+	}
+}
@@ -0,0 +1,26 @@
+package youtube
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// isSpecialFile reports whether fi is a named pipe or a device, which are
+// written to as they are instead of being created or truncated.
+func isSpecialFile(fi os.FileInfo) bool {
+	return fi.Mode()&(os.ModeNamedPipe|os.ModeDevice|os.ModeCharDevice) != 0
+}
+
+// createDestination opens destFile for writing. An existing FIFO is opened
+// as is so a downstream consumer can read the bytes as they arrive, any
+// other destination is created along with its directory.
+func createDestination(destFile string) (*os.File, error) {
+	if fi, err := os.Stat(destFile); err == nil && isSpecialFile(fi) {
+		return os.OpenFile(destFile, os.O_WRONLY, 0)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(destFile), 0755); err != nil {
+		return nil, err
+	}
+	return os.Create(destFile)
+}
@@ -0,0 +1,82 @@
+//go:build (linux || darwin) && !metadataonly
+// +build linux darwin
+// +build !metadataonly
+
+package youtube
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestYoutube_videoDLWorker_FIFO(t *testing.T) {
+	media := append(append([]byte{}, mp4Header...), bytes.Repeat([]byte{0}, 4096)...)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "video/mp4")
+		w.Write(media)
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "youtube")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fifo := filepath.Join(dir, "video.fifo")
+	if err := syscall.Mkfifo(fifo, 0600); err != nil {
+		t.Skip("mkfifo is not supported:", err)
+	}
+
+	received := make(chan []byte)
+	go func() {
+		f, err := os.Open(fifo)
+		if err != nil {
+			received <- nil
+			return
+		}
+		defer f.Close()
+		data, _ := ioutil.ReadAll(f)
+		received <- data
+	}()
+
+	y := NewYoutube(false)
+	if err := y.videoDLWorker(context.Background(), fifo, server.URL, "video/mp4", DownloadOptions{}); err != nil {
+		t.Fatalf("videoDLWorker() error = %v", err)
+	}
+	if got := <-received; !bytes.Equal(got, media) {
+		t.Errorf("read %d bytes from the fifo, want %d", len(got), len(media))
+	}
+	if fi, err := os.Stat(fifo); err != nil || fi.Mode()&os.ModeNamedPipe == 0 {
+		t.Error("the fifo should not be replaced by a regular file")
+	}
+}
+
+func TestLockFile_FIFO(t *testing.T) {
+	dir, err := ioutil.TempDir("", "youtube")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fifo := filepath.Join(dir, "video.fifo")
+	if err := syscall.Mkfifo(fifo, 0600); err != nil {
+		t.Skip("mkfifo is not supported:", err)
+	}
+
+	lock, err := lockFile(fifo, false)
+	if err != nil {
+		t.Fatalf("lockFile() error = %v", err)
+	}
+	if _, err := os.Stat(fifo + lockSuffix); !os.IsNotExist(err) {
+		t.Error("no lock file should be created next to a fifo")
+	}
+	if err := lock.unlock(); err != nil {
+		t.Errorf("unlock() error = %v", err)
+	}
+}
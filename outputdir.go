@@ -0,0 +1,18 @@
+//go:build !js
+// +build !js
+
+package youtube
+
+import (
+	"os/user"
+	"path/filepath"
+)
+
+// defaultOutputDir returns the directory downloads go to when none is given.
+func defaultOutputDir() (string, error) {
+	usr, err := user.Current()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(usr.HomeDir, "Movies", "youtubedr"), nil
+}
@@ -0,0 +1,11 @@
+package youtube
+
+import (
+	"errors"
+)
+
+// defaultOutputDir returns an error as there is no home directory to
+// download to under js/wasm, give an output directory or use StartDownloadToWriter.
+func defaultOutputDir() (string, error) {
+	return "", errors.New("no default output directory under js/wasm")
+}
@@ -0,0 +1,163 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+)
+
+// planVersion is the version of the plans written by NewPlan, plans of
+// another version are rejected by ExecutePlan.
+const planVersion = 1
+
+// Plan is a resolved download plan: the videos to download, with the format
+// chosen and the path of each. It is written to a json job file to be
+// reviewed, then executed verbatim by ExecutePlan, later or elsewhere.
+type Plan struct {
+	Version   int        `json:"version"`
+	CreatedAt time.Time  `json:"created_at"`
+	Items     []PlanItem `json:"items"`
+	// Failed lists the videos which couldn't be planned, they are left out of Items.
+	Failed []PlanFailure `json:"failed,omitempty"`
+	// Skipped lists the videos DownloadList would skip, such as the ones
+	// the Archive holds already, they are left out of Items.
+	Skipped []PlanSkip `json:"skipped,omitempty"`
+}
+
+// PlanItem is a video of a Plan. Its Options are the ones of the download,
+// with the client defaults applied, the itag of Format and the output
+// directory and file of Path, so that nothing is decided again at execution.
+type PlanItem struct {
+	VideoID string          `json:"video_id"`
+	Title   string          `json:"title,omitempty"`
+	Format  Format          `json:"format"`
+	Path    string          `json:"path"`
+	Options DownloadOptions `json:"options"`
+	// Sync is what the download does with the archive of the client, when it has one.
+	Sync SyncAction `json:"sync,omitempty"`
+}
+
+// PlanSkip is a video left out of a plan, Reason is a skip reason of
+// ListItemResult, such as SkipArchived.
+type PlanSkip struct {
+	VideoID string `json:"video_id"`
+	Title   string `json:"title,omitempty"`
+	Reason  string `json:"reason"`
+}
+
+// PlanFailure is a video which couldn't be planned.
+type PlanFailure struct {
+	VideoID string `json:"video_id"`
+	Error   string `json:"error"`
+}
+
+// NewPlan returns the plan of items.
+func NewPlan(items ...PlanItem) *Plan {
+	return &Plan{Version: planVersion, CreatedAt: time.Now().UTC(), Items: items}
+}
+
+// PlanDownload resolves the download of the decoded video according to opts
+// without downloading it.
+func (y *Youtube) PlanDownload(opts DownloadOptions) (PlanItem, error) {
+	opts, s, err := y.prepareDownload(opts)
+	if err != nil {
+		return PlanItem{}, err
+	}
+	path, err := destination(opts, s)
+	if err != nil {
+		return PlanItem{}, err
+	}
+	opts.ItagNo, opts.Quality, opts.Itags = s.ItagNo, "", nil
+	opts.OutputDir, opts.OutputFile = filepath.Dir(path), filepath.Base(path)
+	return PlanItem{VideoID: y.VideoID, Title: s.Title, Format: s.format(), Path: path, Options: opts}, nil
+}
+
+// PlanList resolves the downloads of the items as DownloadList would make
+// them, without downloading anything. With an Archive, the items are synced
+// as by Sync: the archived videos are skipped, or planned as upgrades
+// according to the Upgrade policy. It stops when ctx is done.
+func (y *Youtube) PlanList(ctx context.Context, items []ListItem, opts DownloadOptions) (*Plan, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	opts.OutputFile = ""
+	plan := NewPlan()
+	for _, item := range items {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		planned, err := y.planItem(ctx, item, opts)
+		switch {
+		case err != nil:
+			plan.Failed = append(plan.Failed, PlanFailure{VideoID: item.VideoID, Error: err.Error()})
+		case planned.Sync == SyncSkipped:
+			plan.Skipped = append(plan.Skipped, PlanSkip{VideoID: planned.VideoID, Title: planned.Title, Reason: SkipArchived})
+		default:
+			plan.Items = append(plan.Items, planned)
+		}
+	}
+	return plan, nil
+}
+
+func (y *Youtube) planItem(ctx context.Context, item ListItem, opts DownloadOptions) (PlanItem, error) {
+	video, err := NewYoutubeWithOptions(y.options())
+	if err != nil {
+		return PlanItem{}, err
+	}
+	if err := video.DecodeURLWithContext(ctx, item.VideoID); err != nil {
+		return PlanItem{}, err
+	}
+	if video.Archive == nil {
+		return video.PlanDownload(opts)
+	}
+	archived, action, opts := video.syncOptions(opts)
+	if action == SyncSkipped {
+		return PlanItem{VideoID: video.VideoID, Title: archived.Title, Sync: action}, nil
+	}
+	planned, err := video.PlanDownload(opts)
+	planned.Sync = action
+	return planned, err
+}
+
+// ExecutePlan downloads the items of plan with their options, as
+// DownloadList does: a format gone since the plan was made fails its item
+// with ErrItagNotFound instead of being replaced.
+func (y *Youtube) ExecutePlan(ctx context.Context, plan *Plan) ([]ListItemResult, error) {
+	if plan.Version != planVersion {
+		return nil, ErrPlanVersion
+	}
+	items := make([]ListItem, len(plan.Items))
+	for i, planned := range plan.Items {
+		items[i] = ListItem{Index: i, VideoID: planned.VideoID, Title: planned.Title}
+	}
+	return y.runList(ctx, items, func(item ListItem) DownloadOptions {
+		return plan.Items[item.Index].Options
+	})
+}
+
+// ReadPlan reads a plan written by Plan.WriteFile.
+func ReadPlan(path string) (*Plan, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var plan Plan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// WriteFile writes the plan to path as json.
+func (p *Plan) WriteFile(path string) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
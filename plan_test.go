@@ -0,0 +1,114 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestYoutube_ExecutePlan(t *testing.T) {
+	media := newMediaServer(append([]byte{}, mp4Header...))
+	defer media.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		videoID := r.URL.Query().Get("video_id")
+		if videoID == "dQw4w9WgXcQ" {
+			w.Write([]byte(url.Values{"status": {"fail"}, "reason": {"Video unavailable"}}.Encode()))
+			return
+		}
+		playerResponse, _ := json.Marshal(map[string]interface{}{
+			"playabilityStatus": map[string]string{"status": "OK"},
+			"videoDetails":      map[string]string{"title": "title of " + videoID, "author": "gopher"},
+			"streamingData": map[string]interface{}{
+				"formats": []map[string]interface{}{
+					{"itag": 18, "url": media.URL + "/18", "mimeType": "video/mp4", "quality": "medium"},
+					{"itag": 22, "url": media.URL + "/22", "mimeType": "video/mp4", "quality": "hd720"},
+				},
+			},
+		})
+		w.Write([]byte(url.Values{"status": {"ok"}, "player_response": {string(playerResponse)}}.Encode()))
+	}))
+	defer server.Close()
+	defer func(url string) { baseURL = url }(baseURL)
+	baseURL = server.URL
+
+	dir, err := ioutil.TempDir("", "youtube")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	y := NewYoutube(false)
+	items := []ListItem{{Index: 0, VideoID: "BaW_jenozKc"}, {Index: 1, VideoID: "dQw4w9WgXcQ"}, {Index: 2, VideoID: "rFejpH_tAHM"}}
+	plan, err := y.PlanList(context.Background(), items, DownloadOptions{OutputDir: dir, Quality: "hd720"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Items) != 2 || len(plan.Failed) != 1 || plan.Failed[0].VideoID != "dQw4w9WgXcQ" {
+		t.Fatalf("PlanList() = %+v", plan)
+	}
+	planned := plan.Items[0]
+	if planned.Format.ItagNo != 22 || planned.Options.ItagNo != 22 || planned.Path != filepath.Join(dir, "title of BaW_jenozKc.mp4") {
+		t.Errorf("planned item = %+v", planned)
+	}
+	if entries, _ := ioutil.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("PlanList() wrote %d files", len(entries))
+	}
+
+	// reviewed, the second video is changed to another format gone since
+	path := filepath.Join(dir, "plan.json")
+	plan.Items[1].Options.ItagNo = 137
+	if err := plan.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+	reviewed, err := ReadPlan(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := y.ExecutePlan(context.Background(), reviewed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Err != nil || results[0].Path != planned.Path {
+		t.Errorf("executed item = %+v, want %s", results[0], planned.Path)
+	}
+	if _, err := os.Stat(planned.Path); err != nil {
+		t.Error(err)
+	}
+	if results[1].Err != ErrItagNotFound {
+		t.Errorf("item of a vanished format = %v, want ErrItagNotFound", results[1].Err)
+	}
+
+	// the archived videos are planned as DownloadList syncs them
+	archive, err := OpenArchive(filepath.Join(dir, "archive.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := archive.Record(ArchiveEntry{VideoID: "BaW_jenozKc", Path: planned.Path, Title: "title of BaW_jenozKc", Format: planned.Format}); err != nil {
+		t.Fatal(err)
+	}
+	y.Archive = archive
+	synced, err := y.PlanList(context.Background(), items, DownloadOptions{OutputDir: dir, Quality: "hd720"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(synced.Skipped) != 1 || synced.Skipped[0].VideoID != "BaW_jenozKc" || synced.Skipped[0].Reason != SkipArchived {
+		t.Errorf("PlanList() skipped %+v, want the archived video", synced.Skipped)
+	}
+	if len(synced.Items) != 1 || synced.Items[0].VideoID != "rFejpH_tAHM" || synced.Items[0].Sync != SyncDownloaded {
+		t.Errorf("PlanList() planned %+v, want the video missing from the archive", synced.Items)
+	}
+	y.Archive = nil
+
+	reviewed.Version = 2
+	if _, err := y.ExecutePlan(context.Background(), reviewed); err != ErrPlanVersion {
+		t.Errorf("ExecutePlan() of another version = %v", err)
+	}
+}
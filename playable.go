@@ -0,0 +1,76 @@
+package youtube
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// userAgent is sent with every request, players opening a PlayableStream
+// must send it too.
+const userAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/86.0.4240.111 Safari/537.36"
+
+// PlayableStream is a stream a media player can open directly instead of
+// downloading it first.
+type PlayableStream struct {
+	Format Format
+	URL    string
+	// AudioFormat and AudioURL are set when Format is an adaptive video
+	// without audio, the player must play the audio alongside.
+	AudioFormat *Format
+	AudioURL    string
+	// Header holds the headers the player must send with its requests.
+	Header http.Header
+}
+
+// ResolvePlayable returns the url of the stream selected by opts, as a
+// download would, with the headers needed to open it, without downloading
+// anything. The output directory and file of opts are ignored.
+func (y *Youtube) ResolvePlayable(opts DownloadOptions) (*PlayableStream, error) {
+	_, stream, err := y.prepareDownload(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	playable := &PlayableStream{
+		Format: stream.format(),
+		URL:    stream.URL,
+		Header: http.Header{},
+	}
+	if stream.Adaptive && strings.HasPrefix(stream.Type, "video/") {
+		if audio, ok := y.bestAudioStream(); ok {
+			audioFormat := audio.format()
+			playable.AudioFormat = &audioFormat
+			playable.AudioURL = audio.URL
+		}
+	}
+
+	playable.Header.Set("User-Agent", userAgent)
+	if httpClient, err := y.getHTTPClientWithProxy(opts.Socks5Proxy); err == nil && httpClient.Jar != nil {
+		if u, err := url.Parse(stream.URL); err == nil {
+			var cookies []string
+			for _, cookie := range httpClient.Jar.Cookies(u) {
+				cookies = append(cookies, cookie.String())
+			}
+			if len(cookies) > 0 {
+				playable.Header.Set("Cookie", strings.Join(cookies, "; "))
+			}
+		}
+	}
+	return playable, nil
+}
+
+// bestAudioStream returns the audio only stream with the highest bitrate.
+func (y *Youtube) bestAudioStream() (stream, bool) {
+	var best stream
+	found := false
+	for _, s := range y.StreamList {
+		if !s.Adaptive || !strings.HasPrefix(s.Type, "audio/") {
+			continue
+		}
+		if !found || s.Bitrate > best.Bitrate {
+			best, found = s, true
+		}
+	}
+	return best, found
+}
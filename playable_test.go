@@ -0,0 +1,52 @@
+package youtube
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"testing"
+)
+
+func TestYoutube_ResolvePlayable(t *testing.T) {
+	y := NewYoutube(false)
+	y.StreamList = []stream{
+		{ItagNo: 18, Quality: "medium", Type: "video/mp4", URL: "https://r1.googlevideo.com/videoplayback?itag=18"},
+		{ItagNo: 137, Quality: "hd1080", Type: "video/mp4", URL: "https://r1.googlevideo.com/videoplayback?itag=137", Adaptive: true},
+		{ItagNo: 139, Type: "audio/mp4", URL: "https://r1.googlevideo.com/videoplayback?itag=139", Adaptive: true, Bitrate: 48000},
+		{ItagNo: 140, Type: "audio/mp4", URL: "https://r1.googlevideo.com/videoplayback?itag=140", Adaptive: true, Bitrate: 128000},
+	}
+
+	muxed, err := y.ResolvePlayable(DownloadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if muxed.Format.ItagNo != 18 || muxed.URL != y.StreamList[0].URL || muxed.AudioFormat != nil {
+		t.Errorf("ResolvePlayable() = %+v, want the muxed stream alone", muxed)
+	}
+	if muxed.Header.Get("User-Agent") != userAgent {
+		t.Errorf("User-Agent = %q, want %q", muxed.Header.Get("User-Agent"), userAgent)
+	}
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse("https://r1.googlevideo.com/")
+	jar.SetCookies(u, []*http.Cookie{{Name: "CONSENT", Value: "YES+1"}})
+	y.HTTPClient = &http.Client{Jar: jar}
+
+	video, err := y.ResolvePlayable(DownloadOptions{ItagNo: 137})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if video.AudioFormat == nil || video.AudioFormat.ItagNo != 140 || video.AudioURL != y.StreamList[3].URL {
+		t.Errorf("ResolvePlayable() audio = %+v, want the best audio stream", video.AudioFormat)
+	}
+	if got := video.Header.Get("Cookie"); got != "CONSENT=YES+1" {
+		t.Errorf("Cookie = %q, want the cookies of the jar", got)
+	}
+
+	if _, err := y.ResolvePlayable(DownloadOptions{ItagNo: 22}); err != ErrItagNotFound {
+		t.Errorf("ResolvePlayable() error = %v, want ErrItagNotFound", err)
+	}
+}
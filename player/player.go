@@ -0,0 +1,194 @@
+/*
+Package player hands the streams resolved by youtube.Youtube.ResolvePlayable
+over to a local media player, mpv, VLC or QuickTime, to watch a video without
+saving it.
+
+The players are looked up in PATH, then where they are usually installed on
+the OS, such as /Applications on macOS or Program Files on Windows.
+*/
+package player
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+
+	"github.com/kkdai/youtube"
+)
+
+// Kind is the command line dialect of a player.
+type Kind string
+
+// The supported players.
+const (
+	MPV Kind = "mpv"
+	// VLC sends no header but the user agent.
+	VLC Kind = "vlc"
+	// QuickTime, on macOS only, sends no header and plays no separate audio
+	// stream, it suits the muxed streams.
+	QuickTime Kind = "quicktime"
+)
+
+// ErrNotFound is returned by New when no supported player is installed.
+var ErrNotFound = errors.New("no supported player found")
+
+// preference is the order the players are looked up in, mpv is preferred as
+// it sends every header of the stream.
+var preference = []Kind{MPV, VLC, QuickTime}
+
+// installPaths are where the players are installed when they are not in PATH, by OS.
+var installPaths = map[string]map[Kind][]string{
+	"darwin": {
+		MPV:       {"/opt/homebrew/bin/mpv", "/usr/local/bin/mpv", "/Applications/mpv.app/Contents/MacOS/mpv"},
+		VLC:       {"/Applications/VLC.app/Contents/MacOS/VLC"},
+		QuickTime: {"/System/Applications/QuickTime Player.app", "/Applications/QuickTime Player.app"},
+	},
+	"windows": {
+		MPV: {`C:\Program Files\mpv\mpv.exe`},
+		VLC: {`C:\Program Files\VideoLAN\VLC\vlc.exe`, `C:\Program Files (x86)\VideoLAN\VLC\vlc.exe`},
+	},
+	"linux": {
+		MPV: {"/snap/bin/mpv", "/var/lib/flatpak/exports/bin/io.mpv.Mpv"},
+		VLC: {"/snap/bin/vlc", "/var/lib/flatpak/exports/bin/org.videolan.VLC"},
+	},
+}
+
+// profiles are the default arguments of the players: mpv opens its window
+// while buffering, VLC exits at the end of the video rather than idling, and
+// QuickTime, started through open, is waited for.
+var profiles = map[Kind][]string{
+	MPV:       {"--force-window=immediate"},
+	VLC:       {"--play-and-exit"},
+	QuickTime: {"-W"},
+}
+
+// goos, lookPath and exists are replaced in tests.
+var (
+	goos     = runtime.GOOS
+	lookPath = exec.LookPath
+	exists   = func(path string) bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}
+)
+
+// Player runs the player binary found at Path, or the application bundle of
+// QuickTime through open.
+type Player struct {
+	Kind Kind
+	Path string
+	// Profile are arguments passed before the ones playing the stream.
+	Profile []string
+}
+
+// Options selects and configures the player returned by NewWithOptions.
+type Options struct {
+	// Kind selects the player, the first one installed is used when empty.
+	Kind Kind
+	// Path is the player binary, it is looked up when empty.
+	Path string
+	// Profile replaces the default arguments of the player when not nil.
+	Profile []string
+}
+
+// New returns the first supported player installed, with its default profile.
+func New() (*Player, error) {
+	return NewWithOptions(Options{})
+}
+
+// NewWithOptions returns the player selected by opts.
+func NewWithOptions(opts Options) (*Player, error) {
+	kinds := preference
+	if opts.Kind != "" {
+		kinds = []Kind{opts.Kind}
+	}
+	for _, kind := range kinds {
+		path := opts.Path
+		if path == "" {
+			path = find(kind)
+		}
+		if path == "" {
+			continue
+		}
+		profile := opts.Profile
+		if profile == nil {
+			profile = profiles[kind]
+		}
+		return &Player{Kind: kind, Path: path, Profile: profile}, nil
+	}
+	return nil, ErrNotFound
+}
+
+// find returns the path of the player, or an empty string when it is not installed.
+func find(kind Kind) string {
+	if kind == QuickTime && goos != "darwin" {
+		return ""
+	}
+	if kind != QuickTime {
+		if path, err := lookPath(string(kind)); err == nil {
+			return path
+		}
+	}
+	for _, path := range installPaths[goos][kind] {
+		if exists(path) {
+			return path
+		}
+	}
+	return ""
+}
+
+// Play runs the player on s until it exits or ctx is done.
+func (p *Player) Play(ctx context.Context, s *youtube.PlayableStream) error {
+	name := p.Path
+	if p.Kind == QuickTime {
+		name = "open"
+	}
+	cmd := exec.CommandContext(ctx, name, p.Args(s)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd.Run()
+}
+
+// Args returns the command line arguments playing s, along with its separate
+// audio stream when there is one.
+func (p *Player) Args(s *youtube.PlayableStream) []string {
+	args := append([]string(nil), p.Profile...)
+	switch p.Kind {
+	case QuickTime:
+		// the arguments of open, QuickTime can't be given headers
+		args = append(args, "-a", p.Path)
+	case VLC:
+		// VLC has no option for arbitrary headers, only the user agent is sent
+		if userAgent := s.Header.Get("User-Agent"); userAgent != "" {
+			args = append(args, "--http-user-agent="+userAgent)
+		}
+		if s.AudioURL != "" {
+			args = append(args, "--input-slave="+s.AudioURL)
+		}
+	default:
+		for _, name := range sortedNames(s.Header) {
+			if name == "User-Agent" {
+				args = append(args, "--user-agent="+s.Header.Get(name))
+				continue
+			}
+			// the append form takes the value as is, commas included
+			args = append(args, "--http-header-fields-append="+name+": "+s.Header.Get(name))
+		}
+		if s.AudioURL != "" {
+			args = append(args, "--audio-file="+s.AudioURL)
+		}
+		args = append(args, "--")
+	}
+	return append(args, s.URL)
+}
+
+func sortedNames(header map[string][]string) []string {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
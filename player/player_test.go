@@ -0,0 +1,97 @@
+package player
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/kkdai/youtube"
+)
+
+func TestPlayer_Args(t *testing.T) {
+	header := http.Header{}
+	header.Set("User-Agent", "Mozilla/5.0")
+	header.Set("Cookie", "CONSENT=YES+1; PREF=f1=50000000,f6=8")
+	muxed := &youtube.PlayableStream{URL: "https://example.com/18", Header: header}
+	adaptive := &youtube.PlayableStream{URL: "https://example.com/137", AudioURL: "https://example.com/140", Header: header}
+
+	tests := []struct {
+		name   string
+		kind   Kind
+		stream *youtube.PlayableStream
+		want   []string
+	}{
+		{"mpv muxed", MPV, muxed, []string{
+			"--http-header-fields-append=Cookie: CONSENT=YES+1; PREF=f1=50000000,f6=8",
+			"--user-agent=Mozilla/5.0",
+			"--",
+			"https://example.com/18",
+		}},
+		{"mpv adaptive", MPV, adaptive, []string{
+			"--http-header-fields-append=Cookie: CONSENT=YES+1; PREF=f1=50000000,f6=8",
+			"--user-agent=Mozilla/5.0",
+			"--audio-file=https://example.com/140",
+			"--",
+			"https://example.com/137",
+		}},
+		{"vlc adaptive", VLC, adaptive, []string{
+			"--http-user-agent=Mozilla/5.0",
+			"--input-slave=https://example.com/140",
+			"https://example.com/137",
+		}},
+		{"quicktime muxed", QuickTime, muxed, []string{
+			"-a", "quicktime",
+			"https://example.com/18",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Player{Kind: tt.kind, Path: string(tt.kind)}
+			if got := p.Args(tt.stream); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Args() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewWithOptions(t *testing.T) {
+	defer func(os string, look func(string) (string, error), stat func(string) bool) {
+		goos, lookPath, exists = os, look, stat
+	}(goos, lookPath, exists)
+
+	// only VLC and QuickTime are installed, in their macOS folders
+	goos = "darwin"
+	lookPath = func(file string) (string, error) { return "", errors.New("not found") }
+	installed := map[string]bool{
+		"/Applications/VLC.app/Contents/MacOS/VLC":  true,
+		"/System/Applications/QuickTime Player.app": true,
+	}
+	exists = func(path string) bool { return installed[path] }
+
+	tests := []struct {
+		name    string
+		opts    Options
+		want    *Player
+		wantErr error
+	}{
+		{"first installed", Options{}, &Player{Kind: VLC, Path: "/Applications/VLC.app/Contents/MacOS/VLC", Profile: []string{"--play-and-exit"}}, nil},
+		{"selected", Options{Kind: QuickTime}, &Player{Kind: QuickTime, Path: "/System/Applications/QuickTime Player.app", Profile: []string{"-W"}}, nil},
+		{"overridden", Options{Kind: MPV, Path: "/opt/mpv", Profile: []string{}}, &Player{Kind: MPV, Path: "/opt/mpv", Profile: []string{}}, nil},
+		{"missing", Options{Kind: MPV}, nil, ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewWithOptions(tt.opts)
+			if err != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewWithOptions() = %+v, %v, want %+v, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+
+	// QuickTime only exists on macOS
+	goos = "linux"
+	if _, err := NewWithOptions(Options{Kind: QuickTime}); err != ErrNotFound {
+		t.Errorf("NewWithOptions() error = %v, want ErrNotFound", err)
+	}
+}
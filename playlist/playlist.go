@@ -0,0 +1,21 @@
+/*
+Package playlist holds the lists of videos enumerated by the client package,
+in the v2 layout described in VERSIONING.md.
+*/
+package playlist
+
+import "github.com/kkdai/youtube"
+
+// Playlist is an enumerated list of videos, such as the tracks of an album.
+type Playlist struct {
+	// ID is the id of the list, such as the browse id of an album.
+	ID     string
+	Title  string
+	Author string
+	Items  []youtube.ListItem
+}
+
+// FromMusicAlbum returns the playlist of the tracks of album.
+func FromMusicAlbum(album *youtube.MusicAlbum) *Playlist {
+	return &Playlist{ID: album.BrowseID, Title: album.Title, Author: album.Artist, Items: album.Items()}
+}
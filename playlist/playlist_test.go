@@ -0,0 +1,26 @@
+package playlist
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kkdai/youtube"
+)
+
+func TestFromMusicAlbum(t *testing.T) {
+	album := &youtube.MusicAlbum{
+		BrowseID: "MPREb_abc",
+		Title:    "Album",
+		Artist:   "Artist",
+		Tracks:   []youtube.MusicTrack{{VideoID: "rFejpH_tAHM", Title: "One", Artist: "Artist"}},
+	}
+	want := &Playlist{
+		ID:     "MPREb_abc",
+		Title:  "Album",
+		Author: "Artist",
+		Items:  []youtube.ListItem{{Index: 0, VideoID: "rFejpH_tAHM", Title: "One", Author: "Artist"}},
+	}
+	if got := FromMusicAlbum(album); !reflect.DeepEqual(got, want) {
+		t.Errorf("FromMusicAlbum() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,70 @@
+package youtube
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// POTokenContext is what a proof of origin token is bound to.
+type POTokenContext string
+
+// The contexts a POTokenProvider is asked a token for.
+const (
+	// POTokenPlayer is the token of the request of the video info.
+	POTokenPlayer POTokenContext = "player"
+	// POTokenGVS is the token of the stream urls, served by the Google Video Servers.
+	POTokenGVS POTokenContext = "gvs"
+)
+
+// POTokenRequest describes the token asked to a POTokenProvider.
+type POTokenRequest struct {
+	Context POTokenContext `json:"context"`
+	VideoID string         `json:"video_id"`
+	// VisitorData identifies the session of the requests, empty before the
+	// session was warmed up, see Session.
+	VisitorData string `json:"visitor_data,omitempty"`
+}
+
+// POTokenProvider supplies the proof of origin tokens youtube asks some
+// clients for, attesting the requests come from a genuine player. Minting
+// them needs a javascript runtime or a browser, so this package doesn't: the
+// tokens are obtained from a provider, usually an external process, such as
+// the ones of github.com/kkdai/youtube/potoken.
+//
+// POToken returns the token of req, or an empty token when the request
+// doesn't need one. The provider caches the tokens it can reuse.
+type POTokenProvider interface {
+	POToken(ctx context.Context, req POTokenRequest) (string, error)
+}
+
+// poToken asks the POTokenProvider of y for a token, an empty one without provider.
+func (y *Youtube) poToken(ctx context.Context, tokenContext POTokenContext) (string, error) {
+	if y.POTokenProvider == nil {
+		return "", nil
+	}
+	token, err := y.POTokenProvider.POToken(ctx, POTokenRequest{
+		Context:     tokenContext,
+		VideoID:     y.VideoID,
+		VisitorData: y.session().getVisitorData(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("po token provider error=%s", err)
+	}
+	return token, nil
+}
+
+// withPOToken returns streamURL carrying token, streamURL when token is empty.
+func withPOToken(streamURL, token string) (string, error) {
+	if token == "" {
+		return streamURL, nil
+	}
+	u, err := url.Parse(streamURL)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	query.Set("pot", token)
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
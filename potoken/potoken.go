@@ -0,0 +1,223 @@
+/*
+Package potoken implements youtube.POTokenProvider with the token providers
+running outside of the program: a command run for each token, or a server
+answering http requests, such as the ones minting the tokens in a headless
+javascript runtime.
+*/
+package potoken
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kkdai/youtube"
+)
+
+var (
+	_ youtube.POTokenProvider = (*Command)(nil)
+	_ youtube.POTokenProvider = (*HTTP)(nil)
+	_ youtube.POTokenProvider = (*Cache)(nil)
+)
+
+// DefaultTTL is how long New keeps the tokens minted for a visitor.
+const DefaultTTL = 6 * time.Hour
+
+// New returns the provider of the command line flags of the programs: the
+// token server at serverURL, or else the command line command split on the
+// spaces, cached for DefaultTTL. It returns nil when both are empty.
+func New(serverURL, command string) youtube.POTokenProvider {
+	var provider youtube.POTokenProvider
+	if serverURL != "" {
+		provider = &HTTP{URL: serverURL}
+	} else if fields := strings.Fields(command); len(fields) > 0 {
+		provider = &Command{Path: fields[0], Args: fields[1:]}
+	} else {
+		return nil
+	}
+	return &Cache{Provider: provider, TTL: DefaultTTL}
+}
+
+// Command runs the program found at Path with Args for each token. The
+// youtube.POTokenRequest is written as json on its standard input, the token
+// is read from its standard output, an empty output is no token.
+type Command struct {
+	Path string
+	Args []string
+}
+
+// POToken implements youtube.POTokenProvider.
+func (c *Command) POToken(ctx context.Context, req youtube.POTokenRequest) (string, error) {
+	input, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	cmd := exec.CommandContext(ctx, c.Path, c.Args...)
+	cmd.Stdin = bytes.NewReader(input)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%s: %s: %s", c.Path, err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// HTTP posts the requests to the token server at URL, such as
+// http://127.0.0.1:4416/get_pot. The body holds the youtube.POTokenRequest
+// along with its content_binding, the identifier the token is bound to: the
+// visitor data for the stream urls, the video id otherwise. The server
+// answers with a json object holding the token in poToken.
+type HTTP struct {
+	URL        string
+	HTTPClient *http.Client
+}
+
+type httpRequest struct {
+	youtube.POTokenRequest
+	ContentBinding string `json:"content_binding"`
+}
+
+type httpAnswer struct {
+	POToken string `json:"poToken"`
+}
+
+// POToken implements youtube.POTokenProvider.
+func (h *HTTP) POToken(ctx context.Context, req youtube.POTokenRequest) (string, error) {
+	binding := req.VideoID
+	if req.Context == youtube.POTokenGVS && req.VisitorData != "" {
+		binding = req.VisitorData
+	}
+	body, err := json.Marshal(httpRequest{POTokenRequest: req, ContentBinding: binding})
+	if err != nil {
+		return "", err
+	}
+	httpReq, err := http.NewRequest(http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpClient := h.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(httpReq.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		answer, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, answer)
+	}
+	var answer httpAnswer
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&answer); err != nil {
+		return "", err
+	}
+	if answer.POToken == "" {
+		return "", errors.New("no poToken in the answer")
+	}
+	return answer.POToken, nil
+}
+
+// Cache keeps the tokens of Provider for TTL, a token minted for a visitor
+// is reused for every video of the session. The tokens bound to a video, of
+// the video info, aren't cached. The concurrent misses of a visitor wait for
+// a single token, the expired tokens are dropped when a new one is stored.
+type Cache struct {
+	Provider youtube.POTokenProvider
+	TTL      time.Duration
+
+	mu     sync.Mutex
+	tokens map[string]cachedToken
+	// minting holds the tokens being minted by visitor data.
+	minting map[string]*mint
+}
+
+type cachedToken struct {
+	token   string
+	expires time.Time
+}
+
+// mint is a token being minted, done is closed once it is. abandoned is set
+// when it failed because the caller minting it gave up.
+type mint struct {
+	done      chan struct{}
+	token     string
+	err       error
+	abandoned bool
+}
+
+// POToken implements youtube.POTokenProvider.
+func (c *Cache) POToken(ctx context.Context, req youtube.POTokenRequest) (string, error) {
+	if req.Context != youtube.POTokenGVS || req.VisitorData == "" {
+		return c.Provider.POToken(ctx, req)
+	}
+	for {
+		c.mu.Lock()
+		cached, ok := c.tokens[req.VisitorData]
+		if ok && time.Now().Before(cached.expires) {
+			c.mu.Unlock()
+			return cached.token, nil
+		}
+		m, ok := c.minting[req.VisitorData]
+		if !ok {
+			m = &mint{done: make(chan struct{}), err: errors.New("the token provider panicked")}
+			if c.minting == nil {
+				c.minting = make(map[string]*mint)
+			}
+			c.minting[req.VisitorData] = m
+			c.mu.Unlock()
+			c.mint(ctx, req, m)
+			return m.token, m.err
+		}
+		c.mu.Unlock()
+
+		select {
+		case <-m.done:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+		if !m.abandoned {
+			return m.token, m.err
+		}
+		// the caller minting the token gave up, this one mints it
+	}
+}
+
+// mint mints the token of req for the callers waiting for m and stores it.
+func (c *Cache) mint(ctx context.Context, req youtube.POTokenRequest, m *mint) {
+	defer func() {
+		c.mu.Lock()
+		delete(c.minting, req.VisitorData)
+		c.mu.Unlock()
+		close(m.done)
+	}()
+	token, err := c.Provider.POToken(ctx, req)
+	m.token, m.err, m.abandoned = token, err, err != nil && ctx.Err() != nil
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	for visitor, cached := range c.tokens {
+		if !now.Before(cached.expires) {
+			delete(c.tokens, visitor)
+		}
+	}
+	if c.tokens == nil {
+		c.tokens = make(map[string]cachedToken)
+	}
+	c.tokens[req.VisitorData] = cachedToken{token: token, expires: now.Add(c.TTL)}
+}
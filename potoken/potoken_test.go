@@ -0,0 +1,140 @@
+package potoken
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kkdai/youtube"
+)
+
+func TestCommand_POToken(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no shell:", err)
+	}
+	c := &Command{Path: sh, Args: []string{"-c", `grep -q '"video_id":"BaW_jenozKc"' && echo "  token-from-stdin  "`}}
+	token, err := c.POToken(context.Background(), youtube.POTokenRequest{Context: youtube.POTokenPlayer, VideoID: "BaW_jenozKc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "token-from-stdin" {
+		t.Errorf("POToken() = %q", token)
+	}
+
+	c = &Command{Path: sh, Args: []string{"-c", "echo broken >&2; exit 3"}}
+	if _, err := c.POToken(context.Background(), youtube.POTokenRequest{}); err == nil {
+		t.Error("POToken() ignored the failure of the command")
+	}
+}
+
+func TestHTTP_POToken(t *testing.T) {
+	var bindings []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req httpRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		bindings = append(bindings, req.ContentBinding)
+		json.NewEncoder(w).Encode(map[string]string{"poToken": "minted-for-" + req.ContentBinding})
+	}))
+	defer server.Close()
+
+	h := &HTTP{URL: server.URL}
+	tests := []struct {
+		req  youtube.POTokenRequest
+		want string
+	}{
+		{youtube.POTokenRequest{Context: youtube.POTokenGVS, VideoID: "BaW_jenozKc", VisitorData: "Cgt2aXNpdG9y"}, "minted-for-Cgt2aXNpdG9y"},
+		{youtube.POTokenRequest{Context: youtube.POTokenGVS, VideoID: "BaW_jenozKc"}, "minted-for-BaW_jenozKc"},
+		{youtube.POTokenRequest{Context: youtube.POTokenPlayer, VideoID: "BaW_jenozKc", VisitorData: "Cgt2aXNpdG9y"}, "minted-for-BaW_jenozKc"},
+	}
+	for _, tt := range tests {
+		got, err := h.POToken(context.Background(), tt.req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("POToken(%+v) = %q, want %q", tt.req, got, tt.want)
+		}
+	}
+
+	c := &Cache{Provider: h, TTL: time.Hour}
+	for i := 0; i < 3; i++ {
+		c.POToken(context.Background(), tests[0].req)
+	}
+	if len(bindings) != 4 {
+		t.Errorf("%d tokens minted, want the visitor token cached", len(bindings))
+	}
+}
+
+// blockingProvider mints a token per visitor once release is closed.
+type blockingProvider struct {
+	release chan struct{}
+	minted  int32
+}
+
+func (p *blockingProvider) POToken(ctx context.Context, req youtube.POTokenRequest) (string, error) {
+	atomic.AddInt32(&p.minted, 1)
+	select {
+	case <-p.release:
+		return "minted-for-" + req.VisitorData, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func TestCache_POToken(t *testing.T) {
+	p := &blockingProvider{release: make(chan struct{})}
+	c := &Cache{Provider: p, TTL: time.Hour}
+	req := youtube.POTokenRequest{Context: youtube.POTokenGVS, VideoID: "BaW_jenozKc", VisitorData: "Cgt2aXNpdG9y"}
+
+	// the caller minting the token gives up, a waiting one takes over
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := make(chan error, 1)
+	go func() {
+		_, err := c.POToken(ctx, req)
+		canceled <- err
+	}()
+	for atomic.LoadInt32(&p.minted) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	const callers = 5
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if token, err := c.POToken(context.Background(), req); err != nil || token != "minted-for-Cgt2aXNpdG9y" {
+				t.Errorf("POToken() = %q, %v", token, err)
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	if err := <-canceled; err != context.Canceled {
+		t.Errorf("canceled POToken() error = %v, want %v", err, context.Canceled)
+	}
+	close(p.release)
+	wg.Wait()
+	if p.minted != 2 {
+		t.Errorf("%d tokens minted, want the concurrent misses to wait for one", p.minted)
+	}
+
+	// the expired tokens are dropped
+	c.TTL = -time.Second
+	c.POToken(context.Background(), youtube.POTokenRequest{Context: youtube.POTokenGVS, VisitorData: "first"})
+	c.POToken(context.Background(), youtube.POTokenRequest{Context: youtube.POTokenGVS, VisitorData: "second"})
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.tokens["first"]; ok || len(c.tokens) != 2 {
+		t.Errorf("%d tokens cached, want the expired ones dropped", len(c.tokens))
+	}
+}
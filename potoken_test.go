@@ -0,0 +1,68 @@
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+type fakeTokenProvider struct {
+	requests []POTokenRequest
+	err      error
+}
+
+func (p *fakeTokenProvider) POToken(ctx context.Context, req POTokenRequest) (string, error) {
+	p.requests = append(p.requests, req)
+	return string(req.Context) + "-token", p.err
+}
+
+func TestYoutube_POTokenProvider(t *testing.T) {
+	var infoToken string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		infoToken = r.URL.Query().Get("pot")
+		playerResponse, _ := json.Marshal(map[string]interface{}{
+			"playabilityStatus": map[string]string{"status": "OK"},
+			"videoDetails":      map[string]string{"title": "Simplicity is Complicated", "author": "dotconferences"},
+			"streamingData": map[string]interface{}{
+				"formats": []map[string]interface{}{
+					{"itag": 18, "url": "https://r1.googlevideo.com/videoplayback?itag=18", "mimeType": "video/mp4", "quality": "medium"},
+				},
+			},
+		})
+		w.Write([]byte(url.Values{"status": {"ok"}, "player_response": {string(playerResponse)}}.Encode()))
+	}))
+	defer server.Close()
+	defer func(url string) { baseURL = url }(baseURL)
+	baseURL = server.URL
+
+	provider := &fakeTokenProvider{}
+	y, err := NewYoutubeWithOptions(Options{POTokenProvider: provider})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := y.DecodeURLWithContext(context.Background(), "rFejpH_tAHM"); err != nil {
+		t.Fatal(err)
+	}
+	if infoToken != "player-token" {
+		t.Errorf("video info requested with pot=%q", infoToken)
+	}
+	streamURL, _ := url.Parse(y.StreamList[0].URL)
+	if got := streamURL.Query(); got.Get("pot") != "gvs-token" || got.Get("itag") != "18" {
+		t.Errorf("stream url = %s, want the gvs token", y.StreamList[0].URL)
+	}
+	for _, req := range provider.requests {
+		if req.VideoID != "rFejpH_tAHM" {
+			t.Errorf("token asked for video %q", req.VideoID)
+		}
+	}
+
+	provider.err = errors.New("provider down")
+	y.VideoID = "BaW_jenozKc"
+	if err := y.decode(context.Background(), true); err == nil {
+		t.Error("decode() ignored the failure of the provider")
+	}
+}
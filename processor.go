@@ -0,0 +1,24 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package youtube
+
+import (
+	"context"
+)
+
+// Processor post-processes downloaded files. Implementations relying on heavy
+// dependencies (external tools, cgo libraries) live in their own packages so
+// this package stays dependency-light, see github.com/kkdai/youtube/ffmpeg.
+type Processor interface {
+	// Merge muxes the video stream of videoFile and the audio stream of audioFile into destFile.
+	Merge(ctx context.Context, videoFile, audioFile, destFile string) error
+}
+
+// AudioConverter converts audio files, it is implemented by the processors
+// able to re-encode, such as github.com/kkdai/youtube/ffmpeg.
+type AudioConverter interface {
+	// ConvertAudio decodes the audio of srcFile and writes it to destFile as
+	// 16 bits PCM WAV with the given sample rate and number of channels.
+	ConvertAudio(ctx context.Context, srcFile, destFile string, sampleRate, channels int) error
+}
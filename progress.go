@@ -0,0 +1,147 @@
+package youtube
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ProgressPhase is the phase of the work a Progress reports on.
+type ProgressPhase string
+
+// The phases reported through Youtube.OnProgress.
+const (
+	// PhaseEnumerate is the listing of the videos of a playlist, an album or a channel.
+	PhaseEnumerate ProgressPhase = "enumerate"
+	// PhaseDownload is the download of a stream, also reported on DownloadPercent.
+	PhaseDownload ProgressPhase = "download"
+)
+
+// Progress is a progress event passed to Youtube.OnProgress, the fields
+// set depend on its Phase.
+type Progress struct {
+	Phase ProgressPhase
+	// Percent is the percentage of the download, for PhaseDownload.
+	Percent int64
+	// Pages is the number of pages fetched and Items the number of videos
+	// discovered so far, for PhaseEnumerate. Total is the number of videos
+	// announced by the list, 0 when unknown.
+	Pages int
+	Items int
+	Total int
+	// ETA is the estimated time left, 0 when unknown.
+	ETA time.Duration
+}
+
+// Budget bounds the listing of a large list to preview it, a zero field is
+// no bound. The items are listed up to MaxItems, from up to MaxPages pages.
+type Budget struct {
+	MaxItems int
+	MaxPages int
+}
+
+func (b Budget) validate() ErrInvalidOptions {
+	var errs ErrInvalidOptions
+	if b.MaxItems < 0 {
+		errs = append(errs, ErrInvalidOption{Option: "Budget.MaxItems", Reason: "must not be negative"})
+	}
+	if b.MaxPages < 0 {
+		errs = append(errs, ErrInvalidOption{Option: "Budget.MaxPages", Reason: "must not be negative"})
+	}
+	return errs
+}
+
+// itemCountPattern matches the item counts of the list headers, such as "1,024 songs".
+var itemCountPattern = regexp.MustCompile(`([0-9][0-9,.]*) (?:songs?|tracks?|videos?|episodes?)\b`)
+
+// enumeration reports the progress of the listing of a paged list.
+type enumeration struct {
+	y       *Youtube
+	started time.Time
+	pages   int
+	items   int
+	total   int
+}
+
+func (y *Youtube) newEnumeration() *enumeration {
+	return &enumeration{y: y, started: time.Now()}
+}
+
+// page records a page fetched along with the number of items discovered so
+// far, total is the number of items announced, 0 when unknown.
+func (e *enumeration) page(items, total int) {
+	e.pages++
+	e.items = items
+	if total > 0 {
+		e.total = total
+	}
+	if e.y.OnProgress == nil {
+		return
+	}
+	progress := Progress{Phase: PhaseEnumerate, Pages: e.pages, Items: e.items, Total: e.total}
+	if e.items > 0 && e.total > e.items {
+		perItem := time.Since(e.started) / time.Duration(e.items)
+		progress.ETA = perItem * time.Duration(e.total-e.items)
+	}
+	e.y.OnProgress(progress)
+}
+
+// itemCount returns the number of items announced by text, 0 when none is.
+func itemCount(text string) int {
+	match := itemCountPattern.FindStringSubmatch(text)
+	if match == nil {
+		return 0
+	}
+	count, _ := strconv.Atoi(strings.NewReplacer(",", "", ".", "").Replace(match[1]))
+	return count
+}
+
+// aggregateProgress spreads the progress of successive downloads over a
+// single percentage, each download weighting its share of the total size, or
+// an equal share when a size is unknown.
+type aggregateProgress struct {
+	weights []float64
+	current int
+	done    float64
+}
+
+func newAggregateProgress(streams []stream) *aggregateProgress {
+	var total int64
+	for _, s := range streams {
+		if s.ContentLength <= 0 {
+			total = 0
+			break
+		}
+		total += s.ContentLength
+	}
+	weights := make([]float64, len(streams))
+	for i, s := range streams {
+		if total > 0 {
+			weights[i] = float64(s.ContentLength) / float64(total)
+		} else {
+			weights[i] = 1 / float64(len(streams))
+		}
+	}
+	return &aggregateProgress{weights: weights}
+}
+
+// percent returns the overall percentage once the current download reached
+// fraction, p may be nil for a single download.
+func (p *aggregateProgress) percent(fraction float64) float64 {
+	if p == nil {
+		return fraction * 100
+	}
+	if p.current >= len(p.weights) {
+		return 100
+	}
+	return (p.done + p.weights[p.current]*fraction) * 100
+}
+
+// next moves to the following download.
+func (p *aggregateProgress) next() {
+	if p.current < len(p.weights) {
+		p.done += p.weights[p.current]
+		p.current++
+	}
+}
@@ -0,0 +1,39 @@
+package youtube
+
+import (
+	"testing"
+	"time"
+)
+
+func TestItemCount(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"3 songs • 9 minutes", 3},
+		{"Album • 1 song", 1},
+		{"Playlist • 1,024 videos", 1024},
+		{"Album • Rob Pike • 2021", 0},
+	}
+	for _, tt := range tests {
+		if got := itemCount(tt.text); got != tt.want {
+			t.Errorf("itemCount(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestEnumeration_ETA(t *testing.T) {
+	var last Progress
+	y := NewYoutube(false)
+	y.OnProgress = func(p Progress) { last = p }
+	e := y.newEnumeration()
+	e.started = time.Now().Add(-10 * time.Second)
+	e.page(100, 300)
+	if last.ETA < 19*time.Second || last.ETA > 21*time.Second {
+		t.Errorf("ETA = %s after 10s for 100 of 300 items, want 20s", last.ETA)
+	}
+	e.page(300, 0)
+	if last.Total != 300 || last.ETA != 0 {
+		t.Errorf("last progress = %+v, want the total kept and no ETA", last)
+	}
+}
@@ -0,0 +1,378 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package youtube
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// JobStatus is the state of a job of a Queue.
+type JobStatus string
+
+// The states a job goes through.
+const (
+	JobQueued  JobStatus = "queued"
+	JobRunning JobStatus = "running"
+	JobDone    JobStatus = "done"
+	JobFailed  JobStatus = "failed"
+	// JobCancelled jobs were stopped on purpose, see CancelReason.
+	JobCancelled JobStatus = "cancelled"
+)
+
+// CancelReason tells why a job was cancelled, a cancelled job didn't fail.
+type CancelReason string
+
+// The reasons a job is cancelled for.
+const (
+	// CancelUser is for jobs cancelled at the request of a user.
+	CancelUser CancelReason = "user"
+	// CancelShutdown is for the running jobs aborted when the queue stops.
+	CancelShutdown CancelReason = "shutdown"
+	// CancelQuota is for jobs cancelled by the caller enforcing a quota.
+	CancelQuota CancelReason = "quota"
+	// CancelDeadline is for the running jobs aborted by the deadline of the queue.
+	CancelDeadline CancelReason = "deadline"
+)
+
+// Job is a download of a Queue.
+type Job struct {
+	ID       int             `json:"id"`
+	URL      string          `json:"url"`
+	Options  DownloadOptions `json:"options"`
+	Status   JobStatus       `json:"status"`
+	Title    string          `json:"title,omitempty"`
+	Author   string          `json:"author,omitempty"`
+	Duration time.Duration   `json:"duration,omitempty"`
+	Path     string          `json:"path,omitempty"`
+	// Paths holds the files of every format when several itags were requested, Path is the first one.
+	Paths []string `json:"paths,omitempty"`
+	// Sync is what was done with the video when the queue has an archive.
+	Sync  SyncAction `json:"sync,omitempty"`
+	Size  int64      `json:"size,omitempty"`
+	Error string     `json:"error,omitempty"`
+	// CancelReason is set instead of Error when the job was cancelled.
+	CancelReason CancelReason `json:"cancel_reason,omitempty"`
+	AddedAt      time.Time    `json:"added_at"`
+	StartedAt    time.Time    `json:"started_at"`
+	FinishedAt   time.Time    `json:"finished_at"`
+}
+
+// queueMaxURLAge is the default Options.MaxURLAge of a Queue, a retried
+// download may start long after its video was decoded.
+const queueMaxURLAge = 3 * time.Hour
+
+// Queue downloads the videos added to it with a fixed number of workers. The
+// workers share one http client, one FormatHealth and one Session.
+type Queue struct {
+	options Options
+	workers int
+
+	mu        sync.Mutex
+	jobs      []*Job
+	pending   []*Job
+	closed    bool
+	wake      chan struct{}
+	notifiers []Notifier
+	// cancels stops the running jobs by id.
+	cancels map[int]context.CancelFunc
+
+	// run processes a job, it is replaced in tests.
+	run func(ctx context.Context, job *Job) (string, error)
+}
+
+// NewQueue returns a Queue downloading with workers parallel downloads,
+// each of them done by a Youtube created with opts.
+func NewQueue(opts Options, workers int) (*Queue, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if workers <= 0 {
+		return nil, ErrInvalidOptions{ErrInvalidOption{Option: "workers", Reason: "must be positive"}}
+	}
+	if opts.Session == nil {
+		opts.Session = NewSession()
+	}
+	if opts.HTTPClient == nil {
+		httpClient, err := NewBatchHTTPClient(opts.Socks5Proxy)
+		if err != nil {
+			return nil, err
+		}
+		httpClient.Jar = opts.Session.jar
+		opts.HTTPClient = httpClient
+	}
+	if opts.Health == nil {
+		opts.Health = NewFormatHealth()
+	}
+	if opts.MaxURLAge == 0 {
+		opts.MaxURLAge = queueMaxURLAge
+	}
+	q := &Queue{options: opts, workers: workers, wake: make(chan struct{}, 1), cancels: make(map[int]context.CancelFunc)}
+	q.run = q.download
+	return q, nil
+}
+
+// Add queues the download of the video at url.
+func (q *Queue) Add(url string, opts DownloadOptions) (Job, error) {
+	if err := opts.Validate(); err != nil {
+		return Job{}, err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return Job{}, ErrQueueClosed
+	}
+	job := &Job{
+		ID:      len(q.jobs) + 1,
+		URL:     url,
+		Options: opts,
+		Status:  JobQueued,
+		AddedAt: time.Now(),
+	}
+	q.jobs = append(q.jobs, job)
+	q.pending = append(q.pending, job)
+	q.signal()
+	return *job, nil
+}
+
+// AddNotifier registers n to be notified when a job finishes or fails.
+func (q *Queue) AddNotifier(n Notifier) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.notifiers = append(q.notifiers, n)
+}
+
+// Jobs returns a snapshot of every job of the queue.
+func (q *Queue) Jobs() []Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	jobs := make([]Job, 0, len(q.jobs))
+	for _, job := range q.jobs {
+		jobs = append(jobs, *job)
+	}
+	return jobs
+}
+
+// Job returns a snapshot of the job id.
+func (q *Queue) Job(id int) (Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if id <= 0 || id > len(q.jobs) {
+		return Job{}, false
+	}
+	return *q.jobs[id-1], true
+}
+
+// Cancel cancels the job id for reason: a queued job is dropped, a running
+// one is stopped. It returns ErrJobNotFound for an unknown job and
+// ErrJobFinished for a job already finished.
+func (q *Queue) Cancel(id int, reason CancelReason) error {
+	q.mu.Lock()
+	if id <= 0 || id > len(q.jobs) {
+		q.mu.Unlock()
+		return ErrJobNotFound
+	}
+	job := q.jobs[id-1]
+	switch job.Status {
+	case JobQueued:
+		for i, pending := range q.pending {
+			if pending == job {
+				q.pending = append(q.pending[:i], q.pending[i+1:]...)
+				break
+			}
+		}
+		job.Status = JobCancelled
+		job.CancelReason = reason
+		job.FinishedAt = time.Now()
+		n, notifiers := job.notification(context.Canceled), q.notifiers
+		q.mu.Unlock()
+		q.notify(notifiers, n)
+		return nil
+	case JobRunning:
+		// the job is marked cancelled once its download returned
+		job.CancelReason = reason
+		q.cancels[id]()
+		q.mu.Unlock()
+		return nil
+	}
+	q.mu.Unlock()
+	return ErrJobFinished
+}
+
+// Run downloads the queued jobs until ctx is done, then it waits for the
+// running jobs to be aborted and closes the queue.
+func (q *Queue) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for i := 0; i < q.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.work(ctx)
+		}()
+	}
+	<-ctx.Done()
+	wg.Wait()
+
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+	return ctx.Err()
+}
+
+// signal wakes a worker up, q.mu must be held.
+func (q *Queue) signal() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (q *Queue) work(ctx context.Context) {
+	for ctx.Err() == nil {
+		job, jobCtx := q.next(ctx)
+		if job == nil {
+			select {
+			case <-q.wake:
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+		q.process(ctx, jobCtx, job)
+	}
+}
+
+// next pops the next queued job and returns it with the context it runs
+// with, or returns nil when there is none. The job can be cancelled as soon
+// as it is running.
+func (q *Queue) next(ctx context.Context) (*Job, context.Context) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.pending) == 0 {
+		return nil, nil
+	}
+	i := q.options.Jitter.pick(len(q.pending))
+	job := q.pending[i]
+	q.pending = append(q.pending[:i], q.pending[i+1:]...)
+	job.Status = JobRunning
+	job.StartedAt = time.Now()
+	jobCtx, cancel := context.WithCancel(ctx)
+	q.cancels[job.ID] = cancel
+	if len(q.pending) > 0 {
+		q.signal()
+	}
+	return job, jobCtx
+}
+
+// process runs job with jobCtx, the context next returned with it.
+func (q *Queue) process(ctx, jobCtx context.Context, job *Job) {
+	path, err := q.run(jobCtx, job)
+
+	q.mu.Lock()
+	q.cancels[job.ID]()
+	delete(q.cancels, job.ID)
+	job.FinishedAt = time.Now()
+	job.Path = path
+	if err != nil && job.CancelReason == "" {
+		switch ctx.Err() {
+		case context.Canceled:
+			job.CancelReason = CancelShutdown
+		case context.DeadlineExceeded:
+			job.CancelReason = CancelDeadline
+		}
+	}
+	switch {
+	case err == nil:
+		// a cancellation coming too late doesn't undo the download
+		job.CancelReason = ""
+		job.Status = JobDone
+		paths := job.Paths
+		if len(paths) == 0 {
+			paths = []string{path}
+		}
+		for _, path := range paths {
+			if fi, err := os.Stat(path); err == nil {
+				job.Size += fi.Size()
+			}
+		}
+	case job.CancelReason != "":
+		job.Status = JobCancelled
+	default:
+		job.Status = JobFailed
+		job.Error = err.Error()
+	}
+	n, notifiers := job.notification(err), q.notifiers
+	q.mu.Unlock()
+
+	q.notify(notifiers, n)
+}
+
+// notification returns the notification of the outcome of job, q.mu must be held.
+func (job *Job) notification(err error) Notification {
+	return Notification{
+		URL:          job.URL,
+		Title:        job.Title,
+		Author:       job.Author,
+		Duration:     job.Duration,
+		Size:         job.Size,
+		Path:         job.Path,
+		Err:          err,
+		CancelReason: job.CancelReason,
+	}
+}
+
+func (q *Queue) notify(notifiers []Notifier, n Notification) {
+	for _, notifier := range notifiers {
+		ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+		if err := notifier.Notify(ctx, n); err != nil {
+			q.log(fmt.Sprintf("notification failed, err=%s", err))
+		}
+		cancel()
+	}
+}
+
+func (q *Queue) download(ctx context.Context, job *Job) (string, error) {
+	y, err := NewYoutubeWithOptions(q.options)
+	if err != nil {
+		return "", err
+	}
+	if err := y.DecodeURLWithContext(ctx, job.URL); err != nil {
+		return "", err
+	}
+	if info := y.GetItagInfo(); info != nil {
+		q.mu.Lock()
+		job.Title, job.Author = info.Title, info.Author
+		job.Duration = y.duration()
+		q.mu.Unlock()
+	}
+	if len(job.Options.Itags) > 0 {
+		paths, err := y.downloadFormats(ctx, job.Options)
+		q.mu.Lock()
+		job.Paths = paths
+		q.mu.Unlock()
+		if len(paths) == 0 {
+			return "", err
+		}
+		return paths[0], err
+	}
+	if y.Archive != nil {
+		entry, action, err := y.Sync(ctx, job.Options)
+		q.mu.Lock()
+		job.Sync = action
+		q.mu.Unlock()
+		return entry.Path, err
+	}
+	return y.download(ctx, job.Options)
+}
+
+func (q *Queue) log(logText string) {
+	if q.options.DebugMode {
+		log.Println(logText)
+	}
+}
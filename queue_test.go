@@ -0,0 +1,191 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package youtube
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// notificationRecorder records the notifications it receives.
+type notificationRecorder struct {
+	mu            sync.Mutex
+	notifications []Notification
+}
+
+func (r *notificationRecorder) Notify(ctx context.Context, n Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notifications = append(r.notifications, n)
+	return nil
+}
+
+// waitJobs waits until every job of q is finished.
+func waitJobs(t *testing.T, q *Queue) []Job {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		jobs := q.Jobs()
+		finished := true
+		for _, job := range jobs {
+			if job.Status == JobQueued || job.Status == JobRunning {
+				finished = false
+			}
+		}
+		if finished {
+			return jobs
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("jobs didn't finish in time")
+	return nil
+}
+
+func TestQueue(t *testing.T) {
+	if _, err := NewQueue(Options{}, 0); err == nil {
+		t.Error("a queue without worker should be rejected")
+	}
+
+	q, err := NewQueue(Options{}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.run = func(ctx context.Context, job *Job) (string, error) {
+		if job.URL == "fail" {
+			return "", errors.New("decoding failed")
+		}
+		return "/tmp/" + job.URL + ".mp4", nil
+	}
+
+	recorder := &notificationRecorder{}
+	q.AddNotifier(recorder)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- q.Run(ctx) }()
+
+	for _, url := range []string{"first", "fail", "third"} {
+		if _, err := q.Add(url, DownloadOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := q.Add("invalid", DownloadOptions{RateLimit: -1}); err == nil {
+		t.Error("invalid download options should be rejected")
+	}
+
+	jobs := waitJobs(t, q)
+	want := []JobStatus{JobDone, JobFailed, JobDone}
+	for i, job := range jobs {
+		if job.Status != want[i] {
+			t.Errorf("job %d status = %s, want %s", job.ID, job.Status, want[i])
+		}
+	}
+	if job, ok := q.Job(3); !ok || job.Path != "/tmp/third.mp4" {
+		t.Errorf("Job(3) = %+v, %v", job, ok)
+	}
+
+	recorder.mu.Lock()
+	failures := 0
+	for _, n := range recorder.notifications {
+		if n.Err != nil {
+			failures++
+		}
+	}
+	if len(recorder.notifications) != 3 || failures != 1 {
+		t.Errorf("got %d notifications with %d failures, want 3 with 1 failure", len(recorder.notifications), failures)
+	}
+	recorder.mu.Unlock()
+
+	cancel()
+	<-done
+	if _, err := q.Add("late", DownloadOptions{}); err != ErrQueueClosed {
+		t.Errorf("Add() error = %v, want %v", err, ErrQueueClosed)
+	}
+}
+
+func TestQueue_Cancel(t *testing.T) {
+	q, err := NewQueue(Options{}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := make(chan struct{}, 3)
+	q.run = func(ctx context.Context, job *Job) (string, error) {
+		started <- struct{}{}
+		<-ctx.Done()
+		return "", ctx.Err()
+	}
+	recorder := &notificationRecorder{}
+	q.AddNotifier(recorder)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- q.Run(ctx) }()
+
+	for _, url := range []string{"running", "queued", "shutdown"} {
+		if _, err := q.Add(url, DownloadOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	<-started
+	if err := q.Cancel(2, CancelQuota); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Cancel(1, CancelUser); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	cancel()
+	<-done
+
+	want := []struct {
+		status JobStatus
+		reason CancelReason
+	}{{JobCancelled, CancelUser}, {JobCancelled, CancelQuota}, {JobCancelled, CancelShutdown}}
+	for i, job := range q.Jobs() {
+		if job.Status != want[i].status || job.CancelReason != want[i].reason || job.Error != "" {
+			t.Errorf("job %d = %s %q %q, want %s %q", job.ID, job.Status, job.CancelReason, job.Error, want[i].status, want[i].reason)
+		}
+	}
+	recorder.mu.Lock()
+	for _, n := range recorder.notifications {
+		if n.CancelReason == "" {
+			t.Errorf("notification of %s should carry the cancel reason", n.URL)
+		}
+	}
+	recorder.mu.Unlock()
+
+	if err := q.Cancel(1, CancelUser); err != ErrJobFinished {
+		t.Errorf("Cancel() error = %v, want ErrJobFinished", err)
+	}
+	if err := q.Cancel(4, CancelUser); err != ErrJobNotFound {
+		t.Errorf("Cancel() error = %v, want ErrJobNotFound", err)
+	}
+}
+
+func TestQueue_CancelStarting(t *testing.T) {
+	q, err := NewQueue(Options{}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.run = func(ctx context.Context, job *Job) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}
+	if _, err := q.Add("starting", DownloadOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// cancelled once running, before its download started
+	job, jobCtx := q.next(context.Background())
+	if err := q.Cancel(job.ID, CancelUser); err != nil {
+		t.Fatal(err)
+	}
+	q.process(context.Background(), jobCtx, job)
+
+	if got, _ := q.Job(job.ID); got.Status != JobCancelled || got.CancelReason != CancelUser {
+		t.Errorf("job = %s %q, want %s %q", got.Status, got.CancelReason, JobCancelled, CancelUser)
+	}
+}
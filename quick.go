@@ -0,0 +1,40 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package youtube
+
+import "context"
+
+// quickRetries is the number of retries of QuickDownload.
+const quickRetries = 3
+
+// QuickDownload downloads the video at url with sensible defaults: the best
+// stream with both audio and video, saved in the default output directory,
+// resuming an interrupted download of the same video and retrying transient
+// failures. It returns the path of the downloaded file.
+func QuickDownload(ctx context.Context, url string) (string, error) {
+	y := NewYoutube(false)
+	if err := y.DecodeURLWithContext(ctx, url); err != nil {
+		return "", err
+	}
+	opts := DownloadOptions{Resume: true, Retries: quickRetries}
+	if best, ok := y.bestMuxedStream(); ok {
+		opts.ItagNo = best.ItagNo
+	}
+	return y.download(ctx, opts)
+}
+
+// bestMuxedStream returns the muxed stream with the highest resolution, then bitrate.
+func (y *Youtube) bestMuxedStream() (stream, bool) {
+	var best stream
+	found := false
+	for _, s := range y.StreamList {
+		if s.Adaptive {
+			continue
+		}
+		if !found || s.Height > best.Height || (s.Height == best.Height && s.Bitrate > best.Bitrate) {
+			best, found = s, true
+		}
+	}
+	return best, found
+}
@@ -0,0 +1,36 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package youtube
+
+import (
+	"io"
+	"time"
+)
+
+// rateLimitedReader slows reads down to at most limit bytes per second.
+type rateLimitedReader struct {
+	r     io.Reader
+	limit int64
+	start time.Time
+	read  int64
+}
+
+func newRateLimitedReader(r io.Reader, limit int64) *rateLimitedReader {
+	return &rateLimitedReader{r: r, limit: limit, start: time.Now()}
+}
+
+func (r *rateLimitedReader) Read(p []byte) (int, error) {
+	if int64(len(p)) > r.limit {
+		p = p[:r.limit]
+	}
+	n, err := r.r.Read(p)
+	r.read += int64(n)
+
+	// sleep until the bytes read so far fit in the allowed rate
+	expected := time.Duration(float64(r.read) / float64(r.limit) * float64(time.Second))
+	if elapsed := time.Since(r.start); expected > elapsed {
+		time.Sleep(expected - elapsed)
+	}
+	return n, err
+}
@@ -0,0 +1,27 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package youtube
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestRateLimitedReader(t *testing.T) {
+	data := bytes.Repeat([]byte{'a'}, 300)
+	start := time.Now()
+	got, err := ioutil.ReadAll(newRateLimitedReader(bytes.NewReader(data), 1000))
+	if err != nil && err != io.EOF {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("read %d bytes, want %d", len(got), len(data))
+	}
+	if elapsed := time.Since(start); elapsed < 250*time.Millisecond {
+		t.Errorf("300 bytes at 1000 bytes/s were read in %v", elapsed)
+	}
+}
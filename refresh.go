@@ -0,0 +1,31 @@
+package youtube
+
+import (
+	"context"
+)
+
+// RefreshFormats resolves the formats of the decoded video again, for long
+// lived processes holding onto a video past the expiry of its stream urls.
+// Only the streaming data is replaced, the title and the other metadata of the
+// first decode are kept. The video is left untouched when the refresh fails.
+func (y *Youtube) RefreshFormats(ctx context.Context) error {
+	if y.playerResponse == nil || len(y.StreamList) == 0 {
+		return ErrEmptyStreamList
+	}
+	previous := *y.playerResponse
+	videoInfo, streams := y.videoInfo, y.StreamList
+	title, author := streams[0].Title, streams[0].Author
+
+	if err := y.decode(ctx, true); err != nil {
+		y.videoInfo, y.playerResponse, y.StreamList = videoInfo, &previous, streams
+		return err
+	}
+
+	refreshed := previous
+	refreshed.StreamingData = y.playerResponse.StreamingData
+	y.playerResponse = &refreshed
+	for i := range y.StreamList {
+		y.StreamList[i].Title, y.StreamList[i].Author = title, author
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestYoutube_RefreshFormats(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 3 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		playerResponse, _ := json.Marshal(map[string]interface{}{
+			"playabilityStatus": map[string]string{"status": "OK"},
+			"videoDetails":      map[string]string{"title": fmt.Sprintf("title %d", requests), "author": "dotconferences", "lengthSeconds": "1401"},
+			"streamingData": map[string]interface{}{
+				"formats": []map[string]interface{}{
+					{"itag": 18, "url": fmt.Sprintf("https://r1.googlevideo.com/videoplayback?itag=18&n=%d", requests), "mimeType": "video/mp4", "quality": "medium"},
+				},
+			},
+		})
+		w.Write([]byte(url.Values{"status": {"ok"}, "player_response": {string(playerResponse)}}.Encode()))
+	}))
+	defer server.Close()
+	defer func(url string) { baseURL = url }(baseURL)
+	baseURL = server.URL
+
+	ctx := context.Background()
+	y := NewYoutube(false)
+	if err := y.RefreshFormats(ctx); err != ErrEmptyStreamList {
+		t.Errorf("RefreshFormats() error = %v, want ErrEmptyStreamList before a decode", err)
+	}
+	if err := y.DecodeURLWithContext(ctx, "rFejpH_tAHM"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := y.RefreshFormats(ctx); err != nil {
+		t.Fatal(err)
+	}
+	s := y.StreamList[0]
+	if !strings.HasSuffix(s.URL, "n=2") {
+		t.Errorf("url = %s, want the refreshed url", s.URL)
+	}
+	if s.Title != "title 1" || y.playerResponse.VideoDetails.Title != "title 1" {
+		t.Errorf("title = %q, the metadata of the first decode should be kept", s.Title)
+	}
+	if y.duration() == 0 {
+		t.Error("the duration should be kept")
+	}
+
+	if err := y.RefreshFormats(ctx); err == nil {
+		t.Fatal("RefreshFormats() should fail when the video info can't be fetched")
+	}
+	if !strings.HasSuffix(y.StreamList[0].URL, "n=2") || y.playerResponse == nil {
+		t.Errorf("url = %s, a failed refresh should keep the formats", y.StreamList[0].URL)
+	}
+}
@@ -0,0 +1,89 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package youtube
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// partSuffix is appended to the name of the files being downloaded with
+// DownloadOptions.Resume until they are complete.
+const partSuffix = ".part"
+
+// resumeDLWorker downloads target into destFile+partSuffix, continuing where
+// a previous attempt stopped, and renames it to destFile once complete.
+func (y *Youtube) resumeDLWorker(ctx context.Context, destFile string, target string, mimeType string, opts DownloadOptions) error {
+	partFile := destFile + partSuffix
+	var offset int64
+	if fi, err := os.Stat(partFile); err == nil && fi.Mode().IsRegular() {
+		offset = fi.Size()
+		y.log(fmt.Sprintf("Resuming %s from byte %d", partFile, offset))
+	}
+
+	var out *os.File
+	err := y.streamWorker(ctx, target, mimeType, opts, offset, func(start int64) (io.Writer, error) {
+		var err error
+		out, err = openPartial(partFile, start)
+		return out, err
+	})
+	if out != nil {
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if err := y.trashExisting(destFile); err != nil {
+		return err
+	}
+	return os.Rename(partFile, destFile)
+}
+
+// openPartial opens path for writing from offset, the bytes after offset are discarded.
+func openPartial(path string, offset int64) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Truncate(offset); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// resumeOffset returns the offset the body of resp starts at for a request
+// of the bytes from offset, and whether the resource is already complete.
+func resumeOffset(resp *http.Response, offset int64) (start int64, complete bool, err error) {
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return 0, false, nil
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		var first, last int64
+		contentRange := resp.Header.Get("Content-Range")
+		if _, err := fmt.Sscanf(contentRange, "bytes %d-%d/", &first, &last); err != nil || first != offset {
+			return 0, false, fmt.Errorf("unexpected content range %q for offset %d", contentRange, offset)
+		}
+		return offset, false, nil
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// the whole resource was downloaded by a previous attempt
+		if resp.Header.Get("Content-Range") == fmt.Sprintf("bytes */%d", offset) {
+			return offset, true, nil
+		}
+	}
+	return 0, false, ErrUnexpectedStatusCode(resp.StatusCode)
+}
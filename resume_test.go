@@ -0,0 +1,342 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package youtube
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newMediaServer serves media with range support.
+func newMediaServer(media []byte) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "video/mp4")
+		http.ServeContent(w, r, "video.mp4", time.Time{}, bytes.NewReader(media))
+	}))
+}
+
+// dropServer serves media with range support and simulates connection drops:
+// the answer to the n-th request is cut once the absolute offset drops[n] of
+// media was sent, the requests after the last drop are served completely.
+type dropServer struct {
+	media []byte
+	drops []int64
+
+	mu     sync.Mutex
+	ranges []string
+}
+
+func (s *dropServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	n := len(s.ranges)
+	s.ranges = append(s.ranges, r.Header.Get("Range"))
+	s.mu.Unlock()
+
+	var start int64
+	if header := r.Header.Get("Range"); header != "" {
+		if _, err := fmt.Sscanf(header, "bytes=%d-", &start); err != nil || start > int64(len(s.media)) {
+			http.Error(w, "invalid range", http.StatusBadRequest)
+			return
+		}
+	}
+	end := int64(len(s.media))
+	if start == end && start > 0 {
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", end))
+		w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "video/mp4")
+	w.Header().Set("Content-Length", fmt.Sprint(end-start))
+	if start > 0 {
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end-1, end))
+		w.WriteHeader(http.StatusPartialContent)
+	}
+	if n < len(s.drops) && s.drops[n] >= start && s.drops[n] < end {
+		w.Write(s.media[start:s.drops[n]])
+		w.(http.Flusher).Flush()
+		// closes the connection short of the announced length
+		panic(http.ErrAbortHandler)
+	}
+	w.Write(s.media[start:])
+}
+
+// requestedRanges returns the Range header of every request received.
+func (s *dropServer) requestedRanges() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.ranges...)
+}
+
+func TestYoutube_download_ResumeIntegrity(t *testing.T) {
+	defer func(delay time.Duration) { retryDelay = delay }(retryDelay)
+	retryDelay = 0
+
+	media := append([]byte{}, mp4Header...)
+	for i := 0; len(media) < 64*1024; i++ {
+		media = append(media, byte(i), byte(i>>8), byte(i*7))
+	}
+	size := int64(len(media))
+
+	tests := []struct {
+		name  string
+		drops []int64
+	}{
+		{"no drop", nil},
+		{"one drop", []int64{10000}},
+		{"drop before any byte", []int64{0}},
+		{"drop while sniffing", []int64{100}},
+		{"successive drops", []int64{1000, 1001, 30000, 50000}},
+		{"drop before the last byte", []int64{size - 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "youtube")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+
+			server := &dropServer{media: media, drops: tt.drops}
+			httpServer := httptest.NewServer(server)
+			defer httpServer.Close()
+
+			y := NewYoutube(false)
+			y.StreamList = []stream{{ItagNo: 18, Type: "video/mp4", URL: httpServer.URL, Title: "video"}}
+			destFile, err := y.download(context.Background(), DownloadOptions{
+				OutputDir: dir,
+				Resume:    true,
+				Retries:   len(tt.drops),
+			})
+			if err != nil {
+				t.Fatalf("download() error = %v", err)
+			}
+			got, err := ioutil.ReadFile(destFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, media) {
+				t.Fatalf("downloaded %d bytes differing from the %d bytes of a clean download", len(got), len(media))
+			}
+
+			// every retry continues from what the previous attempts kept
+			ranges := server.requestedRanges()
+			if len(ranges) != len(tt.drops)+1 {
+				t.Fatalf("server received %d requests, want %d", len(ranges), len(tt.drops)+1)
+			}
+			var kept int64
+			for i, got := range ranges {
+				want := ""
+				if kept > 0 {
+					want = fmt.Sprintf("bytes=%d-", kept)
+				}
+				if got != want {
+					t.Errorf("request %d range = %q, want %q", i, got, want)
+				}
+				if i < len(tt.drops) && tt.drops[i] >= sniffLen {
+					kept = tt.drops[i]
+				}
+			}
+		})
+	}
+}
+
+func TestYoutube_resumeDLWorker(t *testing.T) {
+	media := append(append([]byte{}, mp4Header...), bytes.Repeat([]byte{1, 2, 3, 4}, 1024)...)
+	server := newMediaServer(media)
+	defer server.Close()
+
+	tests := []struct {
+		name string
+		part []byte
+	}{
+		{"no part", nil},
+		{"half part", media[:len(media)/2]},
+		{"complete part", media},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "youtube")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+
+			destFile := filepath.Join(dir, "video.mp4")
+			if tt.part != nil {
+				if err := ioutil.WriteFile(destFile+partSuffix, tt.part, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			y := NewYoutube(false)
+			if err := y.videoDLWorker(context.Background(), destFile, server.URL, "video/mp4", DownloadOptions{Resume: true}); err != nil {
+				t.Fatalf("videoDLWorker() error = %v", err)
+			}
+			got, err := ioutil.ReadFile(destFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, media) {
+				t.Errorf("downloaded %d bytes, want the %d bytes of the media", len(got), len(media))
+			}
+			if _, err := os.Stat(destFile + partSuffix); !os.IsNotExist(err) {
+				t.Error("the part file should be renamed once complete")
+			}
+		})
+	}
+}
+
+func TestYoutube_download_Retries(t *testing.T) {
+	defer func(delay time.Duration) { retryDelay = delay }(retryDelay)
+	retryDelay = 0
+
+	media := append(append([]byte{}, mp4Header...), bytes.Repeat([]byte{0}, 1024)...)
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "video/mp4")
+		w.Write(media)
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "youtube")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	y := NewYoutube(false)
+	y.StreamList = []stream{{ItagNo: 18, Type: "video/mp4", URL: server.URL, Title: "video"}}
+	opts := DownloadOptions{OutputDir: dir}
+	if _, err := y.download(context.Background(), opts); err != ErrUnexpectedStatusCode(http.StatusServiceUnavailable) {
+		t.Errorf("download() error = %v, want the status code without retry", err)
+	}
+	opts.Retries = 1
+	if _, err := y.download(context.Background(), opts); err != nil {
+		t.Errorf("download() error = %v, want the retry to succeed", err)
+	}
+	if requests != 2 {
+		t.Errorf("server received %d requests, want 2", requests)
+	}
+}
+
+func TestYoutube_download_RetryUnreadProgress(t *testing.T) {
+	defer func(delay time.Duration) { retryDelay = delay }(retryDelay)
+	retryDelay = 0
+
+	media := append(append([]byte{}, mp4Header...), bytes.Repeat([]byte{1}, 4<<20)...)
+	server := httptest.NewServer(&dropServer{media: media, drops: []int64{int64(len(media) / 2)}})
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "youtube")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	y := NewYoutube(false)
+	y.StreamList = []stream{{ItagNo: 18, Type: "video/mp4", URL: server.URL, Title: "video"}}
+	done := make(chan error)
+	go func() {
+		// as QuickDownload, nothing reads DownloadPercent
+		_, err := y.download(context.Background(), DownloadOptions{OutputDir: dir, Resume: true, Retries: 1})
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(30 * time.Second):
+		t.Fatalf("the retried download blocked with %d unread progress values", len(y.DownloadPercent))
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want bool
+	}{
+		{"server error", context.Background(), ErrUnexpectedStatusCode(502), true},
+		{"too many requests", context.Background(), ErrUnexpectedStatusCode(429), true},
+		{"forbidden", context.Background(), ErrUnexpectedStatusCode(403), false},
+		{"stalled", context.Background(), ErrDownloadStalled, true},
+		{"content type", context.Background(), ErrUnexpectedContentType{Expected: "video/mp4", Got: "text/html"}, false},
+		{"canceled", canceled, ErrDownloadStalled, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryable(tt.ctx, tt.err); got != tt.want {
+				t.Errorf("retryable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResumeOffset(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		contentRange string
+		offset       int64
+		wantStart    int64
+		wantComplete bool
+		wantErr      string
+	}{
+		{"full answer", http.StatusOK, "", 100, 0, false, ""},
+		{"partial answer", http.StatusPartialContent, "bytes 100-199/200", 100, 100, false, ""},
+		{"wrong range", http.StatusPartialContent, "bytes 0-199/200", 100, 0, false, "content range"},
+		{"complete", http.StatusRequestedRangeNotSatisfiable, "bytes */200", 200, 200, true, ""},
+		{"larger part", http.StatusRequestedRangeNotSatisfiable, "bytes */150", 200, 0, false, "416"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+			resp.Header.Set("Content-Range", tt.contentRange)
+			start, complete, err := resumeOffset(resp, tt.offset)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("resumeOffset() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || start != tt.wantStart || complete != tt.wantComplete {
+				t.Errorf("resumeOffset() = %d, %v, %v, want %d, %v", start, complete, err, tt.wantStart, tt.wantComplete)
+			}
+		})
+	}
+}
+
+func TestYoutube_bestMuxedStream(t *testing.T) {
+	y := NewYoutube(false)
+	y.StreamList = []stream{
+		{ItagNo: 18, Height: 360, Bitrate: 500000},
+		{ItagNo: 22, Height: 720, Bitrate: 1000000},
+		{ItagNo: 137, Height: 1080, Adaptive: true},
+	}
+	if best, ok := y.bestMuxedStream(); !ok || best.ItagNo != 22 {
+		t.Errorf("bestMuxedStream() = %d, %v, want 22", best.ItagNo, ok)
+	}
+	y.StreamList = y.StreamList[2:]
+	if _, ok := y.bestMuxedStream(); ok {
+		t.Error("bestMuxedStream() should find no muxed stream")
+	}
+}
@@ -0,0 +1,49 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package youtube
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// retryDelay is the wait before the first retry of a download, it doubles at
+// every attempt up to maxRetryDelay.
+var retryDelay = time.Second
+
+const maxRetryDelay = 30 * time.Second
+
+// retryable reports whether the download which failed with err may succeed
+// when attempted again.
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if urlErr, ok := err.(*url.Error); ok {
+		err = urlErr.Err
+	}
+	switch err := err.(type) {
+	case ErrUnexpectedStatusCode:
+		return err == http.StatusTooManyRequests || err >= 500
+	case net.Error:
+		return true
+	}
+	return err == ErrDownloadStalled || err == io.ErrUnexpectedEOF
+}
+
+// backoff returns the wait before the retry following attempt.
+func backoff(attempt int) time.Duration {
+	delay := retryDelay
+	for i := 0; i < attempt && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
@@ -0,0 +1,74 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package youtube
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// SegmentFormat is the container of the segments exported by a Segmenter.
+type SegmentFormat string
+
+// The supported segment formats.
+const (
+	// SegmentHLS writes MPEG-TS segments along with an index.m3u8 HLS playlist.
+	SegmentHLS SegmentFormat = "hls"
+	// SegmentMP4 writes standalone mp4 parts.
+	SegmentMP4 SegmentFormat = "mp4"
+)
+
+// SegmentOptions configures the export of a video into segments.
+type SegmentOptions struct {
+	// Duration is the length of the segments.
+	Duration time.Duration
+	// Format is SegmentHLS when empty.
+	Format SegmentFormat
+	// KeyframeAligned cuts the segments at the existing keyframes without
+	// re-encoding, they are then only roughly Duration long. Otherwise the
+	// video is re-encoded with keyframes forced every Duration, for segments
+	// of exactly Duration.
+	KeyframeAligned bool
+}
+
+// Validate checks the options and returns every problem found at once as ErrInvalidOptions.
+func (o SegmentOptions) Validate() error {
+	var errs ErrInvalidOptions
+	if o.Duration <= 0 {
+		errs = append(errs, ErrInvalidOption{Option: "Duration", Reason: "must be positive"})
+	}
+	if o.Format != "" && o.Format != SegmentHLS && o.Format != SegmentMP4 {
+		errs = append(errs, ErrInvalidOption{Option: "Format", Reason: "unsupported format " + string(o.Format)})
+	}
+	return errs.err()
+}
+
+// Segmenter splits video files into segments, it is implemented by
+// github.com/kkdai/youtube/ffmpeg.
+type Segmenter interface {
+	// Segment writes the segments of srcFile into destDir and returns their
+	// paths in playing order.
+	Segment(ctx context.Context, srcFile, destDir string, opts SegmentOptions) ([]string, error)
+}
+
+// DownloadSegments downloads the video according to opts, then exports it
+// with segmenter into a directory named after the downloaded file, without
+// its extension. The downloaded file is kept, the paths of the segments are
+// returned in playing order.
+func (y *Youtube) DownloadSegments(ctx context.Context, opts DownloadOptions, segmenter Segmenter, segOpts SegmentOptions) ([]string, error) {
+	if err := segOpts.Validate(); err != nil {
+		return nil, err
+	}
+	if segOpts.Format == "" {
+		segOpts.Format = SegmentHLS
+	}
+	path, err := y.download(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	destDir := strings.TrimSuffix(path, filepath.Ext(path))
+	return segmenter.Segment(ctx, path, destDir, segOpts)
+}
@@ -0,0 +1,76 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package youtube
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeSegmenter records the file it segments.
+type fakeSegmenter struct {
+	srcFile, destDir string
+	opts             SegmentOptions
+}
+
+func (s *fakeSegmenter) Segment(ctx context.Context, srcFile, destDir string, opts SegmentOptions) ([]string, error) {
+	s.srcFile, s.destDir, s.opts = srcFile, destDir, opts
+	return []string{filepath.Join(destDir, "segment00000.ts")}, nil
+}
+
+func TestSegmentOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    SegmentOptions
+		wantErr bool
+	}{
+		{"hls", SegmentOptions{Duration: 6 * time.Second}, false},
+		{"mp4", SegmentOptions{Duration: time.Minute, Format: SegmentMP4, KeyframeAligned: true}, false},
+		{"no duration", SegmentOptions{Format: SegmentHLS}, true},
+		{"unknown format", SegmentOptions{Duration: time.Second, Format: "dash"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.opts.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestYoutube_DownloadSegments(t *testing.T) {
+	media := append(append([]byte{}, mp4Header...), bytes.Repeat([]byte{0}, 1024)...)
+	server := newMediaServer(media)
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "youtube")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	y := NewYoutube(false)
+	y.StreamList = []stream{{ItagNo: 18, Type: "video/mp4", URL: server.URL, Title: "talk"}}
+	segmenter := &fakeSegmenter{}
+	ctx := context.Background()
+
+	if _, err := y.DownloadSegments(ctx, DownloadOptions{OutputDir: dir}, segmenter, SegmentOptions{}); err == nil {
+		t.Fatal("DownloadSegments() should reject invalid segment options")
+	}
+	segments, err := y.DownloadSegments(ctx, DownloadOptions{OutputDir: dir}, segmenter, SegmentOptions{Duration: 6 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if segmenter.srcFile != filepath.Join(dir, "talk.mp4") || segmenter.destDir != filepath.Join(dir, "talk") {
+		t.Errorf("Segment(%s, %s), want the download segmented next to it", segmenter.srcFile, segmenter.destDir)
+	}
+	if segmenter.opts.Format != SegmentHLS || len(segments) != 1 {
+		t.Errorf("DownloadSegments() = %q in %s, want hls segments by default", segments, segmenter.opts.Format)
+	}
+}
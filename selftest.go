@@ -0,0 +1,69 @@
+package youtube
+
+import (
+	"context"
+	"fmt"
+)
+
+// SelfTestVideoIDs are the known-stable public videos checked by SelfTest
+// when no video id is given.
+var SelfTestVideoIDs = []string{
+	"rFejpH_tAHM",
+	"54e6lBE3BoQ",
+	"n3kPvBCYT3E",
+}
+
+// SelfTestReport describes which extraction capabilities currently work.
+type SelfTestReport struct {
+	Muxed    bool
+	Adaptive bool
+	Cipher   bool
+	Captions bool
+	// Failures holds the decoding error of every video that could not be extracted.
+	Failures map[string]error
+}
+
+// Healthy reports whether every tested video was extracted and both muxed
+// and adaptive streams were found.
+func (r *SelfTestReport) Healthy() bool {
+	return len(r.Failures) == 0 && r.Muxed && r.Adaptive
+}
+
+// SelfTest decodes a set of known-stable videos and reports which capabilities
+// currently work, SelfTestVideoIDs are used when no video id is given.
+func (y *Youtube) SelfTest(ctx context.Context, videoIDs ...string) *SelfTestReport {
+	if len(videoIDs) == 0 {
+		videoIDs = SelfTestVideoIDs
+	}
+
+	report := &SelfTestReport{Failures: make(map[string]error)}
+	for _, videoID := range videoIDs {
+		if err := ctx.Err(); err != nil {
+			report.Failures[videoID] = err
+			continue
+		}
+
+		probe := NewYoutube(y.DebugMode)
+		probe.Socks5Proxy = y.Socks5Proxy
+		if err := probe.DecodeURLWithContext(ctx, videoID); err != nil {
+			y.log(fmt.Sprintf("self test of %s failed: %s", videoID, err))
+			report.Failures[videoID] = err
+			continue
+		}
+
+		for _, stream := range probe.StreamList {
+			if stream.Adaptive {
+				report.Adaptive = true
+			} else {
+				report.Muxed = true
+			}
+			if stream.Ciphered {
+				report.Cipher = true
+			}
+		}
+		if len(probe.playerResponse.Captions.PlayerCaptionsTracklistRenderer.CaptionTracks) > 0 {
+			report.Captions = true
+		}
+	}
+	return report
+}
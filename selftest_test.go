@@ -0,0 +1,20 @@
+//go:build integration
+// +build integration
+
+package youtube
+
+import (
+	"context"
+	"testing"
+)
+
+func TestYoutube_SelfTest(t *testing.T) {
+	y := NewYoutube(false)
+	report := y.SelfTest(context.Background())
+	for videoID, err := range report.Failures {
+		t.Errorf("self test of %s failed, err:%v", videoID, err)
+	}
+	if !report.Healthy() {
+		t.Errorf("self test reported unhealthy capabilities: %+v", report)
+	}
+}
@@ -0,0 +1,199 @@
+package youtube
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// sessionStateVersion is the version of the state written by ExportState,
+// states of another version are rejected by ImportState.
+const sessionStateVersion = 1
+
+// playerMaxAge is how long the player javascript found in an embed page is
+// used without looking it up again, youtube rotates it every few days.
+const playerMaxAge = 6 * time.Hour
+
+// Session holds what a Youtube learns while warming up: the player
+// javascript, the cipher plans parsed from it, the visitor data of the
+// innertube requests and the cookies. Share it between the Youtube of a
+// process through Options.Session, and save it across processes with
+// ExportState and ImportState.
+type Session struct {
+	mu          sync.Mutex
+	playerPath  string
+	playerAt    time.Time
+	plans       map[string]cipherPlan
+	visitorData string
+	jar         http.CookieJar
+}
+
+// cipherPlan is the sequence of operations deciphering the signatures of a player.
+type cipherPlan struct {
+	Operations []string `json:"operations"`
+	Args       []int    `json:"args"`
+}
+
+// NewSession returns an empty session.
+func NewSession() *Session {
+	jar, _ := cookiejar.New(nil)
+	return &Session{plans: make(map[string]cipherPlan), jar: jar}
+}
+
+// player returns the path of the player javascript, when looked up recently.
+func (s *Session) player() (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.playerPath, s.playerPath != "" && time.Since(s.playerAt) < playerMaxAge
+}
+
+func (s *Session) setPlayer(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.playerPath, s.playerAt = path, time.Now()
+}
+
+// plan returns the cipher plan of the player at path.
+func (s *Session) plan(path string) (cipherPlan, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	plan, ok := s.plans[path]
+	return plan, ok
+}
+
+func (s *Session) setPlan(path string, plan cipherPlan) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.plans == nil {
+		s.plans = make(map[string]cipherPlan)
+	}
+	s.plans[path] = plan
+}
+
+func (s *Session) getVisitorData() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.visitorData
+}
+
+func (s *Session) setVisitorData(visitorData string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.visitorData = visitorData
+}
+
+// session returns the Session of y, created on first use.
+func (y *Youtube) session() *Session {
+	y.sessionMu.Lock()
+	defer y.sessionMu.Unlock()
+	if y.Session == nil {
+		y.Session = NewSession()
+	}
+	return y.Session
+}
+
+// sessionState is the serialized Session.
+type sessionState struct {
+	Version     int                      `json:"version"`
+	PlayerPath  string                   `json:"player_path,omitempty"`
+	PlayerAt    time.Time                `json:"player_at"`
+	CipherPlans map[string]cipherPlan    `json:"cipher_plans,omitempty"`
+	VisitorData string                   `json:"visitor_data,omitempty"`
+	Cookies     map[string][]stateCookie `json:"cookies,omitempty"`
+}
+
+type stateCookie struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// ExportState serializes the session of y along with the youtube cookies of
+// its http client, so a short lived process, such as a CLI invocation or a
+// cold started serverless function, can skip the warm-up with ImportState.
+// The state holds cookies, store it as privately as credentials.
+func (y *Youtube) ExportState() ([]byte, error) {
+	s := y.session()
+	s.mu.Lock()
+	state := sessionState{
+		Version:     sessionStateVersion,
+		PlayerPath:  s.playerPath,
+		PlayerAt:    s.playerAt,
+		CipherPlans: make(map[string]cipherPlan, len(s.plans)),
+		VisitorData: s.visitorData,
+	}
+	for path, plan := range s.plans {
+		state.CipherPlans[path] = plan
+	}
+	s.mu.Unlock()
+
+	if jar := y.cookieJar(); jar != nil {
+		for _, rawURL := range []string{baseURL, musicURL} {
+			u, err := url.Parse(rawURL)
+			if err != nil {
+				return nil, err
+			}
+			for _, cookie := range jar.Cookies(u) {
+				if state.Cookies == nil {
+					state.Cookies = make(map[string][]stateCookie)
+				}
+				state.Cookies[rawURL] = append(state.Cookies[rawURL], stateCookie{Name: cookie.Name, Value: cookie.Value})
+			}
+		}
+	}
+	return json.Marshal(state)
+}
+
+// ImportState restores a state written by ExportState into the session of y,
+// the player javascript is looked up again once older than playerMaxAge.
+func (y *Youtube) ImportState(data []byte) error {
+	var state sessionState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	if state.Version != sessionStateVersion {
+		return ErrStateVersion
+	}
+
+	s := y.session()
+	s.mu.Lock()
+	s.playerPath, s.playerAt = state.PlayerPath, state.PlayerAt
+	if s.plans == nil {
+		s.plans = make(map[string]cipherPlan)
+	}
+	for path, plan := range state.CipherPlans {
+		s.plans[path] = plan
+	}
+	if state.VisitorData != "" {
+		s.visitorData = state.VisitorData
+	}
+	s.mu.Unlock()
+
+	jar := y.cookieJar()
+	if jar == nil {
+		return nil
+	}
+	for rawURL, cookies := range state.Cookies {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return err
+		}
+		httpCookies := make([]*http.Cookie, len(cookies))
+		for i, cookie := range cookies {
+			httpCookies[i] = &http.Cookie{Name: cookie.Name, Value: cookie.Value}
+		}
+		jar.SetCookies(u, httpCookies)
+	}
+	return nil
+}
+
+// cookieJar returns the jar of the http client of y, nil when it has none.
+func (y *Youtube) cookieJar() http.CookieJar {
+	httpClient, err := y.getHTTPClient()
+	if err != nil {
+		return nil
+	}
+	return httpClient.Jar
+}
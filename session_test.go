@@ -0,0 +1,104 @@
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const (
+	testEmbedPage = `<script>yt.setConfig({'PLAYER_CONFIG':{"assets":{"js":"\/s\/player\/f676c671\/base.js"}}});</script>`
+	testBaseJS    = `var Et={vw:function(a,b){a.splice(0,b)},cn:function(a){a.reverse()},Zm:function(a,b){var c=a[0];a[0]=a[b%a.length];a[b%a.length]=c}};` +
+		`;Ft=function(a){a=a.split("");Et.vw(a,2);Et.cn(a,0);Et.Zm(a,3);return a.join("")};`
+)
+
+// newCipherServer serves a video with a ciphered stream, its embed page and
+// its player, counting the requests by path, and sets a cookie.
+func newCipherServer(t *testing.T, requests map[string]int, mu *sync.Mutex) *httptest.Server {
+	cipher := url.Values{"s": {"abcdefghij"}, "sp": {"sig"}, "url": {"https://r1.googlevideo.com/videoplayback?itag=18"}}.Encode()
+	playerResponse, err := json.Marshal(map[string]interface{}{
+		"playabilityStatus": map[string]string{"status": "OK"},
+		"videoDetails":      map[string]string{"title": "Simplicity is Complicated", "author": "dotconferences"},
+		"streamingData": map[string]interface{}{
+			"formats": []map[string]interface{}{{"itag": 18, "signatureCipher": cipher, "mimeType": "video/mp4", "quality": "medium"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	videoInfo := url.Values{"status": {"ok"}, "player_response": {string(playerResponse)}}.Encode()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		if cookie, err := r.Cookie("VISITOR_INFO1_LIVE"); err == nil {
+			requests["cookie "+cookie.Value]++
+		}
+		mu.Unlock()
+		switch {
+		case r.URL.Path == "/get_video_info":
+			http.SetCookie(w, &http.Cookie{Name: "VISITOR_INFO1_LIVE", Value: "visitor"})
+			w.Write([]byte(videoInfo))
+		case strings.HasPrefix(r.URL.Path, "/embed/"):
+			w.Write([]byte(testEmbedPage))
+		case r.URL.Path == "/s/player/f676c671/base.js":
+			w.Write([]byte(testBaseJS))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestYoutube_ExportState(t *testing.T) {
+	var mu sync.Mutex
+	requests := make(map[string]int)
+	server := newCipherServer(t, requests, &mu)
+	defer server.Close()
+	defer func(url string) { baseURL = url }(baseURL)
+	baseURL = server.URL
+
+	ctx := context.Background()
+	cold := NewYoutube(false)
+	if err := cold.DecodeURLWithContext(ctx, "rFejpH_tAHM"); err != nil {
+		t.Fatal(err)
+	}
+	// splice 2, reverse, swap 3
+	if want := "https://r1.googlevideo.com/videoplayback?itag=18&sig=gihjfedc"; cold.StreamList[0].URL != want {
+		t.Errorf("url = %s, want %s", cold.StreamList[0].URL, want)
+	}
+	if requests["/s/player/f676c671/base.js"] != 1 {
+		t.Errorf("player fetched %d times, want once", requests["/s/player/f676c671/base.js"])
+	}
+	state, err := cold.ExportState()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	withCookie := requests["cookie visitor"]
+
+	warm := NewYoutube(false)
+	if err := warm.ImportState(state); err != nil {
+		t.Fatal(err)
+	}
+	if err := warm.DecodeURLWithContext(ctx, "rFejpH_tAHM"); err != nil {
+		t.Fatal(err)
+	}
+	if warm.StreamList[0].URL != cold.StreamList[0].URL {
+		t.Errorf("url = %s, want %s", warm.StreamList[0].URL, cold.StreamList[0].URL)
+	}
+	if requests["/embed/rFejpH_tAHM"] != 1 || requests["/s/player/f676c671/base.js"] != 1 {
+		t.Errorf("requests = %v, the warm decode should skip the embed page and the player", requests)
+	}
+	if requests["cookie visitor"] != withCookie+1 {
+		t.Errorf("requests = %v, the warm decode should send the imported cookie", requests)
+	}
+
+	if err := warm.ImportState([]byte(`{"version": 99}`)); err != ErrStateVersion {
+		t.Errorf("ImportState() error = %v, want ErrStateVersion", err)
+	}
+}
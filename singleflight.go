@@ -0,0 +1,109 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// errFlightPanicked is the error of the callers waiting for a call that panicked.
+var errFlightPanicked = errors.New("the shared call panicked")
+
+// flightCall is an in-flight or completed call of a flightGroup.
+type flightCall struct {
+	done chan struct{}
+	val  interface{}
+	err  error
+
+	// waiters is the number of the callers of doContext still waiting for
+	// the call, cancel cancels its context once none is left.
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// flightGroup deduplicates concurrent calls sharing a key: while a call is
+// in flight, the callers of the same key wait for it and share its result.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+// do runs fn once for all the concurrent callers of key, shared reports
+// whether the result was given to several callers.
+func (g *flightGroup) do(key string, fn func() (interface{}, error)) (val interface{}, err error, shared bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall)
+	}
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		<-c.done
+		return c.val, c.err, true
+	}
+	// the waiting callers get this error if fn panics
+	c := &flightCall{done: make(chan struct{}), err: errFlightPanicked}
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	// deferred so that the waiting callers are released if fn panics
+	defer g.release(key, c)
+	c.val, c.err = fn()
+	return c.val, c.err, false
+}
+
+// doContext is do for the calls bound to a context: fn runs with a context of
+// its own, canceled once every caller gave up, and each caller waits for the
+// result until its ctx is done. A caller of a call canceled this way starts
+// a new call. A panic of fn is returned to the callers as an error.
+func (g *flightGroup) doContext(ctx context.Context, key string, fn func(context.Context) (interface{}, error)) (val interface{}, err error, shared bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall)
+	}
+	c, shared := g.calls[key]
+	if !shared {
+		callCtx, cancel := context.WithCancel(context.Background())
+		c = &flightCall{done: make(chan struct{}), err: errFlightPanicked, cancel: cancel}
+		g.calls[key] = c
+		go func() {
+			defer cancel()
+			defer g.release(key, c)
+			// no caller could recover a panic of this goroutine
+			defer func() {
+				if r := recover(); r != nil {
+					c.val, c.err = nil, fmt.Errorf("%s: %v", errFlightPanicked, r)
+				}
+			}()
+			c.val, c.err = fn(callCtx)
+		}()
+	}
+	c.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.val, c.err, shared
+	case <-ctx.Done():
+		g.mu.Lock()
+		c.waiters--
+		if c.waiters == 0 {
+			c.cancel()
+			if g.calls[key] == c {
+				delete(g.calls, key)
+			}
+		}
+		g.mu.Unlock()
+		return nil, ctx.Err(), shared
+	}
+}
+
+// release removes the completed call c of key and wakes its callers up.
+func (g *flightGroup) release(key string, c *flightCall) {
+	g.mu.Lock()
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+	g.mu.Unlock()
+	close(c.done)
+}
@@ -0,0 +1,76 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFlightGroup_panic(t *testing.T) {
+	var g flightGroup
+	release := make(chan struct{})
+	go func() {
+		defer func() { recover() }()
+		g.do("key", func() (interface{}, error) {
+			<-release
+			panic("boom")
+		})
+	}()
+	for {
+		g.mu.Lock()
+		_, ok := g.calls["key"]
+		g.mu.Unlock()
+		if ok {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	waiting := make(chan error, 1)
+	go func() {
+		_, err, _ := g.do("key", func() (interface{}, error) {
+			return nil, nil
+		})
+		waiting <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	select {
+	case err := <-waiting:
+		if err != errFlightPanicked && err != nil {
+			t.Fatalf("unexpected error of the waiting call: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("a call waiting for a panicked one should not block")
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err, shared := g.do("key", func() (interface{}, error) {
+			return nil, errors.New("second call")
+		})
+		if shared {
+			err = errors.New("the second call should not share the panicked one")
+		}
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil || err.Error() != "second call" {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("a call after a panic should not block")
+	}
+}
+
+func TestFlightGroup_doContextPanic(t *testing.T) {
+	var g flightGroup
+	_, err, _ := g.doContext(context.Background(), "key", func(ctx context.Context) (interface{}, error) {
+		panic("boom")
+	})
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("doContext() error = %v, want the panic", err)
+	}
+}
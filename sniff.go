@@ -0,0 +1,144 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package youtube
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// sniffLen is the number of bytes http.DetectContentType looks at.
+const sniffLen = 512
+
+// verifyContentType checks that resp carries media of mimeType rather than
+// an error page served with a 200 status, it returns a reader replaying the
+// sniffed bytes followed by the rest of the body. The body of a resumed
+// download starts in the middle of the media, only its header is checked.
+func verifyContentType(resp *http.Response, mimeType string, resumed bool) (io.Reader, error) {
+	expected, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		expected = mimeType
+	}
+
+	if header := resp.Header.Get("Content-Type"); header != "" {
+		got, _, err := mime.ParseMediaType(header)
+		if err != nil {
+			got = header
+		}
+		if got != expected && got != "application/octet-stream" {
+			return nil, ErrUnexpectedContentType{Expected: expected, Got: got}
+		}
+	}
+	if resumed {
+		return resp.Body, nil
+	}
+
+	body := bufio.NewReaderSize(resp.Body, sniffLen)
+	head, err := body.Peek(sniffLen)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if len(head) == 0 {
+		return nil, ErrUnexpectedContentType{Expected: expected, Got: "empty body"}
+	}
+	if sniffed := http.DetectContentType(head); strings.HasPrefix(sniffed, "text/") {
+		got, _, _ := mime.ParseMediaType(sniffed)
+		return nil, ErrUnexpectedContentType{Expected: expected, Got: got}
+	}
+	return body, nil
+}
+
+// containers groups the extensions of the same container, a .m4a file holding
+// mp4 bytes is not a mismatch.
+var containers = map[string]string{
+	".mp4": "mp4", ".m4a": "mp4", ".m4v": "mp4", ".mov": "mp4",
+	".3gp": "3gp", ".3gpp": "3gp", ".3g2": "3gp",
+	".webm": "matroska", ".weba": "matroska", ".mkv": "matroska",
+	".ogg": "ogg", ".oga": "ogg", ".opus": "ogg",
+}
+
+// sniffExtension returns the extension of the container starting with head,
+// from its magic numbers, or an empty string when it is not recognized.
+func sniffExtension(head []byte) string {
+	switch {
+	case len(head) >= 12 && string(head[4:8]) == "ftyp":
+		switch brand := string(head[8:12]); {
+		case strings.HasPrefix(brand, "3g"):
+			return ".3gp"
+		case brand == "M4A " || brand == "M4B ":
+			return ".m4a"
+		case brand == "qt  ":
+			return ".mov"
+		}
+		return ".mp4"
+	case bytes.HasPrefix(head, []byte("\x1a\x45\xdf\xa3")):
+		if bytes.Contains(head, []byte("webm")) {
+			return ".webm"
+		}
+		return ".mkv"
+	case bytes.HasPrefix(head, []byte("FLV")):
+		return ".flv"
+	case bytes.HasPrefix(head, []byte("OggS")):
+		return ".ogg"
+	case bytes.HasPrefix(head, []byte("ID3")), len(head) >= 2 && head[0] == 0xff && head[1]&0xe0 == 0xe0:
+		return ".mp3"
+	case len(head) > 188 && head[0] == 0x47 && head[188] == 0x47:
+		return ".ts"
+	}
+	return ""
+}
+
+// sameContainer reports whether the extensions a and b are of the same container.
+func sameContainer(a, b string) bool {
+	a, b = strings.ToLower(a), strings.ToLower(b)
+	if container, ok := containers[a]; ok {
+		a = container
+	}
+	if container, ok := containers[b]; ok {
+		b = container
+	}
+	return a == b
+}
+
+// checkExtension sniffs the content of the downloaded destFile and reports a
+// mismatch with its extension, the file is renamed to the sniffed extension
+// when fix is set and no file has that name yet. It returns the path of the file.
+func (y *Youtube) checkExtension(destFile string, fix bool) string {
+	fi, err := os.Stat(destFile)
+	if err != nil || isSpecialFile(fi) {
+		return destFile
+	}
+	f, err := os.Open(destFile)
+	if err != nil {
+		return destFile
+	}
+	head := make([]byte, sniffLen)
+	n, _ := io.ReadFull(f, head)
+	f.Close()
+
+	extension := filepath.Ext(destFile)
+	sniffed := sniffExtension(head[:n])
+	if sniffed == "" || sameContainer(extension, sniffed) {
+		return destFile
+	}
+	mismatch := ExtensionMismatch{Path: destFile, Extension: extension, Sniffed: sniffed}
+	if fix {
+		renamed := strings.TrimSuffix(destFile, extension) + sniffed
+		if _, err := os.Stat(renamed); os.IsNotExist(err) && os.Rename(destFile, renamed) == nil {
+			mismatch.Renamed, destFile = renamed, renamed
+		}
+	}
+	y.log(fmt.Sprintf("%s holds %s content, not %s", mismatch.Path, sniffed, extension))
+	if y.OnExtensionMismatch != nil {
+		y.OnExtensionMismatch(mismatch)
+	}
+	return destFile
+}
@@ -0,0 +1,56 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package youtube
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// stallTimeout is how long a download may go without receiving any byte.
+var stallTimeout = 30 * time.Second
+
+// stallDetector cancels a download when its body doesn't make progress for timeout.
+type stallDetector struct {
+	timer   *time.Timer
+	timeout time.Duration
+	fired   int32
+}
+
+func newStallDetector(cancel context.CancelFunc, timeout time.Duration) *stallDetector {
+	d := &stallDetector{timeout: timeout}
+	d.timer = time.AfterFunc(timeout, func() {
+		atomic.StoreInt32(&d.fired, 1)
+		cancel()
+	})
+	return d
+}
+
+// reader returns r which reads postpone the stall detection.
+func (d *stallDetector) reader(r io.Reader) io.Reader {
+	return stallReader{r: r, d: d}
+}
+
+func (d *stallDetector) stalled() bool {
+	return atomic.LoadInt32(&d.fired) == 1
+}
+
+func (d *stallDetector) stop() {
+	d.timer.Stop()
+}
+
+type stallReader struct {
+	r io.Reader
+	d *stallDetector
+}
+
+func (r stallReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		r.d.timer.Reset(r.d.timeout)
+	}
+	return n, err
+}
@@ -0,0 +1,97 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package youtube
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestDownload(t *testing.T) {
+	testcases := []struct {
+		name      string
+		outputDir string
+		ouputFile string
+		quality   string
+		itag      int
+	}{
+		{name: "Default"},
+		{name: "with outputDir", outputDir: dfPath},
+		{name: "SpecificQuality", quality: "hd720"},
+		{name: "SpecificITag", itag: 22},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			y := NewYoutube(false)
+			if y == nil {
+				t.Error("Cannot init object.")
+				return
+			}
+
+			if err := y.StartDownload(tc.outputDir, tc.ouputFile, tc.quality, tc.itag); err == nil {
+				t.Error("No video URL input should not download.")
+				return
+			}
+		})
+	}
+}
+
+func TestDownloadError(t *testing.T) {
+	y := NewYoutube(false)
+	if y == nil {
+		t.Error("Cannot init object.")
+		return
+	}
+	t.Run("empty stream list error", func(t *testing.T) {
+		if err := y.StartDownload("", "", "", 0); err != ErrEmptyStreamList {
+			t.Error("no err returned for empty stream list")
+		}
+	})
+
+	t.Run("itag not found error", func(t *testing.T) {
+		y.StreamList = append(y.StreamList, stream{})
+		if err := y.StartDownload("", "", "", 18); err != ErrItagNotFound {
+			t.Error("no error returned for itag not found")
+		}
+	})
+}
+
+func TestYoutube_download_UnreadProgress(t *testing.T) {
+	// a stream written in many more than 100 chunks
+	media := append(append([]byte{}, mp4Header...), bytes.Repeat([]byte{1}, 4<<20)...)
+	server := newMediaServer(media)
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "youtube")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	y := NewYoutube(false)
+	y.StreamList = []stream{{ItagNo: 18, Type: "video/mp4", URL: server.URL, Title: "video"}}
+	done := make(chan error)
+	go func() {
+		// nothing reads DownloadPercent
+		for i := 0; i < 2; i++ {
+			if err := y.StartDownloadWithOptions(context.Background(), DownloadOptions{OutputDir: dir}); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(30 * time.Second):
+		t.Fatalf("the second download blocked with %d unread progress values", len(y.DownloadPercent))
+	}
+}
@@ -0,0 +1,126 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package youtube
+
+import (
+	"context"
+	"os"
+	"strings"
+	"time"
+)
+
+// SyncAction is what Youtube.Sync did with the video.
+type SyncAction string
+
+// The sync actions.
+const (
+	SyncSkipped    SyncAction = "skipped"
+	SyncDownloaded SyncAction = "downloaded"
+	SyncUpgraded   SyncAction = "upgraded"
+)
+
+// Sync downloads the decoded video into the archive of y unless it is
+// archived already, in which case it is downloaded again according to the
+// Upgrade policy of y only. An upgrade downloads the best format of the same
+// kind as the archived one: muxed, video only or audio only, unless opts
+// selects a format. The file of the archived format is replaced, moved to
+// the Trash of y when set. It returns the entry of the video in the archive.
+func (y *Youtube) Sync(ctx context.Context, opts DownloadOptions) (ArchiveEntry, SyncAction, error) {
+	if y.Archive == nil {
+		return ArchiveEntry{}, "", ErrNoArchive
+	}
+	if len(y.StreamList) == 0 {
+		return ArchiveEntry{}, "", ErrEmptyStreamList
+	}
+	archived, action, opts := y.syncOptions(opts)
+	if action == SyncSkipped {
+		return archived, SyncSkipped, nil
+	}
+
+	opts, s, err := y.prepareDownload(opts)
+	if err != nil {
+		return archived, "", err
+	}
+	// the stream compared is the one downloaded
+	opts.ItagNo = s.ItagNo
+	path, err := y.download(ctx, opts)
+	if err != nil {
+		return archived, "", err
+	}
+	if action == SyncUpgraded && archived.Path != path {
+		if y.Trash != nil {
+			err = y.trashExisting(archived.Path)
+		} else if err = os.Remove(archived.Path); os.IsNotExist(err) {
+			err = nil
+		}
+		if err != nil {
+			return archived, "", err
+		}
+	}
+	entry := ArchiveEntry{
+		VideoID:      y.VideoID,
+		Path:         path,
+		Title:        s.Title,
+		Duration:     y.duration(),
+		Format:       s.format(),
+		DownloadedAt: time.Now().UTC(),
+	}
+	return entry, action, y.Archive.Record(entry)
+}
+
+// syncOptions returns the archive entry of the decoded video, what Sync does
+// with it and the options it downloads it with, those of an upgrade select
+// the better format.
+func (y *Youtube) syncOptions(opts DownloadOptions) (ArchiveEntry, SyncAction, DownloadOptions) {
+	archived, ok := y.Archive.Entry(y.VideoID)
+	if !ok {
+		return archived, SyncDownloaded, opts
+	}
+	switch y.Upgrade {
+	case UpgradeAlways:
+	case UpgradeBetter:
+		candidate, found := y.upgradeStream(archived.Format, opts)
+		if !found {
+			return archived, SyncSkipped, opts
+		}
+		opts.ItagNo, opts.Quality = candidate.ItagNo, ""
+	default:
+		return archived, SyncSkipped, opts
+	}
+	return archived, SyncUpgraded, opts
+}
+
+// upgradeStream returns the stream better than the archived format, the one
+// selected by opts or the best of the same kind.
+func (y *Youtube) upgradeStream(archived Format, opts DownloadOptions) (stream, bool) {
+	if opts.ItagNo != 0 || opts.Quality != "" {
+		s, err := y.selectStream(opts)
+		return s, err == nil && betterFormat(s.format(), archived)
+	}
+	var best stream
+	found := false
+	for _, s := range y.StreamList {
+		if !sameKind(s.format(), archived) {
+			continue
+		}
+		if (!found || betterFormat(s.format(), best.format())) && betterFormat(s.format(), archived) {
+			best, found = s, true
+		}
+	}
+	return best, found
+}
+
+// betterFormat reports whether a is better than b: higher, or as high with a
+// higher bitrate.
+func betterFormat(a, b Format) bool {
+	if a.Height != b.Height {
+		return a.Height > b.Height
+	}
+	return a.Bitrate > b.Bitrate
+}
+
+// sameKind reports whether a and b are both muxed, both video only or both audio only.
+func sameKind(a, b Format) bool {
+	return a.Adaptive == b.Adaptive && strings.SplitN(a.MimeType, "/", 2)[0] == strings.SplitN(b.MimeType, "/", 2)[0]
+}
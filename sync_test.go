@@ -0,0 +1,93 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package youtube
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBetterFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Format
+		want bool
+	}{
+		{"higher", Format{Height: 2160, Bitrate: 100}, Format{Height: 1080, Bitrate: 200}, true},
+		{"lower", Format{Height: 720}, Format{Height: 1080}, false},
+		{"higher bitrate", Format{Height: 1080, Bitrate: 300}, Format{Height: 1080, Bitrate: 200}, true},
+		{"same", Format{Height: 1080, Bitrate: 200}, Format{Height: 1080, Bitrate: 200}, false},
+		{"audio", Format{Bitrate: 160000}, Format{Bitrate: 128000}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := betterFormat(tt.a, tt.b); got != tt.want {
+				t.Errorf("betterFormat() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestYoutube_Sync(t *testing.T) {
+	media := append(append([]byte{}, mp4Header...), bytes.Repeat([]byte{1}, 100)...)
+	server := newMediaServer(media)
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "youtube")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	archive, err := OpenArchive(filepath.Join(dir, "archive.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sync := func(policy UpgradePolicy, streams []stream) (ArchiveEntry, SyncAction) {
+		y := NewYoutube(false)
+		y.VideoID = "BaW_jenozKc"
+		y.Archive, y.Upgrade = archive, policy
+		y.StreamList = streams
+		entry, action, err := y.Sync(context.Background(), DownloadOptions{OutputDir: dir})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return entry, action
+	}
+	sd := stream{ItagNo: 18, Type: "video/mp4", Title: "talk", Height: 360, URL: server.URL}
+	hd := stream{ItagNo: 22, Type: "video/mp4", Title: "talk", Height: 720, URL: server.URL}
+	audio := stream{ItagNo: 140, Type: "audio/mp4", Title: "talk", Bitrate: 128000, Adaptive: true, URL: server.URL}
+
+	entry, action := sync(UpgradeBetter, []stream{sd, audio})
+	if action != SyncDownloaded || entry.Format.ItagNo != 18 || entry.Title != "talk" {
+		t.Fatalf("first Sync() = %+v, %s", entry, action)
+	}
+	// only a better format of the same kind is an upgrade
+	if _, action := sync(UpgradeBetter, []stream{sd, audio}); action != SyncSkipped {
+		t.Errorf("Sync() without a better format = %s", action)
+	}
+	if _, action := sync(UpgradeNever, []stream{hd, sd}); action != SyncSkipped {
+		t.Errorf("Sync() never upgrading = %s", action)
+	}
+	entry, action = sync(UpgradeBetter, []stream{sd, hd, audio})
+	if action != SyncUpgraded || entry.Format.ItagNo != 22 {
+		t.Errorf("Sync() with a better format = %+v, %s", entry, action)
+	}
+	if got, _ := archive.Entry("BaW_jenozKc"); got.Format.ItagNo != 22 {
+		t.Errorf("the upgrade wasn't recorded: %+v", got)
+	}
+	if got, _ := ioutil.ReadFile(entry.Path); !bytes.Equal(got, media) {
+		t.Error("the upgrade wasn't downloaded")
+	}
+
+	if _, _, err := NewYoutube(false).Sync(context.Background(), DownloadOptions{}); err != ErrNoArchive {
+		t.Errorf("Sync() without archive = %v", err)
+	}
+	if err := (Options{Upgrade: "sometimes"}).Validate(); err == nil {
+		t.Error("Validate() accepted an unknown upgrade policy")
+	}
+}
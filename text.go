@@ -0,0 +1,164 @@
+package youtube
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// compositions lists, by combining mark, the pairs of a letter and of its
+// precomposed form with the mark, for the Latin scripts. Titles typed on
+// some systems, macOS notably, carry the decomposed forms.
+var compositions = map[rune]string{
+	// combining grave accent
+	0x0300: "AÀEÈIÌOÒUÙaàeèiìoòuùÜǛüǜNǸnǹĒḔēḕŌṐōṑWẀwẁÂẦâầĂẰăằÊỀêềÔỒôồƠỜơờƯỪưừYỲyỳ",
+	// combining acute accent
+	0x0301: "AÁEÉIÍOÓUÚYÝaáeéiíoóuúyýCĆcćLĹlĺNŃnńRŔrŕSŚsśZŹzźÜǗüǘGǴgǵÅǺåǻÆǼæǽØǾøǿÇḈçḉĒḖēḗÏḮïḯKḰkḱMḾmḿÕṌõṍŌṒōṓPṔpṕŨṸũṹWẂwẃÂẤâấĂẮăắÊẾêếÔỐôốƠỚơớƯỨưứ",
+	// combining circumflex accent
+	0x0302: "AÂEÊIÎOÔUÛaâeêiîoôuûCĈcĉGĜgĝHĤhĥJĴjĵSŜsŝWŴwŵYŶyŷZẐzẑẠẬạậẸỆẹệỌỘọộ",
+	// combining tilde
+	0x0303: "AÃNÑOÕaãnñoõIĨiĩUŨuũVṼvṽÂẪâẫĂẴăẵEẼeẽÊỄêễÔỖôỗƠỠơỡƯỮưữYỸyỹ",
+	// combining macron
+	0x0304: "AĀaāEĒeēIĪiīOŌoōUŪuūÜǕüǖÄǞäǟȦǠȧǡÆǢæǣǪǬǫǭÖȪöȫÕȬõȭȮȰȯȱYȲyȳGḠgḡḶḸḷḹṚṜṛṝ",
+	// combining breve
+	0x0306: "AĂaăEĔeĕGĞgğIĬiĭOŎoŏUŬuŭȨḜȩḝẠẶạặ",
+	// combining dot above
+	0x0307: "CĊcċEĖeėGĠgġIİZŻzżAȦaȧOȮoȯBḂbḃDḊdḋFḞfḟHḢhḣMṀmṁNṄnṅPṖpṗRṘrṙSṠsṡŚṤśṥŠṦšṧṢṨṣṩTṪtṫWẆwẇXẊxẋYẎyẏſẛ",
+	// combining diaeresis
+	0x0308: "AÄEËIÏOÖUÜaäeëiïoöuüyÿYŸHḦhḧÕṎõṏŪṺūṻWẄwẅXẌxẍtẗ",
+	// combining hook above
+	0x0309: "AẢaảÂẨâẩĂẲăẳEẺeẻÊỂêểIỈiỉOỎoỏÔỔôổƠỞơởUỦuủƯỬưửYỶyỷ",
+	// combining ring above
+	0x030A: "AÅaåUŮuůwẘyẙ",
+	// combining double acute accent
+	0x030B: "OŐoőUŰuű",
+	// combining caron
+	0x030C: "CČcčDĎdďEĚeěLĽlľNŇnňRŘrřSŠsšTŤtťZŽzžAǍaǎIǏiǐOǑoǒUǓuǔÜǙüǚGǦgǧKǨkǩƷǮʒǯjǰHȞhȟ",
+	// combining double grave accent
+	0x030F: "AȀaȁEȄeȅIȈiȉOȌoȍRȐrȑUȔuȕ",
+	// combining inverted breve
+	0x0311: "AȂaȃEȆeȇIȊiȋOȎoȏRȒrȓUȖuȗ",
+	// combining horn
+	0x031B: "OƠoơUƯuư",
+	// combining dot below
+	0x0323: "BḄbḅDḌdḍHḤhḥKḲkḳLḶlḷMṂmṃNṆnṇRṚrṛSṢsṣTṬtṭVṾvṿWẈwẉZẒzẓAẠaạEẸeẹIỊiịOỌoọƠỢơợUỤuụƯỰưựYỴyỵ",
+	// combining diaeresis below
+	0x0324: "UṲuṳ",
+	// combining ring below
+	0x0325: "AḀaḁ",
+	// combining comma below
+	0x0326: "SȘsșTȚtț",
+	// combining cedilla
+	0x0327: "CÇcçGĢgģKĶkķLĻlļNŅnņRŖrŗSŞsşTŢtţEȨeȩDḐdḑHḨhḩ",
+	// combining ogonek
+	0x0328: "AĄaąEĘeęIĮiįUŲuųOǪoǫ",
+	// combining circumflex accent below
+	0x032D: "DḒdḓEḘeḙLḼlḽNṊnṋTṰtṱUṶuṷ",
+	// combining breve below
+	0x032E: "HḪhḫ",
+	// combining tilde below
+	0x0330: "EḚeḛIḬiḭUṴuṵ",
+	// combining macron below
+	0x0331: "BḆbḇDḎdḏKḴkḵLḺlḻNṈnṉRṞrṟTṮtṯZẔzẕhẖ",
+}
+
+// transliterations spell the letters without decomposition in ASCII.
+var transliterations = map[rune]string{
+	'Æ': "AE", 'æ': "ae", 'Œ': "OE", 'œ': "oe", 'ß': "ss", 'Ø': "O", 'ø': "o",
+	'Ł': "L", 'ł': "l", 'Đ': "D", 'đ': "d", 'Þ': "Th", 'þ': "th", 'Ð': "D", 'ð': "d", 'ı': "i",
+	'‘': "'", '’': "'", '“': `"`, '”': `"`, '–': "-", '—': "-", '…': "...", '\u00a0': " ",
+}
+
+var (
+	// composed maps a letter and a combining mark to their precomposed form.
+	composed = make(map[[2]rune]rune)
+	// decomposed maps a precomposed letter to the letter without its mark.
+	decomposed = make(map[rune]rune)
+)
+
+func init() {
+	for mark, pairs := range compositions {
+		runes := []rune(pairs)
+		for i := 0; i+1 < len(runes); i += 2 {
+			composed[[2]rune{runes[i], mark}] = runes[i+1]
+			decomposed[runes[i+1]] = runes[i]
+		}
+	}
+}
+
+// normalizeText makes text, such as a title, safe to display and to log: the
+// invalid UTF-8 sequences, the control characters but line breaks and tabs,
+// and the bidirectional controls, which can reorder the text around them,
+// are removed, and the letters are composed with their marks (NFC) for the
+// Latin scripts.
+func normalizeText(text string) string {
+	if !utf8.ValidString(text) {
+		text = strings.ToValidUTF8(text, "")
+	}
+	runes := make([]rune, 0, len(text))
+	for _, r := range text {
+		if (unicode.IsControl(r) && r != '\n' && r != '\t') || isBidiControl(r) {
+			continue
+		}
+		if n := len(runes); n > 0 {
+			if c, ok := composed[[2]rune{runes[n-1], r}]; ok {
+				runes[n-1] = c
+				continue
+			}
+		}
+		runes = append(runes, r)
+	}
+	return string(runes)
+}
+
+// fileNameText normalizes text for a file name, the zero-width characters
+// are removed too, they would make names which look the same differ.
+func fileNameText(text string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '\u200b', '\u200c', '\u200d', '\u2060', '\ufeff':
+			return -1
+		}
+		return r
+	}, normalizeText(text))
+}
+
+// isBidiControl reports whether r is a bidirectional mark, embedding,
+// override or isolate.
+func isBidiControl(r rune) bool {
+	return r == '\u061c' || r == '\u200e' || r == '\u200f' || (r >= '\u202a' && r <= '\u202e') || (r >= '\u2066' && r <= '\u2069')
+}
+
+// transliterate spells text in ASCII: the letters lose their marks, some
+// letters and punctuation are spelled out and the other characters are
+// replaced by an underscore, once per run.
+func transliterate(text string) string {
+	var b strings.Builder
+	replaced := false
+	for _, r := range text {
+		for r >= utf8.RuneSelf {
+			base, ok := decomposed[r]
+			if !ok {
+				break
+			}
+			r = base
+		}
+		switch spelled, ok := transliterations[r]; {
+		case r < utf8.RuneSelf:
+			b.WriteRune(r)
+		case ok:
+			b.WriteString(spelled)
+		case unicode.Is(unicode.Mn, r):
+			// a combining mark left over
+			continue
+		default:
+			if !replaced {
+				b.WriteByte('_')
+			}
+			replaced = true
+			continue
+		}
+		replaced = false
+	}
+	return b.String()
+}
@@ -0,0 +1,52 @@
+package youtube
+
+import "testing"
+
+func TestNormalizeText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "decomposed accents", text: "Cafe\u0301 cre\u0300me", want: "Café crème"},
+		{name: "stacked marks", text: "Vie\u0323\u0302t", want: "Việt"},
+		{name: "precomposed", text: "Café", want: "Café"},
+		{name: "controls", text: "a\x00b\x1bc\u0085d\ne", want: "abcd\ne"},
+		{name: "bidi controls", text: "\u202eevil\u202c \u200fname\u2067", want: "evil name"},
+		{name: "invalid utf-8", text: "a\xffb", want: "ab"},
+		{name: "emoji kept", text: "👨\u200d👩\u200d👧 family", want: "👨\u200d👩\u200d👧 family"},
+		{name: "mark without composition", text: "q\u0301", want: "q\u0301"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeText(tt.text); got != tt.want {
+				t.Errorf("normalizeText(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileNameText(t *testing.T) {
+	if got, want := fileNameText("zero\u200bwidth 👨\u200d👩\ufeff"), "zerowidth 👨👩"; got != want {
+		t.Errorf("fileNameText() = %q, want %q", got, want)
+	}
+}
+
+func TestTransliterate(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Café crème", "Cafe creme"},
+		{"Việt Nam", "Viet Nam"},
+		{"Straße – Œuvre", "Strasse - OEuvre"},
+		{"Łódź", "Lodz"},
+		{"日本語 talk 🎉", "_ talk _"},
+		{"q\u0301", "q"},
+	}
+	for _, tt := range tests {
+		if got := transliterate(tt.text); got != tt.want {
+			t.Errorf("transliterate(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
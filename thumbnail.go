@@ -0,0 +1,230 @@
+package youtube
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// defaultThumbnailCacheSize holds a few hundred thumbnails, a grid of results.
+const defaultThumbnailCacheSize = 32 << 20
+
+// Thumbnail is a thumbnail of the decoded video.
+type Thumbnail struct {
+	URL    string
+	Width  int
+	Height int
+}
+
+// Thumbnails returns the thumbnails of the decoded video, from the smallest to the largest.
+func (y *Youtube) Thumbnails() []Thumbnail {
+	if y.playerResponse == nil {
+		return nil
+	}
+	var thumbnails []Thumbnail
+	for _, t := range y.playerResponse.VideoDetails.Thumbnail.Thumbnails {
+		thumbnails = append(thumbnails, Thumbnail{URL: t.URL, Width: t.Width, Height: t.Height})
+	}
+	return thumbnails
+}
+
+// ThumbnailCache caches the thumbnails fetched by Youtube.FetchThumbnail, so
+// refreshing a grid of videos doesn't download hundreds of images again.
+// Share it between the Youtube of a process through Options.ThumbnailCache.
+//
+// The cached thumbnails are revalidated with their ETag or Last-Modified
+// date once older than FreshFor, an unchanged thumbnail costs a 304 answer.
+// The images are stored by the hash of their content, the same image served
+// at several urls is kept once. The least recently used thumbnails are
+// evicted past MaxBytes.
+type ThumbnailCache struct {
+	MaxBytes int64
+	FreshFor time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	blobs   map[string]*thumbnailBlob
+	size    int64
+	flight  flightGroup
+}
+
+// thumbnailEntry is the cached thumbnail of a url.
+type thumbnailEntry struct {
+	url          string
+	hash         string
+	etag         string
+	lastModified string
+	checkedAt    time.Time
+}
+
+// thumbnailBlob is an image shared by the entries of the same hash.
+type thumbnailBlob struct {
+	data []byte
+	refs int
+}
+
+// NewThumbnailCache returns a cache of at most maxBytes of images,
+// defaultThumbnailCacheSize when zero, revalidating them on every fetch.
+func NewThumbnailCache(maxBytes int64) *ThumbnailCache {
+	if maxBytes == 0 {
+		maxBytes = defaultThumbnailCacheSize
+	}
+	return &ThumbnailCache{MaxBytes: maxBytes}
+}
+
+// FetchThumbnail returns the image at url, through the ThumbnailCache when set.
+// The concurrent fetches of the same url share a single request, canceled
+// once all of them gave up.
+func (y *Youtube) FetchThumbnail(ctx context.Context, url string) ([]byte, error) {
+	c := y.ThumbnailCache
+	if c == nil {
+		data, _, err := y.fetchThumbnail(ctx, url, nil)
+		return data, err
+	}
+
+	entry, data, ok := c.lookup(url)
+	if ok && time.Since(entry.checkedAt) < c.FreshFor {
+		return data, nil
+	}
+	val, err, _ := c.flight.doContext(ctx, url, func(ctx context.Context) (interface{}, error) {
+		fetched, resp, err := y.fetchThumbnail(ctx, url, entry)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusNotModified {
+			if data, ok := c.touch(url); ok {
+				return data, nil
+			}
+			// evicted meanwhile, fetch it unconditionally
+			if fetched, resp, err = y.fetchThumbnail(ctx, url, nil); err != nil {
+				return nil, err
+			}
+		}
+		c.store(url, fetched, resp.Header)
+		return fetched, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return val.([]byte), nil
+}
+
+// fetchThumbnail requests url, conditionally when cached is not nil, in which
+// case the answer may be 304 without data.
+func (y *Youtube) fetchThumbnail(ctx context.Context, url string, cached *thumbnailEntry) ([]byte, *http.Response, error) {
+	httpClient, err := y.getHTTPClient()
+	if err != nil {
+		return nil, nil, err
+	}
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	if cached != nil {
+		if cached.etag != "" {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+		if cached.lastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.lastModified)
+		}
+	}
+	resp, err := httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		return nil, resp, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, nil, ErrUnexpectedStatusCode(resp.StatusCode)
+	}
+	data, err := ioutil.ReadAll(newLimitedReader(resp.Body, y.Limits.MaxResponseSize, "MaxResponseSize"))
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, resp, nil
+}
+
+// lookup returns a copy of the entry of url and its image.
+func (c *ThumbnailCache) lookup(url string) (*thumbnailEntry, []byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[url]
+	if !ok {
+		return nil, nil, false
+	}
+	entry := *elem.Value.(*thumbnailEntry)
+	return &entry, c.blobs[entry.hash].data, true
+}
+
+// touch marks the entry of url as revalidated and returns its image.
+func (c *ThumbnailCache) touch(url string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[url]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*thumbnailEntry)
+	entry.checkedAt = time.Now()
+	c.lru.MoveToFront(elem)
+	return c.blobs[entry.hash].data, true
+}
+
+// store caches data as the image of url and evicts the least recently used
+// thumbnails past MaxBytes. Images larger than MaxBytes aren't cached.
+func (c *ThumbnailCache) store(url string, data []byte, header http.Header) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[string]*list.Element)
+		c.blobs = make(map[string]*thumbnailBlob)
+		c.lru = list.New()
+	}
+	if elem, ok := c.entries[url]; ok {
+		c.remove(elem)
+	}
+	if int64(len(data)) > c.MaxBytes {
+		return
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	blob, ok := c.blobs[hash]
+	if !ok {
+		blob = &thumbnailBlob{data: data}
+		c.blobs[hash] = blob
+		c.size += int64(len(data))
+	}
+	blob.refs++
+	c.entries[url] = c.lru.PushFront(&thumbnailEntry{
+		url:          url,
+		hash:         hash,
+		etag:         header.Get("ETag"),
+		lastModified: header.Get("Last-Modified"),
+		checkedAt:    time.Now(),
+	})
+
+	for c.size > c.MaxBytes {
+		c.remove(c.lru.Back())
+	}
+}
+
+// remove drops the entry of elem, and its image once no other entry uses it.
+func (c *ThumbnailCache) remove(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*thumbnailEntry)
+	delete(c.entries, entry.url)
+	blob := c.blobs[entry.hash]
+	if blob.refs--; blob.refs == 0 {
+		delete(c.blobs, entry.hash)
+		c.size -= int64(len(blob.data))
+	}
+}
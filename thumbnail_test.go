@@ -0,0 +1,155 @@
+package youtube
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// thumbnailServer serves an image per path with an ETag and counts the
+// full and the revalidated answers.
+type thumbnailServer struct {
+	images map[string][]byte
+
+	mu          sync.Mutex
+	full        int
+	revalidated int
+}
+
+func (s *thumbnailServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	image, ok := s.images[r.URL.Path]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	etag := `"` + r.URL.Path + `"`
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		s.revalidated++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	s.full++
+	w.Write(image)
+}
+
+func (s *thumbnailServer) counts() (int, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.full, s.revalidated
+}
+
+func TestYoutube_FetchThumbnail(t *testing.T) {
+	a, b := bytes.Repeat([]byte{'a'}, 100), bytes.Repeat([]byte{'b'}, 100)
+	ts := &thumbnailServer{images: map[string][]byte{"/a.jpg": a, "/a-copy.jpg": a, "/b.jpg": b}}
+	server := httptest.NewServer(ts)
+	defer server.Close()
+
+	ctx := context.Background()
+	cache := NewThumbnailCache(150)
+	y, err := NewYoutubeWithOptions(Options{ThumbnailCache: cache})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fetch := func(path string, want []byte) {
+		t.Helper()
+		got, err := y.FetchThumbnail(ctx, server.URL+path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("FetchThumbnail(%s) = %q", path, got)
+		}
+	}
+
+	fetch("/a.jpg", a)
+	fetch("/a.jpg", a)
+	if full, revalidated := ts.counts(); full != 1 || revalidated != 1 {
+		t.Errorf("%d full and %d revalidated answers, want the cached thumbnail to be revalidated", full, revalidated)
+	}
+
+	// the same image at another url is stored once
+	fetch("/a-copy.jpg", a)
+	if cache.size != 100 || len(cache.entries) != 2 {
+		t.Errorf("cache holds %d bytes for %d urls, want a single copy of the image", cache.size, len(cache.entries))
+	}
+
+	// b doesn't fit along a, the least recently used a is evicted
+	fetch("/b.jpg", b)
+	if cache.size != 100 || len(cache.entries) != 1 {
+		t.Errorf("cache holds %d bytes for %d urls, want only b", cache.size, len(cache.entries))
+	}
+
+	cache.FreshFor = time.Hour
+	full, revalidated := ts.counts()
+	fetch("/b.jpg", b)
+	if f, r := ts.counts(); f != full || r != revalidated {
+		t.Error("a fresh thumbnail should be served without request")
+	}
+
+	if _, err := y.FetchThumbnail(ctx, server.URL+"/missing.jpg"); err != ErrUnexpectedStatusCode(http.StatusNotFound) {
+		t.Errorf("FetchThumbnail() error = %v, want ErrUnexpectedStatusCode", err)
+	}
+}
+
+func TestYoutube_FetchThumbnail_CanceledCaller(t *testing.T) {
+	requested, release := make(chan struct{}), make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(requested)
+		<-release
+		w.Write([]byte("image"))
+	}))
+	defer server.Close()
+
+	y, err := NewYoutubeWithOptions(Options{ThumbnailCache: NewThumbnailCache(0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := make(chan error, 1)
+	go func() {
+		_, err := y.FetchThumbnail(ctx, server.URL+"/a.jpg")
+		canceled <- err
+	}()
+	<-requested
+	waiting := make(chan []byte, 1)
+	go func() {
+		data, _ := y.FetchThumbnail(context.Background(), server.URL+"/a.jpg")
+		waiting <- data
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	// the first caller gives up, the request goes on for the other one
+	cancel()
+	if err := <-canceled; err != context.Canceled {
+		t.Errorf("canceled FetchThumbnail() error = %v, want %v", err, context.Canceled)
+	}
+	close(release)
+	if data := <-waiting; string(data) != "image" {
+		t.Errorf("FetchThumbnail() = %q, want the image", data)
+	}
+}
+
+func TestYoutube_Thumbnails(t *testing.T) {
+	y := NewYoutube(false)
+	if y.Thumbnails() != nil {
+		t.Error("Thumbnails() should be empty before a decode")
+	}
+	y.playerResponse = &PlayerResponseData{}
+	y.playerResponse.VideoDetails.Thumbnail.Thumbnails = append(y.playerResponse.VideoDetails.Thumbnail.Thumbnails, struct {
+		URL    string `json:"url"`
+		Width  int    `json:"width"`
+		Height int    `json:"height"`
+	}{"https://i.ytimg.com/vi/rFejpH_tAHM/default.jpg", 120, 90})
+	want := Thumbnail{URL: "https://i.ytimg.com/vi/rFejpH_tAHM/default.jpg", Width: 120, Height: 90}
+	if got := y.Thumbnails(); len(got) != 1 || got[0] != want {
+		t.Errorf("Thumbnails() = %v, want [%v]", got, want)
+	}
+}
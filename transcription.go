@@ -0,0 +1,118 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package youtube
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// minIntelligibleBitrate is the bitrate under which speech recognition degrades.
+const minIntelligibleBitrate = 48000
+
+// defaultTranscriptionSampleRate is the sample rate expected by most speech recognition models.
+const defaultTranscriptionSampleRate = 16000
+
+// TranscriptionOptions configures the download of the audio of a video for a
+// speech recognition system.
+type TranscriptionOptions struct {
+	OutputDir  string
+	OutputFile string
+	// Converter, when set, converts the audio into a mono WAV file, the audio
+	// is saved as served, usually opus in webm, otherwise.
+	Converter AudioConverter
+	// SampleRate of the converted audio, 16 kHz when zero.
+	SampleRate int
+	Retries    int
+}
+
+// DownloadTranscriptionAudio downloads the smallest audio format still
+// intelligible for speech recognition, converted by opts.Converter when set,
+// and returns the path of the file written.
+func (y *Youtube) DownloadTranscriptionAudio(ctx context.Context, opts TranscriptionOptions) (string, error) {
+	audio, ok := y.transcriptionStream()
+	if !ok {
+		return "", ErrNoAudioFormat
+	}
+	dlOpts := DownloadOptions{
+		OutputDir:  opts.OutputDir,
+		OutputFile: opts.OutputFile,
+		ItagNo:     audio.ItagNo,
+		Retries:    opts.Retries,
+	}
+	if opts.Converter == nil {
+		return y.download(ctx, dlOpts)
+	}
+
+	dlOpts.OutputFile = ""
+	srcFile, err := y.download(ctx, dlOpts)
+	if err != nil {
+		return srcFile, err
+	}
+	defer os.Remove(srcFile)
+
+	destFile := filepath.Join(filepath.Dir(srcFile), SanitizeFilename(opts.OutputFile))
+	if opts.OutputFile == "" {
+		destFile = strings.TrimSuffix(srcFile, filepath.Ext(srcFile)) + ".wav"
+	}
+	sampleRate := opts.SampleRate
+	if sampleRate == 0 {
+		sampleRate = defaultTranscriptionSampleRate
+	}
+	return destFile, opts.Converter.ConvertAudio(ctx, srcFile, destFile, sampleRate, 1)
+}
+
+// TranscriptionAudio returns the audio downloaded by DownloadTranscriptionAudio
+// instead of a file, the output directory and file of opts are ignored.
+func (y *Youtube) TranscriptionAudio(ctx context.Context, opts TranscriptionOptions) ([]byte, error) {
+	dir, err := ioutil.TempDir("", "youtube-transcription")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	opts.OutputDir, opts.OutputFile = dir, ""
+	path, err := y.DownloadTranscriptionAudio(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadFile(path)
+}
+
+// transcriptionStream returns the audio only stream with the lowest bitrate
+// still intelligible, opus being preferred for its quality at low bitrates.
+// The best audio stream is returned when all of them are under the threshold.
+func (y *Youtube) transcriptionStream() (stream, bool) {
+	var best stream
+	found := false
+	better := func(s stream) bool {
+		if !found {
+			return true
+		}
+		sOK, bestOK := s.Bitrate >= minIntelligibleBitrate, best.Bitrate >= minIntelligibleBitrate
+		if sOK != bestOK {
+			return sOK
+		}
+		if !sOK {
+			return s.Bitrate > best.Bitrate
+		}
+		if sOpus, bestOpus := isOpus(s.Type), isOpus(best.Type); sOpus != bestOpus {
+			return sOpus
+		}
+		return s.Bitrate < best.Bitrate
+	}
+	for _, s := range y.StreamList {
+		if s.Adaptive && strings.HasPrefix(s.Type, "audio/") && better(s) {
+			best, found = s, true
+		}
+	}
+	return best, found
+}
+
+func isOpus(mimeType string) bool {
+	return strings.Contains(mimeType, "opus")
+}
@@ -0,0 +1,115 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package youtube
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestYoutube_transcriptionStream(t *testing.T) {
+	tests := []struct {
+		name     string
+		streams  []stream
+		wantItag int
+		wantOK   bool
+	}{
+		{"smallest opus", []stream{
+			{ItagNo: 140, Type: "audio/mp4", Bitrate: 130000, Adaptive: true},
+			{ItagNo: 251, Type: `audio/webm; codecs="opus"`, Bitrate: 160000, Adaptive: true},
+			{ItagNo: 249, Type: `audio/webm; codecs="opus"`, Bitrate: 50000, Adaptive: true},
+			{ItagNo: 250, Type: `audio/webm; codecs="opus"`, Bitrate: 70000, Adaptive: true},
+		}, 249, true},
+		{"opus preferred", []stream{
+			{ItagNo: 139, Type: "audio/mp4", Bitrate: 48000, Adaptive: true},
+			{ItagNo: 250, Type: `audio/webm; codecs="opus"`, Bitrate: 70000, Adaptive: true},
+		}, 250, true},
+		{"too small", []stream{
+			{ItagNo: 599, Type: "audio/mp4", Bitrate: 31000, Adaptive: true},
+			{ItagNo: 600, Type: `audio/webm; codecs="opus"`, Bitrate: 35000, Adaptive: true},
+			{ItagNo: 140, Type: "audio/mp4", Bitrate: 130000, Adaptive: true},
+		}, 140, true},
+		{"under the threshold only", []stream{
+			{ItagNo: 599, Type: "audio/mp4", Bitrate: 31000, Adaptive: true},
+			{ItagNo: 600, Type: `audio/webm; codecs="opus"`, Bitrate: 35000, Adaptive: true},
+		}, 600, true},
+		{"no audio", []stream{
+			{ItagNo: 18, Type: "video/mp4", Bitrate: 500000},
+		}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			y := NewYoutube(false)
+			y.StreamList = tt.streams
+			got, ok := y.transcriptionStream()
+			if ok != tt.wantOK || got.ItagNo != tt.wantItag {
+				t.Errorf("transcriptionStream() = %d, %v, want %d, %v", got.ItagNo, ok, tt.wantItag, tt.wantOK)
+			}
+		})
+	}
+}
+
+// fakeConverter writes the name of the converted file into the destination.
+type fakeConverter struct {
+	srcFile    string
+	sampleRate int
+}
+
+func (c *fakeConverter) ConvertAudio(ctx context.Context, srcFile, destFile string, sampleRate, channels int) error {
+	c.srcFile, c.sampleRate = srcFile, sampleRate
+	return ioutil.WriteFile(destFile, []byte("RIFF"), 0644)
+}
+
+func TestYoutube_DownloadTranscriptionAudio(t *testing.T) {
+	audio := append([]byte("\x1aE\xdf\xa3"), bytes.Repeat([]byte{0}, 1024)...)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "audio/webm")
+		w.Write(audio)
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "youtube")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	y := NewYoutube(false)
+	y.StreamList = []stream{
+		{ItagNo: 18, Type: "video/mp4", URL: server.URL + "/18", Title: "talk"},
+		{ItagNo: 249, Type: `audio/webm; codecs="opus"`, URL: server.URL + "/249", Title: "talk", Bitrate: 50000, Adaptive: true},
+	}
+	ctx := context.Background()
+
+	path, err := y.DownloadTranscriptionAudio(ctx, TranscriptionOptions{OutputDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != filepath.Join(dir, "talk.webm") {
+		t.Errorf("DownloadTranscriptionAudio() = %s, want the audio as served", path)
+	}
+
+	converter := &fakeConverter{}
+	path, err = y.DownloadTranscriptionAudio(ctx, TranscriptionOptions{OutputDir: dir, Converter: converter})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != filepath.Join(dir, "talk.wav") || converter.sampleRate != 16000 {
+		t.Errorf("DownloadTranscriptionAudio() = %s at %d Hz, want talk.wav at 16000 Hz", path, converter.sampleRate)
+	}
+	if _, err := os.Stat(converter.srcFile); !os.IsNotExist(err) {
+		t.Error("the downloaded audio should be removed once converted")
+	}
+
+	data, err := y.TranscriptionAudio(ctx, TranscriptionOptions{})
+	if err != nil || !bytes.Equal(data, audio) {
+		t.Errorf("TranscriptionAudio() = %d bytes, %v, want the %d bytes of the audio", len(data), err, len(audio))
+	}
+}
@@ -0,0 +1,58 @@
+package youtube
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// batchMaxIdleConnsPerHost keeps enough idle connections to the stream
+// servers for the parallel downloads of a batch to reuse them.
+const batchMaxIdleConnsPerHost = 32
+
+// NewBatchHTTPClient returns an http client tuned for long batch runs, it
+// keeps connections alive and pools them generously. Set it as the HTTPClient
+// of every Youtube of the batch so they all share the same connection pool.
+func NewBatchHTTPClient(socks5Proxy string) (*http.Client, error) {
+	httpTransport, err := newTransport(socks5Proxy)
+	if err != nil {
+		return nil, err
+	}
+	httpTransport.MaxIdleConns = 0
+	httpTransport.MaxIdleConnsPerHost = batchMaxIdleConnsPerHost
+	httpTransport.IdleConnTimeout = 90 * time.Second
+	return &http.Client{Transport: httpTransport}, nil
+}
+
+func (y *Youtube) getHTTPClient() (*http.Client, error) {
+	return y.getHTTPClientWithProxy(y.Socks5Proxy)
+}
+
+// getHTTPClientWithProxy returns the client used for requests through
+// socks5Proxy, clients are built once and reused to keep their connections.
+func (y *Youtube) getHTTPClientWithProxy(socks5Proxy string) (*http.Client, error) {
+	if y.HTTPClient != nil && socks5Proxy == y.Socks5Proxy {
+		return y.Limits.withRedirects(y.HTTPClient), nil
+	}
+
+	y.clientsMu.Lock()
+	defer y.clientsMu.Unlock()
+	if httpClient, ok := y.clients[socks5Proxy]; ok {
+		return y.Limits.withRedirects(httpClient), nil
+	}
+
+	httpTransport, err := newTransport(socks5Proxy)
+	if err != nil {
+		return nil, err
+	}
+	httpClient := &http.Client{Transport: httpTransport, Jar: y.session().jar}
+	if len(socks5Proxy) > 0 {
+		y.log(fmt.Sprintf("Using http with proxy %s.", socks5Proxy))
+	}
+
+	if y.clients == nil {
+		y.clients = make(map[string]*http.Client)
+	}
+	y.clients[socks5Proxy] = httpClient
+	return y.Limits.withRedirects(httpClient), nil
+}
@@ -0,0 +1,19 @@
+package youtube
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// newTransport returns a transport without custom dialer, as the js/wasm
+// http.Transport only performs its requests through the fetch API in that case.
+func newTransport(socks5Proxy string) (*http.Transport, error) {
+	if len(socks5Proxy) > 0 {
+		return nil, errors.New("socks5 proxies are not supported under js/wasm")
+	}
+	return &http.Transport{
+		MaxIdleConns:    100,
+		IdleConnTimeout: 60 * time.Second,
+	}, nil
+}
@@ -0,0 +1,44 @@
+//go:build !js
+// +build !js
+
+package youtube
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+func newTransport(socks5Proxy string) (*http.Transport, error) {
+	httpTransport := &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       60 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+
+	if len(socks5Proxy) == 0 {
+		return httpTransport, nil
+	}
+
+	dialer, err := proxy.SOCKS5("tcp", socks5Proxy, nil, proxy.Direct)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "can't connect to the proxy:", err)
+		return nil, err
+	}
+	// set our socks5 as the dialer
+	dc := dialer.(interface {
+		DialContext(ctx context.Context, network, addr string) (net.Conn, error)
+	})
+	httpTransport.DialContext = dc.DialContext
+	return httpTransport, nil
+}
@@ -0,0 +1,47 @@
+package youtube
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestYoutube_getHTTPClientWithProxy(t *testing.T) {
+	y := NewYoutube(false)
+	first, err := y.getHTTPClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := y.getHTTPClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Error("the http client should be reused between requests")
+	}
+
+	proxied, err := y.getHTTPClientWithProxy("10.10.10.10:7878")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proxied == first {
+		t.Error("a different proxy should use a different http client")
+	}
+}
+
+func TestNewBatchHTTPClient(t *testing.T) {
+	batchClient, err := NewBatchHTTPClient("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := batchClient.Transport.(*http.Transport).MaxIdleConnsPerHost; got != batchMaxIdleConnsPerHost {
+		t.Errorf("MaxIdleConnsPerHost = %d, want %d", got, batchMaxIdleConnsPerHost)
+	}
+
+	y, err := NewYoutubeWithOptions(Options{HTTPClient: batchClient})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := y.getHTTPClient(); got != batchClient {
+		t.Error("the shared http client should be used")
+	}
+}
@@ -0,0 +1,141 @@
+package youtube
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// trashTimeFormat prefixes the names of the files in the trash with the time
+// they were put there, the cleanup reads it back.
+const trashTimeFormat = "20060102T150405.000000000"
+
+// Trash keeps the files replaced by the downloads, such as a video downloaded
+// again at a higher quality, instead of overwriting them. The files are moved
+// to Dir, their names prefixed with the time they were replaced. Share it
+// between the Youtube of a process through Options.Trash.
+//
+// The trash is cleaned up after each file put into it: the files older than
+// MaxAge are deleted, then the oldest ones until the trash holds MaxBytes at
+// most. A zero field doesn't bound the trash.
+type Trash struct {
+	Dir      string
+	MaxAge   time.Duration
+	MaxBytes int64
+
+	mu sync.Mutex
+}
+
+func (t *Trash) validate() ErrInvalidOptions {
+	if t == nil {
+		return nil
+	}
+	var errs ErrInvalidOptions
+	if t.Dir == "" {
+		errs = append(errs, ErrInvalidOption{Option: "Trash.Dir", Reason: "must not be empty"})
+	}
+	if t.MaxAge < 0 {
+		errs = append(errs, ErrInvalidOption{Option: "Trash.MaxAge", Reason: "must not be negative"})
+	}
+	if t.MaxBytes < 0 {
+		errs = append(errs, ErrInvalidOption{Option: "Trash.MaxBytes", Reason: "must not be negative"})
+	}
+	return errs
+}
+
+// Put moves the file at path into the trash and cleans the trash up, it
+// returns the path of the file in the trash.
+func (t *Trash) Put(path string) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := os.MkdirAll(t.Dir, 0755); err != nil {
+		return "", err
+	}
+	dest := filepath.Join(t.Dir, time.Now().UTC().Format(trashTimeFormat)+" "+filepath.Base(path))
+	if err := moveFile(path, dest); err != nil {
+		return "", err
+	}
+	return dest, t.clean(time.Now())
+}
+
+// Clean deletes the files of the trash past MaxAge and MaxBytes.
+func (t *Trash) Clean() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.clean(time.Now())
+}
+
+func (t *Trash) clean(now time.Time) error {
+	entries, err := ioutil.ReadDir(t.Dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	type trashed struct {
+		name string
+		at   time.Time
+		size int64
+	}
+	var files []trashed
+	var total int64
+	for _, entry := range entries {
+		i := strings.IndexByte(entry.Name(), ' ')
+		if !entry.Mode().IsRegular() || i < 0 {
+			continue
+		}
+		at, err := time.Parse(trashTimeFormat, entry.Name()[:i])
+		if err != nil {
+			// not put there by the trash
+			continue
+		}
+		files = append(files, trashed{name: entry.Name(), at: at, size: entry.Size()})
+		total += entry.Size()
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].at.Before(files[j].at) })
+
+	for _, file := range files {
+		expired := t.MaxAge > 0 && now.Sub(file.at) > t.MaxAge
+		if !expired && (t.MaxBytes == 0 || total <= t.MaxBytes) {
+			break
+		}
+		if err := os.Remove(filepath.Join(t.Dir, file.name)); err != nil {
+			return err
+		}
+		total -= file.size
+	}
+	return nil
+}
+
+// moveFile renames src to dest, copying it when they are on different
+// filesystems.
+func moveFile(src, dest string) error {
+	if err := os.Rename(src, dest); err == nil {
+		return nil
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dest)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dest)
+		return err
+	}
+	return os.Remove(src)
+}
@@ -0,0 +1,102 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package youtube
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTrash(t *testing.T) {
+	dir, err := ioutil.TempDir("", "youtube")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	trash := &Trash{Dir: filepath.Join(dir, "trash"), MaxBytes: 10}
+	os.MkdirAll(trash.Dir, 0755)
+	ioutil.WriteFile(filepath.Join(trash.Dir, "notes.txt"), bytes.Repeat([]byte{0}, 100), 0644)
+
+	var trashed []string
+	for _, name := range []string{"first.mp4", "second.mp4", "third.mp4"} {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte("12345"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		dest, err := trash.Put(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasSuffix(dest, " "+name) {
+			t.Errorf("Put(%s) = %s", name, dest)
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s is still in place", name)
+		}
+		trashed = append(trashed, dest)
+	}
+	// 15 bytes were put in a trash of 10, the oldest file is gone
+	if _, err := os.Stat(trashed[0]); !os.IsNotExist(err) {
+		t.Error("the oldest file was kept past MaxBytes")
+	}
+	for _, path := range append(trashed[1:], filepath.Join(trash.Dir, "notes.txt")) {
+		if _, err := os.Stat(path); err != nil {
+			t.Error(err)
+		}
+	}
+
+	trash.MaxAge = time.Hour
+	if err := trash.clean(time.Now().Add(2 * time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := ioutil.ReadDir(trash.Dir); len(entries) != 1 {
+		t.Errorf("%d files left in the trash, want the foreign file only", len(entries))
+	}
+
+	if err := (Options{Trash: &Trash{MaxAge: -1}}).Validate(); err == nil {
+		t.Error("Validate() accepted a trash without directory and a negative age")
+	}
+}
+
+func TestYoutube_download_Trash(t *testing.T) {
+	media := append(append([]byte{}, mp4Header...), bytes.Repeat([]byte{1}, 100)...)
+	server := newMediaServer(media)
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "youtube")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, resume := range []bool{false, true} {
+		destFile := filepath.Join(dir, "talk.mp4")
+		if err := ioutil.WriteFile(destFile, []byte("low quality"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		trash := &Trash{Dir: filepath.Join(dir, "trash")}
+		os.RemoveAll(trash.Dir)
+		y := NewYoutube(false)
+		y.Trash = trash
+		y.StreamList = []stream{{ItagNo: 18, Type: "video/mp4", URL: server.URL}}
+		if _, err := y.download(context.Background(), DownloadOptions{OutputDir: dir, OutputFile: "talk.mp4", Resume: resume}); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := ioutil.ReadFile(destFile); !bytes.Equal(got, media) {
+			t.Errorf("resume %v: the file wasn't replaced", resume)
+		}
+		entries, _ := ioutil.ReadDir(trash.Dir)
+		if len(entries) != 1 {
+			t.Fatalf("resume %v: %d files in the trash, want the replaced one", resume, len(entries))
+		}
+		if got, _ := ioutil.ReadFile(filepath.Join(trash.Dir, entries[0].Name())); string(got) != "low quality" {
+			t.Errorf("resume %v: trashed %q", resume, got)
+		}
+	}
+}
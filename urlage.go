@@ -0,0 +1,28 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package youtube
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// freshStream returns s, resolved again first when its url is older than
+// MaxURLAge. The formats are refreshed, bypassing the metadata cache.
+func (y *Youtube) freshStream(ctx context.Context, s stream) (stream, error) {
+	if y.MaxURLAge <= 0 || s.ResolvedAt.IsZero() || time.Since(s.ResolvedAt) < y.MaxURLAge {
+		return s, nil
+	}
+	y.log(fmt.Sprintf("url of itag %d resolved %s ago, refreshing it", s.ItagNo, time.Since(s.ResolvedAt)))
+	if err := y.RefreshFormats(ctx); err != nil {
+		return s, err
+	}
+	for _, fresh := range y.StreamList {
+		if fresh.ItagNo == s.ItagNo {
+			return fresh, nil
+		}
+	}
+	return s, ErrItagNotFound
+}
@@ -0,0 +1,56 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package youtube
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestYoutube_freshStream(t *testing.T) {
+	var requests int32
+	release := make(chan struct{})
+	close(release)
+	server := newVideoInfoServer(t, &requests, release)
+	defer server.Close()
+	defer func(url string) { baseURL = url }(baseURL)
+	baseURL = server.URL
+
+	cache, err := NewMetadataCache(time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	y, err := NewYoutubeWithOptions(Options{MetadataCache: cache, MaxURLAge: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err := y.DecodeURLWithContext(ctx, "rFejpH_tAHM"); err != nil {
+		t.Fatal(err)
+	}
+
+	fresh, err := y.freshStream(ctx, y.StreamList[0])
+	if err != nil || requests != 1 {
+		t.Fatalf("freshStream() error = %v, fetched %d times, a fresh url should be kept", err, requests)
+	}
+
+	stale := fresh
+	stale.ResolvedAt = time.Now().Add(-2 * time.Hour)
+	refreshed, err := y.freshStream(ctx, stale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests != 2 {
+		t.Errorf("fetched %d times, a stale url should be resolved again despite the cache", requests)
+	}
+	if refreshed.ItagNo != stale.ItagNo || time.Since(refreshed.ResolvedAt) > time.Minute {
+		t.Errorf("freshStream() = itag %d resolved at %s, want a fresh itag %d", refreshed.ItagNo, refreshed.ResolvedAt, stale.ItagNo)
+	}
+
+	stale.ItagNo = 22
+	if _, err := y.freshStream(ctx, stale); err != ErrItagNotFound {
+		t.Errorf("freshStream() error = %v, want ErrItagNotFound for a vanished format", err)
+	}
+}
@@ -0,0 +1,25 @@
+/*
+Package version holds the build information injected by the Makefile through -ldflags.
+*/
+package version
+
+var (
+	version   = "dev"
+	commit    = ""
+	buildTime = ""
+)
+
+// Version returns the release version of the build.
+func Version() string {
+	return version
+}
+
+// Commit returns the git commit the build was made from.
+func Commit() string {
+	return commit
+}
+
+// BuildTime returns the UTC time of the build.
+func BuildTime() string {
+	return buildTime
+}
@@ -0,0 +1,41 @@
+/*
+Package video holds the videos decoded by the client package, in the v2
+layout described in VERSIONING.md.
+*/
+package video
+
+import "github.com/kkdai/youtube"
+
+// Video is a decoded video: its metadata and its formats. It is never
+// modified, so it can be shared between goroutines.
+type Video struct {
+	ID         string
+	Title      string
+	Author     string
+	Formats    []youtube.Format
+	Thumbnails []youtube.Thumbnail
+
+	decoded *youtube.Youtube
+}
+
+// FromYoutube returns the video decoded by y, y can still be used afterward.
+// It is the shim turning the v1 API into the v2 one.
+func FromYoutube(y *youtube.Youtube) *Video {
+	v := &Video{
+		ID:         y.VideoID,
+		Formats:    y.Formats(),
+		Thumbnails: y.Thumbnails(),
+		decoded:    y.Clone(),
+	}
+	if info := y.GetItagInfo(); info != nil {
+		v.Title, v.Author = info.Title, info.Author
+	}
+	return v
+}
+
+// Youtube returns a v1 Youtube holding the video, for the APIs not available
+// in the v2 layout yet. Each call returns a new one, which can download the
+// video concurrently with the others.
+func (v *Video) Youtube() *youtube.Youtube {
+	return v.decoded.Clone()
+}
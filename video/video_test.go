@@ -0,0 +1,23 @@
+package video
+
+import (
+	"testing"
+
+	"github.com/kkdai/youtube"
+)
+
+func TestFromYoutube(t *testing.T) {
+	y := youtube.NewYoutube(false)
+	y.VideoID = "rFejpH_tAHM"
+	v := FromYoutube(y)
+	if v.ID != "rFejpH_tAHM" {
+		t.Errorf("FromYoutube() = %+v", v)
+	}
+
+	// y is not tied to the video
+	y.VideoID = "BaW_jenozKc"
+	a, b := v.Youtube(), v.Youtube()
+	if a == b || a.VideoID != "rFejpH_tAHM" || b.VideoID != "rFejpH_tAHM" {
+		t.Errorf("Youtube() = %p %+v, %p %+v, want two copies of the video", a, a, b, b)
+	}
+}
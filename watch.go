@@ -0,0 +1,177 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package youtube
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// The subfolders of a watched folder the files are moved to once their jobs finished.
+const (
+	WatchDoneDir   = "done"
+	WatchFailedDir = "failed"
+)
+
+// watchSettle is how long a file must be left untouched before being read,
+// so that files still being written are not picked up.
+var watchSettle = 2 * time.Second
+
+// WatchFolder queues the links of the .url and .txt files dropped in a
+// directory, then moves each file to the done or failed subfolder once its
+// downloads finished: a file with a failed or cancelled download goes to the
+// failed one. A .txt file holds one link per line, lines starting with # are
+// ignored.
+type WatchFolder struct {
+	Dir     string
+	Queue   *Queue
+	Options DownloadOptions
+	// Interval is the time between two scans of Dir, 5s when zero.
+	Interval time.Duration
+
+	// watched holds the job ids of the files being downloaded.
+	watched map[string][]int
+}
+
+// Run scans the folder until ctx is done.
+func (w *WatchFolder) Run(ctx context.Context) error {
+	interval := w.Interval
+	if interval == 0 {
+		interval = 5 * time.Second
+	}
+	for _, dir := range []string{WatchDoneDir, WatchFailedDir} {
+		if err := os.MkdirAll(filepath.Join(w.Dir, dir), 0755); err != nil {
+			return err
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := w.scan(); err != nil {
+			w.Queue.log(fmt.Sprintf("watching %s failed: %s", w.Dir, err))
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// scan queues the new files and moves the files whose jobs finished.
+func (w *WatchFolder) scan() error {
+	if w.watched == nil {
+		w.watched = make(map[string][]int)
+	}
+	files, err := ioutil.ReadDir(w.Dir)
+	if err != nil {
+		return err
+	}
+	for _, fi := range files {
+		name := fi.Name()
+		ext := strings.ToLower(filepath.Ext(name))
+		if fi.IsDir() || (ext != ".url" && ext != ".txt") {
+			continue
+		}
+		if _, ok := w.watched[name]; ok || time.Since(fi.ModTime()) < watchSettle {
+			continue
+		}
+		ids, err := w.add(name)
+		if err != nil {
+			w.Queue.log(fmt.Sprintf("queuing %s failed: %s", name, err))
+			w.move(name, WatchFailedDir)
+			continue
+		}
+		w.watched[name] = ids
+	}
+
+	for name, ids := range w.watched {
+		if dir, finished := w.outcome(ids); finished {
+			w.move(name, dir)
+			delete(w.watched, name)
+		}
+	}
+	return nil
+}
+
+// add queues the links of the file name.
+func (w *WatchFolder) add(name string) ([]int, error) {
+	links, err := readLinks(filepath.Join(w.Dir, name))
+	if err != nil {
+		return nil, err
+	}
+	if len(links) == 0 {
+		return nil, fmt.Errorf("no link in %s", name)
+	}
+	var ids []int
+	for _, link := range links {
+		job, err := w.Queue.Add(link, w.Options)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, job.ID)
+	}
+	return ids, nil
+}
+
+// outcome returns the subfolder matching the jobs ids once they are all finished.
+func (w *WatchFolder) outcome(ids []int) (string, bool) {
+	dir := WatchDoneDir
+	for _, id := range ids {
+		job, _ := w.Queue.Job(id)
+		switch job.Status {
+		case JobQueued, JobRunning:
+			return "", false
+		case JobFailed, JobCancelled:
+			dir = WatchFailedDir
+		}
+	}
+	return dir, true
+}
+
+// move moves the file name to the subfolder dir, without overwriting a
+// previous file of the same name.
+func (w *WatchFolder) move(name, dir string) {
+	dest := filepath.Join(w.Dir, dir, name)
+	if _, err := os.Stat(dest); err == nil {
+		dest = filepath.Join(w.Dir, dir, fmt.Sprintf("%d-%s", time.Now().Unix(), name))
+	}
+	if err := os.Rename(filepath.Join(w.Dir, name), dest); err != nil {
+		w.Queue.log(fmt.Sprintf("moving %s failed: %s", name, err))
+	}
+}
+
+// readLinks returns the links of a .url internet shortcut or of a .txt file.
+func readLinks(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	shortcut := strings.EqualFold(filepath.Ext(path), ".url")
+	var links []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if shortcut {
+			if !strings.HasPrefix(strings.ToUpper(line), "URL=") {
+				continue
+			}
+			line = strings.TrimSpace(line[len("URL="):])
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		links = append(links, line)
+	}
+	return links, scanner.Err()
+}
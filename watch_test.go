@@ -0,0 +1,117 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package youtube
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReadLinks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "youtube-watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"shortcut.url", "[InternetShortcut]\r\nURL=https://www.youtube.com/watch?v=rFejpH_tAHM\r\nIconIndex=0\r\n", []string{"https://www.youtube.com/watch?v=rFejpH_tAHM"}},
+		{"links.txt", "# talks\nhttps://youtu.be/rFejpH_tAHM\n\n  54e6lBE3BoQ  \n", []string{"https://youtu.be/rFejpH_tAHM", "54e6lBE3BoQ"}},
+		{"empty.txt", "\n# nothing\n", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name)
+			if err := ioutil.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := readLinks(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readLinks() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWatchFolder(t *testing.T) {
+	defer func(settle time.Duration) { watchSettle = settle }(watchSettle)
+	watchSettle = 0
+
+	dir, err := ioutil.TempDir("", "youtube-watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	q, err := NewQueue(Options{}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.run = func(ctx context.Context, job *Job) (string, error) {
+		switch job.URL {
+		case "fail":
+			return "", errors.New("decoding failed")
+		case "cancel":
+			q.Cancel(job.ID, CancelUser)
+			<-ctx.Done()
+			return "", ctx.Err()
+		}
+		return "/tmp/" + job.URL + ".mp4", nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go q.Run(ctx)
+
+	files := map[string]string{
+		"ok.txt":        "first\nsecond\n",
+		"failed.txt":    "first\nfail\n",
+		"cancelled.txt": "first\ncancel\n",
+		"empty.url":     "[InternetShortcut]\n",
+		"ignored.md":    "first\n",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w := &WatchFolder{Dir: dir, Queue: q, Interval: 10 * time.Millisecond}
+	go w.Run(ctx)
+
+	want := []string{
+		filepath.Join(dir, WatchDoneDir, "ok.txt"),
+		filepath.Join(dir, WatchFailedDir, "failed.txt"),
+		filepath.Join(dir, WatchFailedDir, "cancelled.txt"),
+		filepath.Join(dir, WatchFailedDir, "empty.url"),
+		filepath.Join(dir, "ignored.md"),
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for _, path := range want {
+		for {
+			if _, err := os.Stat(path); err == nil {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("%s not found", path)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	if jobs := q.Jobs(); len(jobs) != 6 {
+		t.Errorf("queued %d jobs, want 6", len(jobs))
+	}
+}
@@ -0,0 +1,98 @@
+package youtube
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TransferWindow is a daily period, in local time, during which the media
+// are transferred. Start and End are the times since midnight, a window
+// ending before it starts spans midnight, such as 22:00-06:00.
+type TransferWindow struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+func (w TransferWindow) String() string {
+	return clock(w.Start) + "-" + clock(w.End)
+}
+
+func clock(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d/time.Hour), int(d%time.Hour/time.Minute))
+}
+
+// TransferWindows restricts the transfers of the media to some periods of
+// the day, such as the off-peak hours of a connection billed by the hour.
+// A download waits for the next window to open before it starts, a started
+// download isn't interrupted when its window closes. The metadata requests,
+// such as decoding a video or listing a playlist, are never restricted. No
+// window means no restriction.
+type TransferWindows []TransferWindow
+
+// ParseTransferWindows parses comma separated windows, such as "01:00-07:00,13:00-14:00".
+func ParseTransferWindows(s string) (TransferWindows, error) {
+	var windows TransferWindows
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		bounds := strings.Split(field, "-")
+		if len(bounds) != 2 {
+			return nil, ErrInvalidOption{Option: "TransferWindows", Reason: "want START-END, got " + field}
+		}
+		var window TransferWindow
+		for i, bound := range bounds {
+			var hours, minutes int
+			if n, err := fmt.Sscanf(strings.TrimSpace(bound), "%d:%d", &hours, &minutes); err != nil || n != 2 {
+				return nil, ErrInvalidOption{Option: "TransferWindows", Reason: "want HH:MM, got " + bound}
+			}
+			d := time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute
+			if i == 0 {
+				window.Start = d
+			} else {
+				window.End = d
+			}
+		}
+		windows = append(windows, window)
+	}
+	return windows, windows.validate().err()
+}
+
+func (w TransferWindows) validate() ErrInvalidOptions {
+	var errs ErrInvalidOptions
+	for _, window := range w {
+		switch {
+		case window.Start < 0 || window.Start >= 24*time.Hour || window.End < 0 || window.End > 24*time.Hour:
+			errs = append(errs, ErrInvalidOption{Option: "TransferWindows", Reason: window.String() + " is not within a day"})
+		case window.Start == window.End:
+			errs = append(errs, ErrInvalidOption{Option: "TransferWindows", Reason: window.String() + " is empty"})
+		}
+	}
+	return errs
+}
+
+// until returns how long until a window is open at now, 0 when one is.
+func (w TransferWindows) until(now time.Time) time.Duration {
+	if len(w) == 0 {
+		return 0
+	}
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	offset := now.Sub(midnight)
+	var wait time.Duration
+	for i, window := range w {
+		if window.Start < window.End && offset >= window.Start && offset < window.End ||
+			window.Start > window.End && (offset >= window.Start || offset < window.End) {
+			return 0
+		}
+		opens := midnight.Add(window.Start)
+		if !opens.After(now) {
+			opens = time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location()).Add(window.Start)
+		}
+		if d := opens.Sub(now); i == 0 || d < wait {
+			wait = d
+		}
+	}
+	return wait
+}
@@ -0,0 +1,92 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package youtube
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestParseTransferWindows(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    TransferWindows
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"01:00-07:00", TransferWindows{{Start: time.Hour, End: 7 * time.Hour}}, false},
+		{"22:30-06:00, 13:00-24:00", TransferWindows{{Start: 22*time.Hour + 30*time.Minute, End: 6 * time.Hour}, {Start: 13 * time.Hour, End: 24 * time.Hour}}, false},
+		{"01:00", nil, true},
+		{"1h-7h", nil, true},
+		{"05:00-05:00", nil, true},
+		{"23:00-25:00", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseTransferWindows(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTransferWindows() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParseTransferWindows() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("ParseTransferWindows() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestTransferWindows_until(t *testing.T) {
+	windows, _ := ParseTransferWindows("01:00-07:00,22:00-23:00")
+	overnight, _ := ParseTransferWindows("22:00-06:00")
+	at := func(hour, minute int) time.Time {
+		return time.Date(2020, 3, 14, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name    string
+		windows TransferWindows
+		now     time.Time
+		want    time.Duration
+	}{
+		{"no window", nil, at(12, 0), 0},
+		{"open", windows, at(3, 0), 0},
+		{"closing", windows, at(6, 59), 0},
+		{"closed", windows, at(7, 0), 15 * time.Hour},
+		{"next window", windows, at(21, 30), 30 * time.Minute},
+		{"tomorrow", windows, at(23, 0), 2 * time.Hour},
+		{"overnight before midnight", overnight, at(23, 0), 0},
+		{"overnight after midnight", overnight, at(5, 0), 0},
+		{"overnight closed", overnight, at(6, 0), 16 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.windows.until(tt.now); got != tt.want {
+				t.Errorf("until() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestYoutube_download_TransferWindows(t *testing.T) {
+	closed := time.Now().Add(time.Hour)
+	midnight := time.Date(closed.Year(), closed.Month(), closed.Day(), 0, 0, 0, 0, closed.Location())
+	start := closed.Sub(midnight).Truncate(time.Minute)
+	y := NewYoutube(false)
+	y.TransferWindows = TransferWindows{{Start: start, End: start + time.Minute}}
+	y.StreamList = []stream{{ItagNo: 18, Type: "video/mp4", URL: "http://127.0.0.1:1/never-requested"}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := y.download(ctx, DownloadOptions{OutputDir: os.TempDir()}); err != context.DeadlineExceeded {
+		t.Errorf("download() outside of the windows = %v, want it to wait", err)
+	}
+}
@@ -6,20 +6,15 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"log"
 	"mime"
-	"net"
 	"net/http"
 	"net/url"
-	"os"
-	"os/user"
-	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
-
-	"golang.org/x/net/proxy"
 )
 
 //SetLogOutput :Set logger writer
@@ -28,25 +23,91 @@ func SetLogOutput(w io.Writer) {
 }
 
 type stream struct {
-	Quality string
-	Type    string
-	URL     string
-	ItagNo  int
-	Title   string
-	Author  string
+	Quality  string
+	Type     string
+	URL      string
+	ItagNo   int
+	Title    string
+	Author   string
+	Adaptive bool
+	Ciphered bool
+	Height   int
+	Bitrate  int
+	// ContentLength is the size of the stream, 0 when unknown.
+	ContentLength int64
+	// ResolvedAt is when URL was obtained, youtube stream urls expire after a few hours.
+	ResolvedAt time.Time
 }
 
 // Youtube implements the downloader to download youtube videos.
 type Youtube struct {
-	DebugMode         bool
-	StreamList        []stream
-	VideoID           string
-	videoInfo         string
-	DownloadPercent   chan int64
-	Socks5Proxy       string
+	DebugMode       bool
+	StreamList      []stream
+	VideoID         string
+	videoInfo       string
+	playerResponse  *PlayerResponseData
+	DownloadPercent chan int64
+	Socks5Proxy     string
+	RateLimit       int64
+	// HTTPClient, when set, is used instead of a client built for Socks5Proxy.
+	// NewBatchHTTPClient returns one which can be shared by all the downloads of a batch.
+	HTTPClient *http.Client
+	// Health, when set, records the download failures of each itag and the
+	// automatic stream selection avoids the formats failing chronically.
+	Health *FormatHealth
+	// ChooseFormat, when set, is called to pick the index of the format to
+	// download when neither an itag nor a quality is given, so CLI and GUI
+	// wrappers can prompt the user instead of taking the first format.
+	ChooseFormat func(formats []Format) (int, error)
+	// Limits guards against adversarial inputs, there is no limit by default.
+	Limits Limits
+	// MetadataCache, when set, caches the video info of the decoded videos.
+	MetadataCache *MetadataCache
+	// ThumbnailCache, when set, caches the images fetched by FetchThumbnail.
+	ThumbnailCache *ThumbnailCache
+	// MaxURLAge, when set, is the age past which the url of a stream is
+	// resolved again before downloading it, to avoid expired urls.
+	MaxURLAge time.Duration
+	// Jitter randomizes the timing of the metadata requests, see Jitter.
+	Jitter Jitter
+	// Session holds the warm-up state, such as the cipher plans, it is
+	// created on first use when nil, see ExportState.
+	Session *Session
+	// Filesystem is the filesystem the downloads are written to, their names
+	// follow its restrictions, see Filesystem.
+	Filesystem Filesystem
+	// OnExtensionMismatch, when set, is called when the content of a
+	// downloaded file is another container than its extension says, the
+	// file is renamed beforehand with DownloadOptions.FixExtension.
+	OnExtensionMismatch func(ExtensionMismatch)
+	// OnProgress, when set, is called with the progress of the downloads
+	// and of the enumerations of the lists, such as MusicAlbum.
+	OnProgress func(Progress)
+	// Trash, when set, keeps the files replaced by the downloads.
+	Trash *Trash
+	// Archive and Upgrade are used by Sync.
+	Archive *Archive
+	Upgrade UpgradePolicy
+	// POTokenProvider, when set, supplies the proof of origin tokens of the requests.
+	POTokenProvider POTokenProvider
+	// OnItemStart, OnItemComplete and OnItemSkipped, when set, are called
+	// for each video of the lists downloaded by DownloadList.
+	OnItemStart    func(ListItem)
+	OnItemComplete func(ListItemResult)
+	OnItemSkipped  func(ListItemResult)
+	// TransferWindows, when set, delays the downloads until a window opens.
+	TransferWindows   TransferWindows
+	sessionMu         sync.Mutex
+	clients           map[string]*http.Client
+	clientsMu         sync.Mutex
+	outputDir         string
+	quality           string
+	itagNo            int
 	contentLength     float64
 	totalWrittenBytes float64
 	downloadLevel     float64
+	// progress aggregates the progress of the downloads of DownloadFormats.
+	progress *aggregateProgress
 }
 
 //NewYoutube :Initialize youtube package object
@@ -54,76 +115,74 @@ func NewYoutube(debug bool) *Youtube {
 	return &Youtube{DebugMode: debug, DownloadPercent: make(chan int64, 100)}
 }
 
+// NewYoutubeWithSocks5Proxy initializes a youtube package object using socks5Proxy.
+//
+// Deprecated: use NewYoutubeWithOptions, which validates the proxy address.
 func NewYoutubeWithSocks5Proxy(debug bool, socks5Proxy string) *Youtube {
 	return &Youtube{DebugMode: debug, DownloadPercent: make(chan int64, 100), Socks5Proxy: socks5Proxy}
 }
 
 //DecodeURL : Decode youtube URL to retrieval video information.
+//
+// Deprecated: use DecodeURLWithContext, which can be canceled.
 func (y *Youtube) DecodeURL(url string) error {
+	return y.DecodeURLWithContext(context.Background(), url)
+}
+
+// DecodeURLWithContext is the same as DecodeURL with the requests bound to ctx.
+func (y *Youtube) DecodeURLWithContext(ctx context.Context, url string) error {
 	err := y.findVideoID(url)
 	if err != nil {
 		return fmt.Errorf("findVideoID error=%s", err)
 	}
 
-	err = y.getVideoInfo()
-	if err != nil {
-		return fmt.Errorf("getVideoInfo error=%s", err)
-	}
-
-	err = y.parseVideoInfo()
-	if err != nil {
-		return fmt.Errorf("parse video info failed, err=%s", err)
-	}
-
-	return nil
+	return y.decode(ctx, false)
 }
 
-//StartDownload : Starting download video by arguments
-func (y *Youtube) StartDownload(outputDir, outputFile, quality string, itagNo int) error {
+// prepareDownload validates opts, applies the client defaults and selects the stream to download.
+func (y *Youtube) prepareDownload(opts DownloadOptions) (DownloadOptions, stream, error) {
 	if len(y.StreamList) == 0 {
-		return ErrEmptyStreamList
+		return opts, stream{}, ErrEmptyStreamList
+	}
+	if err := opts.Validate(); err != nil {
+		return opts, stream{}, err
 	}
+	opts = y.withDefaults(opts)
 
-	//download highest resolution on [0] by default
-	index := 0
-	switch {
-	case itagNo != 0:
-		itagFound := false
-		for i, stream := range y.StreamList {
-			if stream.ItagNo == itagNo {
-				itagFound = true
-				index = i
-				break
-			}
-		}
-		if !itagFound {
-			return ErrItagNotFound
-		}
-	case quality != "":
-		for i, stream := range y.StreamList {
-			if strings.Compare(stream.Quality, quality) == 0 {
-				index = i
-				break
+	stream, err := y.selectStream(opts)
+	return opts, stream, err
+}
+
+// selectStream picks the stream to download according to opts, among
+// equivalent streams the ones failing chronically in this session are avoided.
+func (y *Youtube) selectStream(opts DownloadOptions) (stream, error) {
+	if opts.ItagNo != 0 {
+		for _, stream := range y.StreamList {
+			if stream.ItagNo == opts.ItagNo {
+				return stream, nil
 			}
 		}
+		return stream{}, ErrItagNotFound
 	}
-	stream := y.StreamList[index]
-
-	if outputDir == "" {
-		usr, _ := user.Current()
-		outputDir = filepath.Join(usr.HomeDir, "Movies", "youtubedr")
+	if opts.Quality == "" && y.ChooseFormat != nil {
+		return y.chooseStream()
 	}
 
-	outputFile = SanitizeFilename(outputFile)
-	if outputFile == "" {
-		outputFile = SanitizeFilename(stream.Title)
-		outputFile += pickIdealFileExtension(stream.Type)
+	//download highest resolution on [0] by default
+	candidates := y.StreamList[:1]
+	var matches []stream
+	for _, stream := range y.StreamList {
+		switch {
+		case opts.Quality != "" && stream.Quality == opts.Quality:
+			matches = append(matches, stream)
+		case opts.Quality == "" && y.Health != nil && stream.Adaptive == y.StreamList[0].Adaptive:
+			matches = append(matches, stream)
+		}
+	}
+	if len(matches) > 0 {
+		candidates = matches
 	}
-	destFile := filepath.Join(outputDir, outputFile)
-	streamURL := stream.URL
-	y.log(fmt.Sprintln("Download url=", streamURL))
-	y.log(fmt.Sprintln("Download to file=", destFile))
-	return y.videoDLWorker(destFile, streamURL)
+	return y.Health.healthiest(candidates), nil
 }
 
 func pickIdealFileExtension(mediaType string) string {
@@ -154,6 +213,8 @@ func pickIdealFileExtension(mediaType string) string {
 		"video/mp4":        ".mp4",
 		"video/ogg":        ".ogv",
 		"video/mp2t":       ".ts",
+		"audio/mp4":        ".m4a",
+		"audio/webm":       ".webm",
 	}
 
 	if extension, ok := canonicals[mediaType]; ok {
@@ -179,13 +240,14 @@ func SanitizeFilename(fileName string) string {
 
 	// Ref https://docs.microsoft.com/en-us/windows/win32/fileio/naming-a-file#naming-conventions
 
+	fileName = fileNameText(fileName)
 	fileName = regexp.MustCompile(`[:/<>\:"\\|?*]`).ReplaceAllString(fileName, "")
 	fileName = regexp.MustCompile(`\s+`).ReplaceAllString(fileName, " ")
 
 	return fileName
 }
 
-func (y *Youtube) parseVideoInfo() error {
+func (y *Youtube) parseVideoInfo(ctx context.Context) error {
 	answer, err := url.ParseQuery(y.videoInfo)
 	if err != nil {
 		return err
@@ -219,11 +281,11 @@ func (y *Youtube) parseVideoInfo() error {
 
 	// Get video title and author.
 	title, author := getVideoTitleAuthor(answer)
+	title, author = normalizeText(title), normalizeText(author)
 
 	var prData PlayerResponseData
 	if err := json.Unmarshal([]byte(streamMap[0]), &prData); err != nil {
-		fmt.Println(err)
-		panic("Player response json data has changed.")
+		return fmt.Errorf("player response json data has changed: %s", err)
 	}
 
 	// Get video download link
@@ -232,11 +294,12 @@ func (y *Youtube) parseVideoInfo() error {
 		return errors.New(fmt.Sprint("Cannot playback and download, reason:", prData.PlayabilityStatus.Reason))
 	}
 
-	streams, err := y.getStreams(prData, title, author)
+	streams, err := y.getStreams(ctx, prData, title, author)
 	if err != nil {
 		return err
 	}
 
+	y.playerResponse = &prData
 	y.StreamList = streams
 	if len(y.StreamList) == 0 {
 		return errors.New("no stream list found in the server's answer")
@@ -244,7 +307,7 @@ func (y *Youtube) parseVideoInfo() error {
 	return nil
 }
 
-func (y Youtube) getStreams(prData PlayerResponseData, title string, author string) ([]stream, error) {
+func (y *Youtube) getStreams(ctx context.Context, prData PlayerResponseData, title string, author string) ([]stream, error) {
 	size := len(prData.StreamingData.Formats) + len(prData.StreamingData.AdaptiveFormats)
 	formatBases := make([]FormatBase, 0, size)
 	streamPositions := make([]int, 0, size)
@@ -258,8 +321,13 @@ func (y Youtube) getStreams(prData PlayerResponseData, title string, author stri
 		streamPositions = append(streamPositions, adaptiveStreamPos)
 	}
 	var streams []stream
+	resolvedAt := time.Now()
+	token, err := y.poToken(ctx, POTokenGVS)
+	if err != nil {
+		return nil, err
+	}
 	for idx, formatBase := range formatBases {
-		stream, err := y.parseStream(title, author, streamPositions[idx], formatBase)
+		stream, err := y.parseStream(ctx, title, author, streamPositions[idx], formatBase)
 		if err != nil {
 			if errors.Is(err, ErrDecodingStreamInfo{}) {
 				y.log(err.Error())
@@ -267,6 +335,11 @@ func (y Youtube) getStreams(prData PlayerResponseData, title string, author stri
 			}
 			return nil, err
 		}
+		if stream.URL, err = withPOToken(stream.URL, token); err != nil {
+			return nil, err
+		}
+		stream.Adaptive = idx >= len(prData.StreamingData.Formats)
+		stream.ResolvedAt = resolvedAt
 		y.log(fmt.Sprintf("Title: %s Author: %s Stream found: quality '%s', format '%s', itag '%d'",
 			title, author, stream.Quality, stream.Type, stream.ItagNo))
 		streams = append(streams, stream)
@@ -274,23 +347,25 @@ func (y Youtube) getStreams(prData PlayerResponseData, title string, author stri
 	return streams, nil
 }
 
-func (y Youtube) parseStream(title, author string, streamPos int, formatBase FormatBase) (stream, error) {
+func (y *Youtube) parseStream(ctx context.Context, title, author string, streamPos int, formatBase FormatBase) (stream, error) {
 	if formatBase.MimeType == "" {
 		return stream{}, ErrDecodingStreamInfo{
 			streamPos: streamPos,
 		}
 	}
 	streamUrl := formatBase.URL
+	ciphered := false
 	if streamUrl == "" {
 		cipher := formatBase.Cipher
 		if cipher == "" {
 			return stream{}, ErrCipherNotFound
 		}
-		decipheredUrl, err := y.decipher(cipher)
+		decipheredUrl, err := y.decipher(ctx, cipher)
 		if err != nil {
 			return stream{}, err
 		}
 		streamUrl = decipheredUrl
+		ciphered = true
 	}
 
 	stream := stream{
@@ -299,64 +374,43 @@ func (y Youtube) parseStream(title, author string, streamPos int, formatBase For
 		URL:     streamUrl,
 		ItagNo:  formatBase.ItagNo,
 
-		Title:  title,
-		Author: author,
+		Title:    title,
+		Author:   author,
+		Ciphered: ciphered,
+		Height:   formatBase.Height,
+		Bitrate:  formatBase.Bitrate,
 	}
+	stream.ContentLength, _ = strconv.ParseInt(formatBase.ContentLength, 10, 64)
 	return stream, nil
 }
 
-func (y *Youtube) getHTTPClient() (*http.Client, error) {
-	// setup a http client
-	httpTransport := &http.Transport{
-		DialContext: (&net.Dialer{
-			Timeout:   30 * time.Second,
-			KeepAlive: 30 * time.Second,
-		}).DialContext,
-		IdleConnTimeout:       60 * time.Second,
-		TLSHandshakeTimeout:   10 * time.Second,
-		ExpectContinueTimeout: 1 * time.Second,
-	}
-	httpClient := &http.Client{Transport: httpTransport}
-
-	if len(y.Socks5Proxy) == 0 {
-		return httpClient, nil
-	}
-
-	dialer, err := proxy.SOCKS5("tcp", y.Socks5Proxy, nil, proxy.Direct)
-	if err != nil {
-		fmt.Fprintln(os.Stderr, "can't connect to the proxy:", err)
-		return nil, err
-	}
-	// set our socks5 as the dialer
-	dc := dialer.(interface {
-		DialContext(ctx context.Context, network, addr string) (net.Conn, error)
-	})
-	httpTransport.DialContext = dc.DialContext
-
-	y.log(fmt.Sprintf("Using http with proxy %s.", y.Socks5Proxy))
-
-	return httpClient, nil
-}
-
-func (y *Youtube) getVideoInfo() error {
+func (y *Youtube) getVideoInfo(ctx context.Context, refresh bool) error {
 	eurl := "https://youtube.googleapis.com/v/" + y.VideoID
-	url := "https://youtube.com/get_video_info?video_id=" + y.VideoID + "&eurl=" + eurl
-	y.log(fmt.Sprintf("url: %s", url))
+	infoURL := baseURL + "/get_video_info?video_id=" + y.VideoID + "&eurl=" + eurl
+	y.log(fmt.Sprintf("url: %s", infoURL))
 
-	httpClient, err := y.getHTTPClient()
-	if err != nil {
-		return err
-	}
-
-	resp, err := httpClient.Get(url)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != 200 {
-		return err
+	fetch := func(ctx context.Context) ([]byte, error) {
+		token, err := y.poToken(ctx, POTokenPlayer)
+		if err != nil {
+			return nil, err
+		}
+		if token != "" {
+			return y.httpGetBody(ctx, infoURL+"&pot="+url.QueryEscape(token))
+		}
+		return y.httpGetBody(ctx, infoURL)
+	}
+	var body []byte
+	var err error
+	if cache := y.MetadataCache; cache != nil {
+		key := cache.key("video_info", y.VideoID, y.Socks5Proxy)
+		if refresh {
+			body, err = cache.refresh(ctx, key, fetch)
+		} else {
+			body, err = cache.get(ctx, key, fetch)
+		}
+	} else {
+		body, err = fetch(ctx)
 	}
-	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return err
 	}
@@ -390,52 +444,6 @@ func (y *Youtube) findVideoID(url string) error {
 	return nil
 }
 
-func (y *Youtube) Write(p []byte) (n int, err error) {
-	n = len(p)
-	y.totalWrittenBytes = y.totalWrittenBytes + float64(n)
-	currentPercent := (y.totalWrittenBytes / y.contentLength) * 100
-	if (y.downloadLevel <= currentPercent) && (y.downloadLevel < 100) {
-		y.downloadLevel++
-		y.DownloadPercent <- int64(y.downloadLevel)
-	}
-	return
-}
-func (y *Youtube) videoDLWorker(destFile string, target string) error {
-
-	httpClient, err := y.getHTTPClient()
-	if err != nil {
-		return err
-	}
-
-	resp, err := httpClient.Get(target)
-	if err != nil {
-		y.log(fmt.Sprintf("Http.Get\nerror: %s\ntarget: %s\n", err, target))
-		return err
-	}
-	defer resp.Body.Close()
-	y.contentLength = float64(resp.ContentLength)
-
-	if resp.StatusCode != 200 {
-		y.log(fmt.Sprintf("reading answer: non 200[code=%v] status code received: '%v'", resp.StatusCode, err))
-		return errors.New("non 200 status code received")
-	}
-	err = os.MkdirAll(filepath.Dir(destFile), 0755)
-	if err != nil {
-		return err
-	}
-	out, err := os.Create(destFile)
-	if err != nil {
-		return err
-	}
-	mw := io.MultiWriter(out, y)
-	_, err = io.Copy(mw, resp.Body)
-	if err != nil {
-		y.log(fmt.Sprintln("download video err=", err))
-		return err
-	}
-	return nil
-}
-
 func (y *Youtube) log(logText string) {
 	if y.DebugMode {
 		log.Println(logText)
@@ -454,6 +462,18 @@ func (y *Youtube) GetItagInfo() *ItagInfo {
 	return &model
 }
 
+// duration returns the length of the decoded video.
+func (y *Youtube) duration() time.Duration {
+	if y.playerResponse == nil {
+		return 0
+	}
+	seconds, err := strconv.Atoi(y.playerResponse.VideoDetails.LengthSeconds)
+	if err != nil {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
 func getVideoTitleAuthor(in url.Values) (string, string) {
 	playResponse, ok := in["player_response"]
 	if !ok {
@@ -461,18 +481,14 @@ func getVideoTitleAuthor(in url.Values) (string, string) {
 	}
 	personMap := make(map[string]interface{})
 
+	// a malformed player response is reported by the parsing of the streams
 	if err := json.Unmarshal([]byte(playResponse[0]), &personMap); err != nil {
-		panic(err)
+		return "", ""
 	}
 
-	s := personMap["videoDetails"]
-	myMap := s.(map[string]interface{})
+	myMap, _ := personMap["videoDetails"].(map[string]interface{})
 	// fmt.Println("-->", myMap["title"], "oooo:", myMap["author"])
-	if title, ok := myMap["title"]; ok {
-		if author, ok := myMap["author"]; ok {
-			return title.(string), author.(string)
-		}
-	}
-
-	return "", ""
+	title, _ := myMap["title"].(string)
+	author, _ := myMap["author"].(string)
+	return title, author
 }
@@ -1,6 +1,7 @@
 package youtube
 
 import (
+	"context"
 	"errors"
 	"log"
 	"os"
@@ -35,56 +36,6 @@ func TestMain(m *testing.M) {
 	os.Exit(exitCode)
 }
 
-func TestDownload(t *testing.T) {
-	testcases := []struct {
-		name      string
-		outputDir string
-		ouputFile string
-		quality   string
-		itag      int
-	}{
-		{name: "Default"},
-		{name: "with outputDir", outputDir: dfPath},
-		{name: "SpecificQuality", quality: "hd720"},
-		{name: "SpecificITag", itag: 22},
-	}
-
-	for _, tc := range testcases {
-		t.Run(tc.name, func(t *testing.T) {
-			y := NewYoutube(false)
-			if y == nil {
-				t.Error("Cannot init object.")
-				return
-			}
-
-			if err := y.StartDownload(tc.outputDir, tc.ouputFile, tc.quality, tc.itag); err == nil {
-				t.Error("No video URL input should not download.")
-				return
-			}
-		})
-	}
-}
-
-func TestDownloadError(t *testing.T) {
-	y := NewYoutube(false)
-	if y == nil {
-		t.Error("Cannot init object.")
-		return
-	}
-	t.Run("empty stream list error", func(t *testing.T) {
-		if err := y.StartDownload("", "", "", 0); err != ErrEmptyStreamList {
-			t.Error("no err returned for empty stream list")
-		}
-	})
-
-	t.Run("itag not found error", func(t *testing.T) {
-		y.StreamList = append(y.StreamList, stream{})
-		if err := y.StartDownload("", "", "", 18); err != ErrItagNotFound {
-			t.Error("no error returned for itag not found")
-		}
-	})
-}
-
 func TestParseVideo(t *testing.T) {
 	y := NewYoutube(false)
 	if y == nil {
@@ -294,7 +245,7 @@ func TestYoutube_parseStream(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			y := NewYoutube(false)
-			got, err := y.parseStream(tt.args.title, tt.args.author, tt.args.streamPos, tt.args.formatBase)
+			got, err := y.parseStream(context.Background(), tt.args.title, tt.args.author, tt.args.streamPos, tt.args.formatBase)
 			if tt.wantErr && !errors.Is(err, tt.expectErr) {
 				t.Errorf("parseStream() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -0,0 +1,130 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/kkdai/youtube"
+)
+
+// addJobRequest is the body of POST /jobs.
+type addJobRequest struct {
+	URL     string                  `json:"url"`
+	Options youtube.DownloadOptions `json:"options"`
+}
+
+// newAPI returns the REST API of the queue:
+//
+//	GET    /jobs       lists the jobs
+//	POST   /jobs       queues a download, the body is {"url": "...", "options": {...}}
+//	GET    /jobs/{id}  returns a job
+//	DELETE /jobs/{id}  cancels a job
+//
+// The output directory of a job is confined to root, the output directory of
+// the daemon, and the proxy of the daemon is used whatever the job asks.
+func newAPI(queue *youtube.Queue, root string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/jobs", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, queue.Jobs())
+		case http.MethodPost:
+			var req addJobRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeError(w, http.StatusBadRequest, err)
+				return
+			}
+			if req.URL == "" {
+				writeError(w, http.StatusBadRequest, errors.New("missing url"))
+				return
+			}
+			opts, err := confineOptions(req.Options, root)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err)
+				return
+			}
+			job, err := queue.Add(req.URL, opts)
+			switch {
+			case err == youtube.ErrQueueClosed:
+				writeError(w, http.StatusServiceUnavailable, err)
+			case err != nil:
+				writeError(w, http.StatusBadRequest, err)
+			default:
+				writeJSON(w, http.StatusCreated, job)
+			}
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		}
+	})
+	mux.HandleFunc("/jobs/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodDelete {
+			w.Header().Set("Allow", "GET, DELETE")
+			writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+			return
+		}
+		id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/jobs/"))
+		if err != nil {
+			writeError(w, http.StatusNotFound, errors.New("job not found"))
+			return
+		}
+		if r.Method == http.MethodDelete {
+			switch err := queue.Cancel(id, youtube.CancelUser); err {
+			case nil:
+			case youtube.ErrJobNotFound:
+				writeError(w, http.StatusNotFound, err)
+				return
+			default:
+				writeError(w, http.StatusConflict, err)
+				return
+			}
+		}
+		job, ok := queue.Job(id)
+		if !ok {
+			writeError(w, http.StatusNotFound, errors.New("job not found"))
+			return
+		}
+		writeJSON(w, http.StatusOK, job)
+	})
+	return mux
+}
+
+// confineOptions returns the options of a job requested through the API with
+// its output directory resolved under root, a relative one being relative to
+// root, and without proxy.
+func confineOptions(opts youtube.DownloadOptions, root string) (youtube.DownloadOptions, error) {
+	opts.Socks5Proxy = ""
+	if opts.OutputDir == "" {
+		return opts, nil
+	}
+	if root == "" {
+		return opts, errors.New("output_dir needs the daemon to run with -d")
+	}
+	dir := opts.OutputDir
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(root, dir)
+	}
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return opts, errors.New("output_dir must be inside the output directory of the daemon")
+	}
+	opts.OutputDir = filepath.Join(root, rel)
+	return opts, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
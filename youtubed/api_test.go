@@ -0,0 +1,117 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kkdai/youtube"
+)
+
+func TestAPI(t *testing.T) {
+	queue, err := youtube.NewQueue(youtube.Options{}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(newAPI(queue, ""))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{name: "add job", method: http.MethodPost, path: "/jobs", body: `{"url": "rFejpH_tAHM", "options": {"itag": 18}}`, wantStatus: http.StatusCreated},
+		{name: "missing url", method: http.MethodPost, path: "/jobs", body: `{}`, wantStatus: http.StatusBadRequest},
+		{name: "invalid options", method: http.MethodPost, path: "/jobs", body: `{"url": "rFejpH_tAHM", "options": {"itag": 18, "quality": "medium"}}`, wantStatus: http.StatusBadRequest},
+		{name: "list jobs", method: http.MethodGet, path: "/jobs", wantStatus: http.StatusOK},
+		{name: "get job", method: http.MethodGet, path: "/jobs/1", wantStatus: http.StatusOK},
+		{name: "unknown job", method: http.MethodGet, path: "/jobs/2", wantStatus: http.StatusNotFound},
+		{name: "wrong method", method: http.MethodDelete, path: "/jobs", wantStatus: http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, server.URL+tt.path, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("%s %s status = %d, want %d", tt.method, tt.path, resp.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+
+	resp, err := http.Get(server.URL + "/jobs/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var job youtube.Job
+	if err := json.NewDecoder(resp.Body).Decode(&job); err != nil {
+		t.Fatal(err)
+	}
+	if job.URL != "rFejpH_tAHM" || job.Options.ItagNo != 18 || job.Status != youtube.JobQueued {
+		t.Errorf("GET /jobs/1 = %+v", job)
+	}
+
+	for _, want := range []int{http.StatusOK, http.StatusConflict} {
+		req, _ := http.NewRequest(http.MethodDelete, server.URL+"/jobs/1", nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("DELETE /jobs/1 status = %d, want %d", resp.StatusCode, want)
+		}
+	}
+	if job, _ := queue.Job(1); job.Status != youtube.JobCancelled || job.CancelReason != youtube.CancelUser {
+		t.Errorf("job = %+v, want cancelled by the user", job)
+	}
+}
+
+func TestConfineOptions(t *testing.T) {
+	root := filepath.Join(os.TempDir(), "mirror")
+	tests := []struct {
+		name    string
+		dir     string
+		want    string
+		wantErr bool
+	}{
+		{name: "default", dir: "", want: ""},
+		{name: "relative", dir: "music/album", want: filepath.Join(root, "music", "album")},
+		{name: "inside", dir: filepath.Join(root, "music"), want: filepath.Join(root, "music")},
+		{name: "root", dir: root, want: root},
+		{name: "escaping", dir: "../etc", wantErr: true},
+		{name: "outside", dir: os.TempDir(), wantErr: true},
+		{name: "sibling", dir: root + "2", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := confineOptions(youtube.DownloadOptions{OutputDir: tt.dir, Socks5Proxy: "10.10.10.10:7878"}, root)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("confineOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (got.OutputDir != tt.want || got.Socks5Proxy != "") {
+				t.Errorf("confineOptions() = %+v, want the output directory %q without proxy", got, tt.want)
+			}
+		})
+	}
+	if _, err := confineOptions(youtube.DownloadOptions{OutputDir: "music"}, ""); err == nil {
+		t.Error("confineOptions() accepted an output directory without root")
+	}
+}
@@ -0,0 +1,285 @@
+//go:build !metadataonly
+// +build !metadataonly
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/kkdai/youtube"
+	"github.com/kkdai/youtube/notify"
+	"github.com/kkdai/youtube/potoken"
+)
+
+const usageString string = `Usage: youtubed [OPTION]
+Run the youtube downloader as a background service, downloads are queued through a REST API.
+Example: curl -d '{"url": "https://www.youtube.com/watch?v=rFejpH_tAHM"}' http://127.0.0.1:8080/jobs
+`
+
+// shutdownTimeout bounds how long the API waits for the pending requests on stop.
+const shutdownTimeout = 10 * time.Second
+
+func main() {
+	flag.Usage = func() {
+		fmt.Print(usageString)
+		flag.PrintDefaults()
+	}
+	var listen string
+	flag.StringVar(&listen, "listen", "127.0.0.1:8080", "The address the REST API listens on")
+	var outputDir string
+	flag.StringVar(&outputDir, "d", "", "The output directory, $HOME/Movies/youtubedr by default")
+	var workers int
+	flag.IntVar(&workers, "workers", 2, "The number of parallel downloads")
+	var socks5Proxy string
+	flag.StringVar(&socks5Proxy, "p", "", "The Socks 5 proxy, e.g. 10.10.10.10:7878")
+	var rateLimit int64
+	flag.Int64Var(&rateLimit, "r", 0, "Limit the download speed in bytes per second, 0 means no limit")
+	var maxStreamSize int64
+	flag.Int64Var(&maxStreamSize, "max-size", 0, "Reject the streams larger than this size in bytes, 0 means no limit")
+	var jitter time.Duration
+	flag.DurationVar(&jitter, "jitter", 0, "Wait a random duration up to this one before each metadata request, to look less like a bot")
+	var jitterWindow int
+	flag.IntVar(&jitterWindow, "jitter-window", 0, "Pick the next download at random among this many queued ones")
+	var debug bool
+	flag.BoolVar(&debug, "v", false, "Log the details of the downloads")
+	var telegramToken, telegramChat string
+	flag.StringVar(&telegramToken, "telegram-token", "", "The token of the Telegram bot notifying the finished downloads")
+	flag.StringVar(&telegramChat, "telegram-chat", "", "The Telegram chat id the notifications are sent to")
+	var discordWebhook string
+	flag.StringVar(&discordWebhook, "discord-webhook", "", "The Discord webhook url notifying the finished downloads")
+	var smtpAddr, smtpUser, smtpPassword, smtpFrom, smtpTo string
+	flag.StringVar(&smtpAddr, "smtp-addr", "", "The host:port of the SMTP server emailing the finished downloads")
+	flag.StringVar(&smtpUser, "smtp-user", "", "The SMTP user name, no authentication when empty")
+	flag.StringVar(&smtpPassword, "smtp-password", "", "The SMTP password")
+	flag.StringVar(&smtpFrom, "smtp-from", "", "The sender of the emails")
+	flag.StringVar(&smtpTo, "smtp-to", "", "The comma separated recipients of the emails")
+	var smtpDigest time.Duration
+	flag.DurationVar(&smtpDigest, "smtp-digest", 0, "Email a digest of the downloads at this interval instead of one email per download")
+	var jellyfinURL, jellyfinKey string
+	flag.StringVar(&jellyfinURL, "jellyfin-url", "", "The url of the Jellyfin server whose libraries are scanned after each download")
+	flag.StringVar(&jellyfinKey, "jellyfin-key", "", "The Jellyfin API key")
+	var plexURL, plexToken, plexSection string
+	flag.StringVar(&plexURL, "plex-url", "", "The url of the Plex server whose library section is scanned after each download")
+	flag.StringVar(&plexToken, "plex-token", "", "The Plex token")
+	flag.StringVar(&plexSection, "plex-section", "", "The id of the Plex library section of the output directory")
+	var stateFile string
+	flag.StringVar(&stateFile, "state", "", "Restore the warm-up state (player, cipher plans, cookies) from this file at start and save it at exit")
+	var trashDir string
+	flag.StringVar(&trashDir, "trash", "", "Move the files replaced by a download to this directory instead of overwriting them")
+	var trashMaxAge time.Duration
+	flag.DurationVar(&trashMaxAge, "trash-max-age", 0, "Delete the files of the trash older than this, 0 keeps them")
+	var trashMaxSize int64
+	flag.Int64Var(&trashMaxSize, "trash-max-size", 0, "Delete the oldest files of the trash past this size in bytes, 0 means no limit")
+	var manifestFile string
+	flag.StringVar(&manifestFile, "manifest", "", "Write the SHA-256 manifest of the finished downloads in -d to this file at exit, see youtubedr -verify")
+	var archiveFile, upgrade string
+	flag.StringVar(&archiveFile, "archive", "", "Record the downloads in this file and skip the videos already recorded")
+	flag.StringVar(&upgrade, "upgrade", "never", "Download the archived videos again: never, better (when a better format is available) or always")
+	var poTokenURL, poTokenCommand string
+	flag.StringVar(&poTokenURL, "pot-url", "", "Ask the proof of origin tokens to the token server at this url, e.g. http://127.0.0.1:4416/get_pot")
+	flag.StringVar(&poTokenCommand, "pot-cmd", "", "Ask the proof of origin tokens to this command, reading the request as json on its input and printing the token")
+	var windows string
+	flag.StringVar(&windows, "windows", "", "Only download the media during these local time windows, such as 01:00-07:00,13:00-14:00")
+	var watchDir string
+	flag.StringVar(&watchDir, "watch", "", "Queue the links of the .url and .txt files dropped in this directory")
+	flag.Parse()
+	// under the Windows service manager, it must be reached early
+	stopRequests, stopped := listenStopRequests()
+	defer stopped()
+	if manifestFile != "" && outputDir == "" {
+		log.Fatalln("err: -manifest needs -d, the directory of the mirror")
+	}
+	if outputDir != "" {
+		// the output directories of the jobs are confined to it
+		abs, err := filepath.Abs(outputDir)
+		if err != nil {
+			log.Fatalln("err:", err)
+		}
+		outputDir = abs
+	}
+
+	// the state is imported into the session shared by the downloads of the queue
+	session := youtube.NewSession()
+	state, err := youtube.NewYoutubeWithOptions(youtube.Options{Socks5Proxy: socks5Proxy, Session: session})
+	if err != nil {
+		log.Fatalln("err:", err)
+	}
+	if stateFile != "" {
+		if err := loadState(state, stateFile); err != nil {
+			log.Println("err:", err)
+		}
+	}
+
+	var trash *youtube.Trash
+	if trashDir != "" {
+		trash = &youtube.Trash{Dir: trashDir, MaxAge: trashMaxAge, MaxBytes: trashMaxSize}
+	}
+	transferWindows, err := youtube.ParseTransferWindows(windows)
+	if err != nil {
+		log.Fatalln("err:", err)
+	}
+	var archive *youtube.Archive
+	if archiveFile != "" {
+		if archive, err = youtube.OpenArchive(archiveFile); err != nil {
+			log.Fatalln("err:", err)
+		}
+	}
+	queue, err := youtube.NewQueue(youtube.Options{
+		Session:         session,
+		DebugMode:       debug,
+		Socks5Proxy:     socks5Proxy,
+		RateLimit:       rateLimit,
+		OutputDir:       outputDir,
+		Limits:          youtube.Limits{MaxStreamSize: maxStreamSize},
+		Jitter:          youtube.Jitter{Delay: jitter, Window: jitterWindow},
+		Trash:           trash,
+		Archive:         archive,
+		Upgrade:         youtube.UpgradePolicy(upgrade),
+		TransferWindows: transferWindows,
+		POTokenProvider: potoken.New(poTokenURL, poTokenCommand),
+	}, workers)
+	if err != nil {
+		log.Fatalln("err:", err)
+	}
+	if telegramToken != "" {
+		queue.AddNotifier(&notify.Telegram{Token: telegramToken, ChatID: telegramChat})
+	}
+	if discordWebhook != "" {
+		queue.AddNotifier(&notify.Discord{WebhookURL: discordWebhook})
+	}
+
+	if jellyfinURL != "" {
+		queue.AddNotifier(&notify.Jellyfin{URL: jellyfinURL, APIKey: jellyfinKey, LibraryDir: outputDir})
+	}
+	if plexURL != "" {
+		queue.AddNotifier(&notify.Plex{URL: plexURL, Token: plexToken, SectionID: plexSection, LibraryDir: outputDir})
+	}
+
+	ctx, stop := context.WithCancel(context.Background())
+	// the digest outlives ctx to notify the jobs aborted by the shutdown
+	digestCtx, stopDigest := context.WithCancel(context.Background())
+	defer stopDigest()
+	var digestDone chan error
+	if smtpAddr != "" {
+		mailer := &notify.SMTP{Addr: smtpAddr, From: smtpFrom, To: strings.Split(smtpTo, ",")}
+		if smtpUser != "" {
+			host, _, _ := net.SplitHostPort(smtpAddr)
+			mailer.Auth = smtp.PlainAuth("", smtpUser, smtpPassword, host)
+		}
+		if smtpDigest > 0 {
+			digest := &notify.Batch{Notifier: mailer}
+			queue.AddNotifier(digest)
+			digestDone = make(chan error, 1)
+			go func() { digestDone <- digest.Run(digestCtx, smtpDigest) }()
+		} else {
+			queue.AddNotifier(mailer)
+		}
+	}
+	server := &http.Server{Addr: listen, Handler: newAPI(queue, outputDir)}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Println("err:", err)
+			stop()
+		}
+	}()
+
+	queueDone := make(chan struct{})
+	go func() {
+		defer close(queueDone)
+		queue.Run(ctx)
+	}()
+	if watchDir != "" {
+		watcher := &youtube.WatchFolder{Dir: watchDir, Queue: queue}
+		go func() {
+			if err := watcher.Run(ctx); err != nil && err != context.Canceled {
+				log.Println("err:", err)
+			}
+		}()
+	}
+	log.Println("youtubed listening on", listen)
+
+	select {
+	case reason := <-stopRequests:
+		log.Println("received", reason, "stopping")
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Println("err:", err)
+	}
+	stop()
+	<-queueDone
+	if digestDone != nil {
+		// Run flushes the jobs aborted by the shutdown as it stops
+		stopDigest()
+		if err := <-digestDone; err != nil {
+			log.Println("err:", err)
+		}
+	}
+	if stateFile != "" {
+		if err := saveState(state, stateFile); err != nil {
+			log.Println("err:", err)
+		}
+	}
+	if manifestFile != "" {
+		if err := writeManifest(queue, outputDir, manifestFile); err != nil {
+			log.Println("err:", err)
+		}
+	}
+	log.Println("youtubed stopped")
+}
+
+// signalStopRequests returns the channel receiving the name of the signal
+// stopping the daemon, SIGINT or SIGTERM.
+func signalStopRequests() <-chan string {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	requests := make(chan string, 1)
+	go func() { requests <- (<-signals).String() }()
+	return requests
+}
+
+// loadState imports the state saved at path, a missing file is a cold start.
+func loadState(y *youtube.Youtube, path string) error {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return y.ImportState(data)
+}
+
+// saveState exports the state to path, readable by the user only as it holds cookies.
+func saveState(y *youtube.Youtube, path string) error {
+	data, err := y.ExportState()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+// writeManifest writes the manifest of the finished downloads in dir to path.
+func writeManifest(queue *youtube.Queue, dir, path string) error {
+	manifest, err := queue.Manifest(dir)
+	if err != nil {
+		return err
+	}
+	return manifest.WriteFile(path)
+}
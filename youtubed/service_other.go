@@ -0,0 +1,10 @@
+//go:build !windows && !metadataonly
+// +build !windows,!metadataonly
+
+package main
+
+// listenStopRequests returns the channel receiving the reason to stop the
+// daemon, and the func to call once it stopped.
+func listenStopRequests() (<-chan string, func()) {
+	return signalStopRequests(), func() {}
+}
@@ -0,0 +1,148 @@
+//go:build windows && !metadataonly
+// +build windows,!metadataonly
+
+package main
+
+import (
+	"runtime"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// serviceName is the name youtubed is installed under with sc.exe.
+const serviceName = "youtubed"
+
+// The values of the service control manager API.
+const (
+	serviceWin32OwnProcess = 0x10
+
+	serviceStopped     = 1
+	serviceStopPending = 3
+	serviceRunning     = 4
+
+	serviceAcceptStop     = 1
+	serviceAcceptShutdown = 4
+
+	serviceControlStop        = 1
+	serviceControlInterrogate = 4
+	serviceControlShutdown    = 5
+
+	errorCallNotImplemented = 120
+)
+
+var (
+	advapi32                          = syscall.NewLazyDLL("advapi32.dll")
+	procStartServiceCtrlDispatcherW   = advapi32.NewProc("StartServiceCtrlDispatcherW")
+	procRegisterServiceCtrlHandlerExW = advapi32.NewProc("RegisterServiceCtrlHandlerExW")
+	procSetServiceStatus              = advapi32.NewProc("SetServiceStatus")
+)
+
+// serviceStatus is the SERVICE_STATUS structure.
+type serviceStatus struct {
+	serviceType             uint32
+	currentState            uint32
+	controlsAccepted        uint32
+	win32ExitCode           uint32
+	serviceSpecificExitCode uint32
+	checkPoint              uint32
+	waitHint                uint32
+}
+
+// serviceTableEntry is the SERVICE_TABLE_ENTRYW structure.
+type serviceTableEntry struct {
+	name *uint16
+	proc uintptr
+}
+
+// service is the daemon run by the Windows service control manager.
+type service struct {
+	name     *uint16
+	handle   uintptr
+	requests chan string
+	// started is closed once the manager started the service, done once
+	// the daemon stopped and exited once the manager was told so.
+	started chan struct{}
+	done    chan struct{}
+	exited  chan struct{}
+}
+
+// listenStopRequests returns the channel receiving the reason to stop the
+// daemon, and the func to call once it stopped. Run as a Windows service,
+// the daemon is stopped by the service manager, otherwise by a signal.
+func listenStopRequests() (<-chan string, func()) {
+	name, err := syscall.UTF16PtrFromString(serviceName)
+	if err != nil {
+		return signalStopRequests(), func() {}
+	}
+	s := &service{
+		name:     name,
+		requests: make(chan string, 1),
+		started:  make(chan struct{}),
+		done:     make(chan struct{}),
+		exited:   make(chan struct{}),
+	}
+	failed := make(chan struct{})
+	go func() {
+		// the control handler is called on the thread of the dispatcher
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+		defer close(s.exited)
+		table := []serviceTableEntry{{name: s.name, proc: syscall.NewCallback(s.main)}, {}}
+		// it returns once the service stopped, or at once outside of the service manager
+		r, _, _ := procStartServiceCtrlDispatcherW.Call(uintptr(unsafe.Pointer(&table[0])))
+		if r == 0 {
+			close(failed)
+		}
+	}()
+	select {
+	case <-s.started:
+		return s.requests, s.stop
+	case <-failed:
+		return signalStopRequests(), func() {}
+	}
+}
+
+// main is the ServiceMain of the service, it runs until the daemon stopped.
+func (s *service) main(argc, argv uintptr) uintptr {
+	s.handle, _, _ = procRegisterServiceCtrlHandlerExW.Call(uintptr(unsafe.Pointer(s.name)), syscall.NewCallback(s.control), 0)
+	s.setStatus(serviceRunning)
+	close(s.started)
+	<-s.done
+	s.setStatus(serviceStopped)
+	return 0
+}
+
+// control is the HandlerEx of the service.
+func (s *service) control(control, eventType, eventData, context uintptr) uintptr {
+	switch control {
+	case serviceControlStop, serviceControlShutdown:
+		s.setStatus(serviceStopPending)
+		select {
+		case s.requests <- "service stop":
+		default:
+		}
+		return 0
+	case serviceControlInterrogate:
+		return 0
+	}
+	return errorCallNotImplemented
+}
+
+func (s *service) setStatus(state uint32) {
+	status := serviceStatus{serviceType: serviceWin32OwnProcess, currentState: state}
+	switch state {
+	case serviceRunning:
+		status.controlsAccepted = serviceAcceptStop | serviceAcceptShutdown
+	case serviceStopPending:
+		// the pending requests and the downloads are given shutdownTimeout each
+		status.waitHint = uint32(2 * shutdownTimeout / time.Millisecond)
+	}
+	procSetServiceStatus.Call(s.handle, uintptr(unsafe.Pointer(&status)))
+}
+
+// stop tells the service manager the daemon stopped.
+func (s *service) stop() {
+	close(s.done)
+	<-s.exited
+}
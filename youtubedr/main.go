@@ -1,14 +1,23 @@
+//go:build !metadataonly
+// +build !metadataonly
+
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
 	"os/user"
 	"path/filepath"
+	"strings"
+	"time"
 
 	. "github.com/kkdai/youtube"
+	"github.com/kkdai/youtube/player"
+	"github.com/kkdai/youtube/potoken"
 )
 
 const usageString string = `Usage: youtubedr [OPTION] [URL]
@@ -18,7 +27,7 @@ Example: youtubedr -o "Campaign Diary".mp4 https://www.youtube.com/watch\?v\=XbN
 
 func main() {
 	flag.Usage = func() {
-		fmt.Println(usageString)
+		fmt.Print(usageString)
 		flag.PrintDefaults()
 	}
 	usr, _ := user.Current()
@@ -37,23 +46,254 @@ func main() {
 	var itag int
 	flag.IntVar(&itag, "i", 0, "Specify itag number, e.g. 13, 17")
 
+	var rateLimit int64
+	flag.Int64Var(&rateLimit, "r", 0, "Limit the download speed in bytes per second, 0 means no limit")
+
 	var itags bool
 	flag.BoolVar(&itags, "itags", false, "list available itags of video")
 
+	var choose bool
+	flag.BoolVar(&choose, "choose", false, "prompt for the format to download when neither -q nor -i is given")
+
+	var printURL bool
+	flag.BoolVar(&printURL, "url", false, "print the url of the stream and the headers to open it with instead of downloading it")
+
+	var play bool
+	flag.BoolVar(&play, "play", false, "watch the video with mpv or vlc instead of downloading it")
+
+	var tracks bool
+	flag.BoolVar(&tracks, "tracks", false, "print the video ids of the tracks of a youtube music album, one per line")
+
+	var downloadAlbum bool
+	flag.BoolVar(&downloadAlbum, "album", false, "download the tracks of a youtube music album, one after the other")
+
+	var planFile, runPlan string
+	flag.StringVar(&planFile, "plan", "", "write the download plan (video ids, itags, paths and options) to this json file instead of downloading, alone or with -album")
+	flag.StringVar(&runPlan, "run", "", "download the plan of this json file, written by -plan, verbatim")
+
+	var preview int
+	flag.IntVar(&preview, "preview", 0, "with -tracks, list the first tracks only, up to this many, and tell whether more are left")
+
+	var images bool
+	flag.BoolVar(&images, "images", false, "download the images of a community post url along with a json sidecar")
+
+	var stateFile string
+	flag.StringVar(&stateFile, "state", "", "restore the warm-up state (player, cipher plans, cookies) from this file and save it back, to speed up the next runs")
+
+	var playerKind string
+	flag.StringVar(&playerKind, "player", "", "the player used by -play: mpv, vlc or quicktime, the first one installed by default")
+
+	var filesystem string
+	flag.StringVar(&filesystem, "fs", "", "name the files after the restrictions of the target filesystem: ext4, ntfs, exfat, smb or ascii")
+
+	var fixExtension bool
+	flag.BoolVar(&fixExtension, "fix-ext", false, "rename the downloaded file when its content is another container than its extension says")
+
+	var verify string
+	flag.StringVar(&verify, "verify", "", "check the files of the mirror in the output directory against this manifest, written by youtubed -manifest")
+
+	var audit string
+	flag.StringVar(&audit, "audit", "", "check the videos of this archive, written by youtubed -archive, against youtube without downloading and list the retitled, reuploaded and removed ones")
+
+	var poTokenURL, poTokenCommand string
+	flag.StringVar(&poTokenURL, "pot-url", "", "ask the proof of origin tokens to the token server at this url, e.g. http://127.0.0.1:4416/get_pot")
+	flag.StringVar(&poTokenCommand, "pot-cmd", "", "ask the proof of origin tokens to this command, reading the request as json on its input and printing the token")
+
+	var showVersion bool
+	flag.BoolVar(&showVersion, "version", false, "print the version and the supported capabilities")
+
 	flag.Parse()
 
-	if len(flag.Args()) == 0 {
+	if showVersion {
+		caps := Capabilities()
+		fmt.Printf("Version: %s (commit: %s, built: %s)\n", caps.Version, caps.Commit, caps.BuildTime)
+		fmt.Printf("HLS: %t, DASH: %t, Merge: %t, Cipher: %t\n", caps.HLS, caps.DASH, caps.Merge, caps.Cipher)
+		fmt.Printf("Auth modes: %s\n", strings.Join(caps.AuthModes, ", "))
+		return
+	}
+
+	if verify != "" {
+		manifest, err := ReadManifest(verify)
+		if err == nil {
+			err = Verify(outputDir, manifest)
+		}
+		if damaged, ok := err.(ErrMirrorDamaged); ok {
+			for _, path := range damaged.Missing {
+				fmt.Println("missing:", path)
+			}
+			for _, path := range damaged.Corrupted {
+				fmt.Println("corrupted:", path)
+			}
+		}
+		if err != nil {
+			fmt.Println("err:", err)
+			os.Exit(1)
+		}
+		fmt.Printf("%d items, %d bytes verified\n", manifest.ItemCount, manifest.TotalSize)
+		return
+	}
+
+	if audit != "" {
+		archive, err := OpenArchive(audit)
+		if err != nil {
+			fmt.Println("err:", err)
+			os.Exit(1)
+		}
+		y, err := NewYoutubeWithOptions(Options{Socks5Proxy: socks5Proxy, POTokenProvider: potoken.New(poTokenURL, poTokenCommand)})
+		if err != nil {
+			fmt.Println("err:", err)
+			os.Exit(1)
+		}
+		results, err := y.AuditArchive(context.Background(), archive)
+		counts := make(map[AuditStatus]int)
+		for _, result := range results {
+			counts[result.Status]++
+			switch result.Status {
+			case AuditOK:
+			case AuditRetitled:
+				fmt.Printf("%s: %s %q is now %q\n", result.Status, result.Entry.VideoID, result.Entry.Title, result.Title)
+			case AuditReuploaded:
+				fmt.Printf("%s: %s %q lasted %s, now %s\n", result.Status, result.Entry.VideoID, result.Entry.Title, result.Entry.Duration, result.Duration)
+			case AuditMissing:
+				fmt.Printf("%s: %s %s\n", result.Status, result.Entry.VideoID, result.Entry.Path)
+			default:
+				fmt.Printf("%s: %s %q: %s\n", result.Status, result.Entry.VideoID, result.Entry.Title, result.Error)
+			}
+		}
+		if err != nil {
+			fmt.Println("err:", err)
+			os.Exit(1)
+		}
+		fmt.Printf("%d videos audited, %d unchanged\n", len(results), counts[AuditOK])
+		if counts[AuditOK] != len(results) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(flag.Args()) == 0 && runPlan == "" {
 		flag.PrintDefaults()
 		os.Exit(1)
 	}
 
 	log.Println("download to dir=", outputDir)
-	y := NewYoutubeWithSocks5Proxy(true, socks5Proxy)
+	y, err := NewYoutubeWithOptions(Options{
+		DebugMode:       true,
+		Socks5Proxy:     socks5Proxy,
+		RateLimit:       rateLimit,
+		OutputDir:       outputDir,
+		Quality:         outputQuality,
+		ItagNo:          itag,
+		Filesystem:      Filesystem(filesystem),
+		POTokenProvider: potoken.New(poTokenURL, poTokenCommand),
+	})
+	if err != nil {
+		fmt.Println("err:", err)
+		os.Exit(1)
+	}
+	if choose {
+		y.ChooseFormat = promptFormat
+	}
+	if stateFile != "" {
+		if err := loadState(y, stateFile); err != nil {
+			log.Println("err:", err)
+		}
+		defer func() {
+			if err := saveState(y, stateFile); err != nil {
+				log.Println("err:", err)
+			}
+		}()
+	}
 	if len(y.Socks5Proxy) == 0 {
 		log.Println("Using http without proxy.")
 	}
 	arg := flag.Arg(0)
-	if err := y.DecodeURL(arg); err != nil {
+	if runPlan != "" {
+		plan, err := ReadPlan(runPlan)
+		if err != nil {
+			fmt.Println("err:", err)
+			os.Exit(1)
+		}
+		logItems(y, len(plan.Items))
+		results, err := y.ExecutePlan(context.Background(), plan)
+		if err != nil {
+			fmt.Println("err:", err)
+			os.Exit(1)
+		}
+		exitOnFailures(results)
+		return
+	}
+	if tracks {
+		y.OnProgress = func(p Progress) {
+			if p.Total > 0 {
+				log.Printf("listed %d of %d tracks, %d pages", p.Items, p.Total, p.Pages)
+			} else {
+				log.Printf("listed %d tracks, %d pages", p.Items, p.Pages)
+			}
+		}
+		album, err := y.PreviewMusicAlbum(context.Background(), arg, Budget{MaxItems: preview})
+		if err != nil {
+			fmt.Println("err:", err)
+			os.Exit(1)
+		}
+		for _, id := range album.VideoIDs() {
+			fmt.Println(id)
+		}
+		if album.More && album.Total > 0 {
+			log.Printf("first %d of ~%d tracks", len(album.Tracks), album.Total)
+		} else if album.More {
+			log.Printf("first %d tracks, more are left", len(album.Tracks))
+		}
+		return
+	}
+	if downloadAlbum {
+		album, err := y.MusicAlbum(context.Background(), arg)
+		if err != nil {
+			fmt.Println("err:", err)
+			os.Exit(1)
+		}
+		items := album.Items()
+		if planFile != "" {
+			plan, err := y.PlanList(context.Background(), items, DownloadOptions{FixExtension: fixExtension})
+			if err == nil {
+				err = plan.WriteFile(planFile)
+			}
+			if err != nil {
+				fmt.Println("err:", err)
+				os.Exit(1)
+			}
+			for _, failure := range plan.Failed {
+				log.Printf("not planned: %s: %s", failure.VideoID, failure.Error)
+			}
+			log.Printf("%d of %d tracks planned in %s", len(plan.Items), len(items), planFile)
+			return
+		}
+		logItems(y, len(items))
+		results, err := y.DownloadList(context.Background(), items, DownloadOptions{FixExtension: fixExtension})
+		if err != nil {
+			fmt.Println("err:", err)
+			os.Exit(1)
+		}
+		exitOnFailures(results)
+		return
+	}
+	if images {
+		post, err := y.CommunityPost(context.Background(), arg)
+		if err != nil {
+			fmt.Println("err:", err)
+			os.Exit(1)
+		}
+		paths, err := y.DownloadPostImages(context.Background(), post, outputDir)
+		for _, path := range paths {
+			fmt.Println(path)
+		}
+		if err != nil {
+			fmt.Println("err:", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if err := y.DecodeURLWithContext(context.Background(), arg); err != nil {
 		fmt.Println("err:", err)
 		return
 	}
@@ -70,11 +310,113 @@ func main() {
 		for _, itag := range info.Itags {
 			fmt.Printf("itag: %2d , quality: %6s , type: %10s\n", itag.ItagNo, itag.Quality, itag.Type)
 		}
+	} else if printURL || play {
+		playable, err := y.ResolvePlayable(DownloadOptions{})
+		if err != nil {
+			fmt.Println("err:", err)
+			return
+		}
+		if play {
+			p, err := player.NewWithOptions(player.Options{Kind: player.Kind(playerKind)})
+			if err == nil {
+				err = p.Play(context.Background(), playable)
+			}
+			if err != nil {
+				fmt.Println("err:", err)
+			}
+			return
+		}
+		fmt.Println(playable.URL)
+		if playable.AudioURL != "" {
+			fmt.Println(playable.AudioURL)
+		}
+		for name := range playable.Header {
+			fmt.Printf("%s: %s\n", name, playable.Header.Get(name))
+		}
+	} else if planFile != "" {
+		planned, err := y.PlanDownload(DownloadOptions{OutputFile: outputFile, FixExtension: fixExtension})
+		if err == nil {
+			err = NewPlan(planned).WriteFile(planFile)
+		}
+		if err != nil {
+			fmt.Println("err:", err)
+			os.Exit(1)
+		}
+		log.Printf("itag %d to %s planned in %s", planned.Format.ItagNo, planned.Path, planFile)
 	} else {
-		err := y.StartDownload(outputDir, outputFile, outputQuality, itag)
+		err := y.StartDownloadWithOptions(context.Background(), DownloadOptions{OutputFile: outputFile, FixExtension: fixExtension})
 		if err != nil {
 			fmt.Println("err:", err)
 		}
 	}
+}
+
+// logItems logs the lifecycle of each of the n items of a list download.
+func logItems(y *Youtube, n int) {
+	y.OnItemStart = func(item ListItem) {
+		log.Printf("[%d/%d] %s %s", item.Index+1, n, item.VideoID, item.Title)
+	}
+	y.OnItemSkipped = func(r ListItemResult) {
+		log.Printf("[%d/%d] skipped, %s", r.Item.Index+1, n, r.Skipped)
+	}
+	y.OnItemComplete = func(r ListItemResult) {
+		if r.Err != nil {
+			log.Printf("[%d/%d] failed: %s", r.Item.Index+1, n, r.Err)
+			return
+		}
+		log.Printf("[%d/%d] %s in %s", r.Item.Index+1, n, r.Path, r.Elapsed.Round(time.Second))
+	}
+}
+
+// exitOnFailures exits with an error status when an item of a list download failed.
+func exitOnFailures(results []ListItemResult) {
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		fmt.Printf("err: %d of %d items failed\n", failed, len(results))
+		os.Exit(1)
+	}
+}
 
+// loadState imports the state saved at path, a missing file is a cold start.
+func loadState(y *Youtube, path string) error {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return y.ImportState(data)
+}
+
+// saveState exports the state to path, readable by the user only as it holds cookies.
+func saveState(y *Youtube, path string) error {
+	data, err := y.ExportState()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+// promptFormat lists the formats and reads the index of the chosen one on stdin.
+func promptFormat(formats []Format) (int, error) {
+	fmt.Println("-----available formats-----")
+	for i, format := range formats {
+		kind := "audio+video"
+		if format.Adaptive {
+			kind = "adaptive"
+		}
+		fmt.Printf("%2d) itag: %3d , quality: %6s , %s , type: %s\n", i, format.ItagNo, format.Quality, kind, format.MimeType)
+	}
+	fmt.Print("format to download: ")
+	var index int
+	if _, err := fmt.Scanln(&index); err != nil {
+		return 0, err
+	}
+	return index, nil
 }